@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"gym_management/config"
 	"gym_management/internal/handlers"
+	"gym_management/internal/mailer"
 	"gym_management/internal/models"
+	"gym_management/internal/scheduler"
 	"gym_management/internal/service"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -19,19 +23,159 @@ func InitialSetup() {
 	if config.DB == nil {
 		log.Fatal("Database connection not established")
 	}
-	// Create PostgreSQL ENUM Type
-	if err := config.DB.Exec("CREATE TYPE IF NOT EXISTS role_enum AS ENUM ('admin', 'staff', 'member')").Error; err != nil {
-		// Log error jika ENUM tidak dapat dibuat (terutama jika sudah ada)
-		log.Println("Role ENUM setup complete or error:", err)
+	// Kolom users.role dulu bertipe ENUM tetap; ubah ke varchar agar role baru bisa dibuat
+	MigrateRoleColumn()
+
+	// Sequence untuk nomor member (M000001, M000002, ...)
+	if err := config.DB.Exec("CREATE SEQUENCE IF NOT EXISTS member_number_seq").Error; err != nil {
+		log.Println("Member number sequence setup error:", err)
 	}
 
+	// User lama (sebelum ada verifikasi email) dianggap sudah terverifikasi
+	backfillEmailVerified := !config.DB.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	// Auto Migrate Tables
-	config.DB.AutoMigrate(&models.User{}, &models.GymPackage{}, &models.Attendance{})
+	config.DB.AutoMigrate(&models.User{}, &models.GymPackage{}, &models.Attendance{}, &models.Membership{}, &models.MembershipFreeze{}, &models.Payment{},
+		&models.Notification{}, &models.JobRun{}, &models.Device{}, &models.AccessCard{}, &models.ClassType{}, &models.ClassSchedule{}, &models.ClassSession{}, &models.Booking{},
+		&models.Measurement{}, &models.AccountEntry{}, &models.PTCredit{}, &models.TrainerSlot{}, &models.PTAppointment{},
+		&models.CommissionRule{}, &models.StaffShift{}, &models.TimeClockEntry{}, &models.Permission{}, &models.Role{}, &models.AuditLog{},
+		&models.PasswordResetToken{}, &models.RecoveryCode{}, &models.LoginThrottle{}, &models.Session{}, &models.UsedCheckInToken{})
 	log.Println("Database tables auto-migrated successfully.")
 
+	// Refresh token kini disimpan per sesi di tabel sessions; kolom lama di users tidak dipakai lagi
+	if config.DB.Migrator().HasColumn("users", "refresh_token") {
+		if err := config.DB.Migrator().DropColumn("users", "refresh_token"); err != nil {
+			log.Println("Gagal menghapus kolom users.refresh_token:", err)
+		}
+	}
+
+	BackfillMemberships()
+	BackfillMemberNumbers()
+	if backfillEmailVerified {
+		BackfillEmailVerified()
+	}
+	BackfillTOTPEncryption()
+	ScrubSentSecrets()
+
+	// Katalog permission & pemetaan default role admin/staff/member
+	if err := service.NewRoleService().SeedDefaults(); err != nil {
+		log.Println("Role seed error:", err)
+	}
+
 	SeedData()
 }
 
+// MigrateRoleColumn mengubah users.role dari role_enum ke varchar (nilai lama tetap) lalu
+// menghapus tipe ENUM-nya. Tidak melakukan apa pun jika sudah dimigrasi atau tabel belum ada.
+func MigrateRoleColumn() {
+	var udtName string
+	config.DB.Raw(`
+		SELECT udt_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'role'
+	`).Scan(&udtName)
+	if udtName != "role_enum" {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			"ALTER TABLE users ALTER COLUMN role DROP DEFAULT",
+			"ALTER TABLE users ALTER COLUMN role TYPE varchar(50) USING role::text",
+			"ALTER TABLE users ALTER COLUMN role SET DEFAULT 'member'",
+			"DROP TYPE IF EXISTS role_enum",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Role column migration error:", err)
+		return
+	}
+	log.Println("Role column migrated from role_enum to varchar.")
+}
+
+// BackfillMemberships membuat langganan untuk member lama yang hanya memiliki package_id.
+// Periode dihitung dari tanggal member dibuat, sehingga member yang paketnya sudah lewat
+// harus diperpanjang oleh staff.
+func BackfillMemberships() {
+	result := config.DB.Exec(`
+		INSERT INTO memberships (user_id, package_id, start_date, end_date, status, created_at, updated_at)
+		SELECT u.id, u.package_id, u.created_at, u.created_at + gp.duration_days * INTERVAL '1 day', 'active', NOW(), NOW()
+		FROM users u
+		INNER JOIN gym_packages gp ON gp.id = u.package_id
+		WHERE u.role = 'member' AND NOT EXISTS (SELECT 1 FROM memberships m WHERE m.user_id = u.id)
+	`)
+	if result.Error != nil {
+		log.Println("Membership backfill error:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Membership backfill: %d langganan dibuat.", result.RowsAffected)
+	}
+}
+
+// BackfillMemberNumbers memberi nomor member kepada member lama yang belum memilikinya.
+func BackfillMemberNumbers() {
+	result := config.DB.Exec(`
+		UPDATE users SET member_number = 'M' || lpad(nextval('member_number_seq')::text, 6, '0')
+		WHERE role = 'member' AND member_number IS NULL
+	`)
+	if result.Error != nil {
+		log.Println("Member number backfill error:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Member number backfill: %d member diberi nomor.", result.RowsAffected)
+	}
+}
+
+// BackfillEmailVerified menandai semua user yang sudah ada sebelum fitur verifikasi email sebagai terverifikasi.
+func BackfillEmailVerified() {
+	result := config.DB.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`)
+	if result.Error != nil {
+		log.Println("Email verification backfill error:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Email verification backfill: %d user ditandai terverifikasi.", result.RowsAffected)
+	}
+}
+
+// BackfillTOTPEncryption mengenkripsi secret 2FA yang tersimpan sebagai teks biasa sebelum enkripsi diterapkan.
+func BackfillTOTPEncryption() {
+	count, err := service.NewTwoFactorService().EncryptLegacySecrets()
+	if err != nil {
+		log.Println("2FA secret encryption backfill error:", err)
+	}
+	if count > 0 {
+		log.Printf("2FA secret encryption backfill: %d secret dienkripsi.", count)
+	}
+}
+
+// ScrubSentSecrets mengosongkan body email reset password & verifikasi yang tersimpan sebelum
+// notifikasi sensitif dikosongkan otomatis setelah dikirim.
+func ScrubSentSecrets() {
+	types := []string{models.NotificationTypePasswordReset, models.NotificationTypeEmailVerify}
+	if err := config.DB.Model(&models.Notification{}).Where("type IN ? AND sensitive = ?", types, false).
+		Update("sensitive", true).Error; err != nil {
+		log.Println("Notification scrub error:", err)
+		return
+	}
+	result := config.DB.Model(&models.Notification{}).
+		Where("sensitive = ? AND status <> ? AND body <> ''", true, models.NotificationStatusPending).
+		Update("body", "")
+	if result.Error != nil {
+		log.Println("Notification scrub error:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Notification scrub: %d body email sensitif dikosongkan.", result.RowsAffected)
+	}
+}
+
 // SeedData inserts initial users and packages if they don't exist.
 func SeedData() {
 	if config.DB == nil {
@@ -41,12 +185,19 @@ func SeedData() {
 	staffService := service.NewStaffService()
 
 	// 1. Cek dan Buat Paket
-	monthlyPkg := models.GymPackage{Name: "Bulanan", Price: 300000.00, DurationDays: 30, Benefits: "Akses 30 hari"}
+	monthlyPkg := models.GymPackage{Name: "Bulanan", Price: 300000.00, DurationDays: 30, Benefits: "Akses 30 hari", MaxFreezes: 1}
 	config.DB.Where(models.GymPackage{Name: "Bulanan"}).FirstOrCreate(&monthlyPkg)
 
-	yearlyPkg := models.GymPackage{Name: "Tahunan", Price: 3000000.00, DurationDays: 365, Benefits: "Akses 1 tahun, gratis loker khusus"}
+	yearlyPkg := models.GymPackage{Name: "Tahunan", Price: 3000000.00, DurationDays: 365, Benefits: "Akses 1 tahun, gratis loker khusus", MaxFreezeDays: 60, MaxFreezes: 2}
 	config.DB.Where(models.GymPackage{Name: "Tahunan"}).FirstOrCreate(&yearlyPkg)
 
+	offPeakPkg := models.GymPackage{Name: "Off-Peak", Price: 200000.00, DurationDays: 30, Benefits: "Akses hari kerja jam 10:00-16:00",
+		AllowedDays: []int{1, 2, 3, 4, 5}, AccessWindows: []models.TimeWindow{{Start: "10:00", End: "16:00"}}}
+	config.DB.Where(models.GymPackage{Name: "Off-Peak"}).FirstOrCreate(&offPeakPkg)
+
+	visitPkg := models.GymPackage{Name: "10x Visit", Price: 250000.00, DurationDays: 60, Benefits: "10 kali kunjungan dalam 60 hari", MaxVisits: 10}
+	config.DB.Where(models.GymPackage{Name: "10x Visit"}).FirstOrCreate(&visitPkg)
+
 	// 2. Cek dan Buat Admin User
 	var adminUser models.User
 	if err := config.DB.Where("email = ?", "admin@gym.com").First(&adminUser).Error; errors.Is(err, gorm.ErrRecordNotFound) {
@@ -58,7 +209,7 @@ func SeedData() {
 		}
 
 		// Buat Admin (menggunakan service yang mengizinkan role assignment)
-		staffService.CreateStaff(adminInput, "admin")
+		staffService.CreateStaff(adminInput, "admin", service.SystemActor)
 		log.Println("Seed Data: Admin user created.")
 
 		// Buat Staff
@@ -67,7 +218,7 @@ func SeedData() {
 			Email:    "staff@gym.com",
 			Password: "securepassword",
 		}
-		staffService.CreateStaff(staffInput, "staff")
+		staffService.CreateStaff(staffInput, "staff", service.SystemActor)
 		log.Println("Seed Data: Staff user created.")
 
 		// Buat Member
@@ -79,18 +230,137 @@ func SeedData() {
 			PackageID:   &monthlyPkg.ID, // Gunakan ID paket yang sudah dibuat
 		}
 		// RegisterMemberService mengembalikan token, kita hanya ingin membuat user-nya di sini
-		service.RegisterMemberService(memberInput)
+		if member, _, _, err := service.RegisterMemberService(memberInput, service.SystemActor, service.SessionClient{}); err == nil {
+			// Akun contoh langsung dianggap terverifikasi agar bisa dipakai mencoba semua fitur member
+			config.DB.Model(member).Update("email_verified_at", time.Now())
+		}
 		log.Println("Seed Data: Member user created.")
 	}
 }
 
+// StartScheduler mendaftarkan job latar belakang. Aman dijalankan di banyak replika:
+// setiap job dilindungi advisory lock Postgres dan waktu run terakhir disimpan di tabel job_runs.
+// Notifikasi dikirim lewat sender (lihat mailer.FromEnv).
+func StartScheduler(ctx context.Context, sender mailer.Sender) {
+	if !config.GetEnvBool("SCHEDULER_ENABLED", true) {
+		log.Println("Scheduler dinonaktifkan (SCHEDULER_ENABLED=false).")
+		return
+	}
+
+	jobs := scheduler.New(config.DB, scheduler.SystemClock{}, config.Location())
+	membershipService := service.NewMembershipService()
+
+	// Nonaktifkan member yang langganannya sudah lewat
+	jobs.Daily("expire-memberships", config.GetEnvClock("MEMBERSHIP_JOB_AT", "00:05"), func(now time.Time) error {
+		count, err := membershipService.ExpireMemberships(now)
+		if count > 0 {
+			log.Printf("Scheduler: %d langganan kadaluarsa.", count)
+		}
+		return err
+	})
+
+	// Antrekan pengingat perpanjangan N hari sebelum langganan berakhir
+	reminderDays := config.GetEnvInt("RENEWAL_REMINDER_DAYS", 7)
+	jobs.Daily("renewal-reminders", config.GetEnvClock("MEMBERSHIP_JOB_AT", "00:05"), func(now time.Time) error {
+		count, err := membershipService.QueueRenewalReminders(now, reminderDays)
+		if count > 0 {
+			log.Printf("Scheduler: %d pengingat perpanjangan diantrekan.", count)
+		}
+		return err
+	})
+
+	// Auto Check-Out pada jam tutup untuk member yang lupa Check-Out (termasuk sesi lama yang tertinggal)
+	jobs.Daily("auto-checkout", service.GetOpeningHours().Close, func(now time.Time) error {
+		count, err := service.AutoCheckOut(now)
+		if count > 0 {
+			log.Printf("Scheduler: %d absensi ditutup otomatis.", count)
+		}
+		return err
+	})
+
+	// Buat sesi kelas dari jadwal berulang untuk beberapa minggu ke depan
+	classService := service.NewClassService()
+	jobs.Daily("materialize-class-sessions", config.GetEnvClock("CLASS_MATERIALIZE_AT", "00:15"), func(now time.Time) error {
+		count, err := classService.MaterializeSessions(now)
+		if count > 0 {
+			log.Printf("Scheduler: %d sesi kelas dibuat dari jadwal berulang.", count)
+		}
+		return err
+	})
+
+	// Tandai hadir / no-show untuk kelas yang sudah dimulai
+	jobs.Every("class-attendance", 5*time.Minute, func(now time.Time) error {
+		count, err := classService.MarkClassAttendance(now)
+		if count > 0 {
+			log.Printf("Scheduler: kehadiran %d sesi kelas ditandai.", count)
+		}
+		return err
+	})
+
+	// Hapus jti QR Check-In yang sudah kedaluwarsa
+	jobs.Daily("purge-checkin-tokens", config.GetEnvClock("CHECKIN_TOKEN_PURGE_AT", "03:00"), func(now time.Time) error {
+		count, err := service.PurgeUsedCheckInTokens(now)
+		if count > 0 {
+			log.Printf("Scheduler: %d QR Check-In terpakai dihapus.", count)
+		}
+		return err
+	})
+
+	// Hapus hitungan login gagal yang sudah tidak berpengaruh
+	loginThrottleService := service.NewLoginThrottleService()
+	jobs.Daily("purge-login-throttles", config.GetEnvClock("LOGIN_THROTTLE_PURGE_AT", "03:00"), func(now time.Time) error {
+		count, err := loginThrottleService.PurgeStale(now)
+		if count > 0 {
+			log.Printf("Scheduler: %d hitungan login gagal dihapus.", count)
+		}
+		return err
+	})
+
+	// Hapus riwayat sesi yang sudah kedaluwarsa/dicabut
+	sessionService := service.NewSessionService()
+	jobs.Daily("purge-sessions", config.GetEnvClock("SESSION_PURGE_AT", "03:00"), func(now time.Time) error {
+		count, err := sessionService.PurgeStale(now, config.GetEnvInt("SESSION_RETENTION_DAYS", 30))
+		if count > 0 {
+			log.Printf("Scheduler: %d sesi lama dihapus.", count)
+		}
+		return err
+	})
+
+	// Kirim notifikasi di outbox (pengingat, verifikasi email, reset password, ...) lewat MAIL_DRIVER
+	notificationService := service.NewNotificationService()
+	jobs.Every("dispatch-notifications", time.Minute, func(now time.Time) error {
+		count, err := notificationService.DispatchPending(now, sender)
+		if count > 0 {
+			log.Printf("Scheduler: %d notifikasi terkirim.", count)
+		}
+		return err
+	})
+
+	jobs.Start(ctx)
+	log.Println("Scheduler started.")
+}
+
 func main() {
 	godotenv.Load()
 	config.ConnectDatabase()
+	if _, err := service.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	if err := service.CheckTokenSecrets(); err != nil {
+		log.Fatalf("Failed to load token secrets: %v", err)
+	}
+	sender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
 	InitialSetup() // Jalankan Migrasi dan Seeding
+	StartScheduler(context.Background(), sender)
 
 	router := gin.Default()
 
+	// Public key access token untuk layanan lain (RFC 7517)
+	router.GET("/.well-known/jwks.json", handlers.JWKSHandler)
+
 	// Public Routes
 	auth := router.Group("/api/auth")
 	{
@@ -98,65 +368,186 @@ func main() {
 		auth.POST("/register", handlers.RegisterMemberHandler)
 		// Tambahkan Refresh Token
 		auth.POST("/refresh-token", handlers.RefreshTokenHandler)
+		auth.POST("/forgot-password", handlers.ForgotPasswordHandler)
+		auth.POST("/reset-password", handlers.ResetPasswordHandler)
+
+		// Verifikasi email, dibatasi per IP (EMAIL_VERIFICATION_RATE_LIMIT per EMAIL_VERIFICATION_RATE_WINDOW_MINUTES)
+		verifyLimit := config.GetEnvInt("EMAIL_VERIFICATION_RATE_LIMIT", 10)
+		verifyWindow := time.Duration(config.GetEnvInt("EMAIL_VERIFICATION_RATE_WINDOW_MINUTES", 15)) * time.Minute
+		verifyRate := handlers.RateLimit(verifyLimit, verifyWindow)
+		auth.GET("/verify-email", verifyRate, handlers.VerifyEmailHandler)
+		auth.POST("/verify-email", verifyRate, handlers.VerifyEmailHandler)
+		auth.POST("/resend-verification", handlers.RateLimit(verifyLimit, verifyWindow), handlers.ResendVerificationHandler)
+
+		// Langkah kedua login untuk akun dengan 2FA (TWO_FACTOR_RATE_LIMIT percobaan per 15 menit per IP)
+		auth.POST("/2fa/verify", handlers.RateLimit(config.GetEnvInt("TWO_FACTOR_RATE_LIMIT", 10), 15*time.Minute), handlers.VerifyTwoFactorLoginHandler)
 	}
 
 	// Protected Routes Group
+	// Setiap endpoint dijaga permission (lihat RoleService untuk katalog & pemetaan default role)
 	api := router.Group("/api")
 	api.Use(handlers.AuthMiddleware())
 	{
+		perm := handlers.RequirePermission
+
 		// Logout route moved to protected group
-		api.POST("/auth/logout", handlers.LogoutHandler)
-		// === ADMIN ONLY Routes ===
-		admin := api.Group("/")
-		admin.Use(handlers.RoleMiddleware("admin"))
-		{
-			// Member Delete
-			admin.DELETE("/members/:id", handlers.DeleteMemberHandler)
-
-			// Package CRUD (Full)
-			admin.POST("/packages", handlers.CreatePackageHandler)
-			admin.PUT("/packages/:id", handlers.UpdatePackageHandler)
-			admin.DELETE("/packages/:id", handlers.DeletePackageHandler)
-
-			// Staff CRUD (Full)
-			admin.POST("/staff", handlers.CreateStaffHandler)
-			admin.PUT("/staff/:id", handlers.UpdateStaffHandler)
-			admin.DELETE("/staff/:id", handlers.DeleteStaffHandler)
-
-			// Dashboard
-			admin.GET("/dashboard/stats", handlers.GetStatsHandler)
-		}
+		api.POST("/auth/logout", handlers.RequireUser(), handlers.LogoutHandler)
+		api.POST("/auth/change-password", handlers.RequireUser(), handlers.ChangePasswordHandler)
+		api.GET("/auth/sessions", handlers.RequireUser(), handlers.GetSessionsHandler)
+		api.DELETE("/auth/sessions/:id", handlers.RequireUser(), handlers.RevokeSessionHandler)
 
-		// === ADMIN & STAFF Routes ===
-		adminStaff := api.Group("/")
-		adminStaff.Use(handlers.RoleMiddleware("admin", "staff"))
-		{
-			// Member Management (Read & Create/Update)
-			adminStaff.GET("/members", handlers.GetMembersHandler)
-			adminStaff.POST("/members", handlers.CreateMemberHandler)
-			adminStaff.PUT("/members/:id", handlers.UpdateMemberHandler)
-
-			// Attendance Operations
-			adminStaff.POST("/attendance/checkin", handlers.CheckInHandler)
-			adminStaff.POST("/attendance/checkout", handlers.CheckOutHandler)
-			adminStaff.GET("/attendance/history", handlers.GetAllHistoryHandler)
-
-			// Staff Read (Staff juga perlu melihat daftar staff)
-			adminStaff.GET("/staff", handlers.GetStaffHandler)
-		}
+		// Two-factor authentication (TOTP). Tidak memakai permission agar user yang role-nya
+		// mewajibkan 2FA tetap bisa melakukan enrollment.
+		api.GET("/auth/2fa", handlers.RequireUser(), handlers.GetTwoFactorStatusHandler)
+		api.POST("/auth/2fa/enroll", handlers.RequireUser(), handlers.EnrollTwoFactorHandler)
+		api.POST("/auth/2fa/confirm", handlers.RequireUser(), handlers.ConfirmTwoFactorHandler)
+		api.POST("/auth/2fa/disable", handlers.RequireUser(), handlers.DisableTwoFactorHandler)
+		api.POST("/auth/2fa/recovery-codes", handlers.RequireUser(), handlers.RegenerateRecoveryCodesHandler)
 
-		// === PUBLIC (Authenticated) Routes ===
-		// Paket bisa diakses oleh semua role yang sudah login
-		api.GET("/packages", handlers.GetPackagesHandler)
-
-		// === MEMBER ONLY Routes ===
-		member := api.Group("/")
-		member.Use(handlers.RoleMiddleware("member"))
-		{
-			// Member self-service
-			member.GET("/attendance/my-history", handlers.GetMyHistoryHandler)
-			// member.GET("/member/profile", handlers.GetProfileHandler)
-		}
+		// Member Management
+		api.GET("/members", perm(models.PermMembersRead), handlers.GetMembersHandler)
+		api.POST("/members", perm(models.PermMembersCreate), handlers.CreateMemberHandler)
+		api.PUT("/members/:id", perm(models.PermMembersUpdate), handlers.UpdateMemberHandler)
+		api.DELETE("/members/:id", perm(models.PermMembersDelete), handlers.DeleteMemberHandler)
+		api.DELETE("/members/:id/sessions", perm(models.PermSessionsManage), handlers.RevokeMemberSessionsHandler)
+
+		// Membership (Langganan)
+		api.GET("/members/:id/memberships", perm(models.PermMembersRead), handlers.GetMembershipsHandler)
+		api.POST("/members/:id/memberships", perm(models.PermMembershipsManage), handlers.CreateMembershipHandler)
+		api.GET("/members/:id/freezes", perm(models.PermMembersRead), handlers.GetFreezesHandler)
+		api.POST("/members/:id/freeze", perm(models.PermMembershipsManage), handlers.FreezeMembershipHandler)
+		api.POST("/members/:id/unfreeze", perm(models.PermMembershipsManage), handlers.UnfreezeMembershipHandler)
+
+		// Kartu RFID/NFC
+		api.GET("/members/:id/cards", perm(models.PermMembersRead), handlers.GetAccessCardsHandler)
+		api.POST("/members/:id/cards", perm(models.PermCardsManage), handlers.CreateAccessCardHandler)
+		api.PUT("/members/:id/cards/:cardId", perm(models.PermCardsManage), handlers.UpdateAccessCardHandler)
+
+		// Asesmen fisik (berat, komposisi tubuh, lingkar, tekanan darah)
+		api.GET("/members/:id/measurements", perm(models.PermMeasurementsRead), handlers.GetMeasurementsHandler)
+		api.POST("/members/:id/measurements", perm(models.PermMeasurementsManage), handlers.CreateMeasurementHandler)
+		api.DELETE("/members/:id/measurements/:measurementId", perm(models.PermMeasurementsManage), handlers.DeleteMeasurementHandler)
+
+		// Attendance History
+		api.GET("/attendance/history", perm(models.PermAttendanceRead), handlers.GetAllHistoryHandler)
+		api.GET("/attendance/occupancy", perm(models.PermAttendanceRead), handlers.GetOccupancyHandler)
+		api.GET("/attendance/occupancy/stream", perm(models.PermAttendanceRead), handlers.StreamOccupancyHandler)
+
+		// Check-In/Check-Out (staff & kiosk). Device (kiosk) hanya memiliki permission ini.
+		api.POST("/attendance/checkin", perm(models.PermAttendanceCheckIn), handlers.CheckInHandler)
+		api.POST("/attendance/checkin/qr", perm(models.PermAttendanceCheckIn), handlers.QRCheckInHandler)
+		api.POST("/attendance/checkout", perm(models.PermAttendanceCheckIn), handlers.CheckOutHandler)
+
+		// Payments (Transaksi)
+		api.GET("/payments", perm(models.PermPaymentsRead), handlers.GetPaymentsHandler)
+		api.POST("/payments", perm(models.PermPaymentsCreate), handlers.CreatePaymentHandler)
+		api.POST("/payments/:id/void", perm(models.PermPaymentsVoid), handlers.VoidPaymentHandler)
+
+		// Tagihan member (biaya, denda, pelunasan)
+		api.GET("/members/:id/account", perm(models.PermAccountsRead), handlers.GetAccountHandler)
+		api.POST("/members/:id/account/entries", perm(models.PermAccountsManage), handlers.CreateAccountEntryHandler)
+		api.POST("/members/:id/account/settle", perm(models.PermAccountsManage), handlers.SettleAccountHandler)
+
+		// Paket
+		api.GET("/packages", perm(models.PermPackagesRead), handlers.GetPackagesHandler)
+		api.POST("/packages", perm(models.PermPackagesManage), handlers.CreatePackageHandler)
+		api.PUT("/packages/:id", perm(models.PermPackagesManage), handlers.UpdatePackageHandler)
+		api.DELETE("/packages/:id", perm(models.PermPackagesManage), handlers.DeletePackageHandler)
+
+		// Staff
+		api.GET("/staff", perm(models.PermStaffRead), handlers.GetStaffHandler)
+		api.POST("/staff", perm(models.PermStaffManage), handlers.CreateStaffHandler)
+		api.PUT("/staff/:id", perm(models.PermStaffManage), handlers.UpdateStaffHandler)
+		api.DELETE("/staff/:id", perm(models.PermStaffManage), handlers.DeleteStaffHandler)
+		api.PUT("/staff/:id/trainer", perm(models.PermStaffManage), handlers.SetTrainerHandler)
+		api.PUT("/staff/:id/role", perm(models.PermRolesManage), handlers.SetStaffRoleHandler)
+		api.DELETE("/staff/:id/2fa", perm(models.PermStaffManage), handlers.ResetTwoFactorHandler)
+		api.DELETE("/staff/:id/sessions", perm(models.PermStaffManage), handlers.RevokeStaffSessionsHandler)
+
+		// Role & Permission
+		api.GET("/roles", perm(models.PermRolesManage), handlers.GetRolesHandler)
+		api.POST("/roles", perm(models.PermRolesManage), handlers.CreateRoleHandler)
+		api.PUT("/roles/:name", perm(models.PermRolesManage), handlers.UpdateRoleHandler)
+		api.DELETE("/roles/:name", perm(models.PermRolesManage), handlers.DeleteRoleHandler)
+		api.PUT("/roles/:name/two-factor", perm(models.PermRolesManage), handlers.SetRoleTwoFactorHandler)
+		api.GET("/permissions", perm(models.PermRolesManage), handlers.GetPermissionsHandler)
+
+		// Blokir login (brute-force)
+		api.GET("/login-lockouts", perm(models.PermLoginLockoutsManage), handlers.GetLoginLockoutsHandler)
+		api.POST("/login-lockouts/unlock", perm(models.PermLoginLockoutsManage), handlers.UnlockLoginHandler)
+
+		// Audit Log
+		api.GET("/audit-logs", perm(models.PermAuditRead), handlers.GetAuditLogsHandler)
+
+		// Dashboard & laporan
+		api.GET("/dashboard/stats", perm(models.PermDashboardRead), handlers.GetStatsHandler)
+		api.GET("/dashboard/commissions", perm(models.PermCommissionsManage), handlers.GetCommissionReportHandler)
+		api.GET("/dashboard/shifts", perm(models.PermShiftsManage), handlers.GetShiftReportHandler)
+
+		// Aturan komisi trainer/instruktur
+		api.GET("/commission-rules", perm(models.PermCommissionsManage), handlers.GetCommissionRulesHandler)
+		api.POST("/commission-rules", perm(models.PermCommissionsManage), handlers.CreateCommissionRuleHandler)
+		api.PUT("/commission-rules/:id", perm(models.PermCommissionsManage), handlers.UpdateCommissionRuleHandler)
+		api.DELETE("/commission-rules/:id", perm(models.PermCommissionsManage), handlers.DeleteCommissionRuleHandler)
+
+		// Kiosk Devices (API key)
+		api.GET("/devices", perm(models.PermDevicesManage), handlers.GetDevicesHandler)
+		api.POST("/devices", perm(models.PermDevicesManage), handlers.CreateDeviceHandler)
+		api.DELETE("/devices/:id", perm(models.PermDevicesManage), handlers.RevokeDeviceHandler)
+
+		// Roster shift, clock-in/out & koreksi
+		api.GET("/shifts", perm(models.PermShiftsRead), handlers.GetShiftsHandler)
+		api.POST("/shifts", perm(models.PermShiftsManage), handlers.CreateShiftHandler)
+		api.POST("/shifts/copy-week", perm(models.PermShiftsManage), handlers.CopyShiftWeekHandler)
+		api.PUT("/shifts/:id", perm(models.PermShiftsManage), handlers.UpdateShiftHandler)
+		api.DELETE("/shifts/:id", perm(models.PermShiftsManage), handlers.DeleteShiftHandler)
+		api.GET("/timeclock", perm(models.PermShiftsManage), handlers.GetTimeClockHandler)
+		api.PUT("/timeclock/:id", perm(models.PermShiftsManage), handlers.AdjustTimeClockHandler)
+		api.POST("/timeclock/clock-in", perm(models.PermTimeClockUse), handlers.ClockInHandler)
+		api.POST("/timeclock/clock-out", perm(models.PermTimeClockUse), handlers.ClockOutHandler)
+		api.GET("/timeclock/me", perm(models.PermTimeClockUse), handlers.GetMyTimeClockHandler)
+
+		// Kelas
+		api.GET("/classes/types", perm(models.PermClassesRead), handlers.GetClassTypesHandler)
+		api.POST("/classes/types", perm(models.PermClassTypesManage), handlers.CreateClassTypeHandler)
+		api.PUT("/classes/types/:id", perm(models.PermClassTypesManage), handlers.UpdateClassTypeHandler)
+		api.GET("/classes/sessions", perm(models.PermClassesRead), handlers.GetClassSessionsHandler)
+		api.GET("/classes/sessions/:id", perm(models.PermClassesRead), handlers.GetClassSessionHandler)
+		api.POST("/classes/sessions", perm(models.PermClassSessionsManage), handlers.CreateClassSessionHandler)
+		api.PUT("/classes/sessions/:id", perm(models.PermClassSessionsManage), handlers.UpdateClassSessionHandler)
+		api.POST("/classes/sessions/:id/cancel", perm(models.PermClassSessionsManage), handlers.CancelClassSessionHandler)
+		api.GET("/classes/sessions/:id/bookings", perm(models.PermClassSessionsManage), handlers.GetSessionBookingsHandler)
+		api.PUT("/classes/sessions/:id/delivered-by", perm(models.PermClassSessionsManage), handlers.SetClassDeliveredByHandler)
+		api.GET("/classes/schedules", perm(models.PermClassSessionsManage), handlers.GetClassSchedulesHandler)
+		api.POST("/classes/schedules", perm(models.PermClassSessionsManage), handlers.CreateClassScheduleHandler)
+		api.DELETE("/classes/schedules/:id", perm(models.PermClassSessionsManage), handlers.DeactivateClassScheduleHandler)
+		api.POST("/classes/schedules/:id/cancel-occurrence", perm(models.PermClassSessionsManage), handlers.CancelClassOccurrenceHandler)
+		api.POST("/classes/sessions/:id/book", perm(models.PermClassesBook), handlers.BookClassSessionHandler)
+		api.POST("/classes/bookings/:id/cancel", perm(models.PermClassesBook), handlers.CancelBookingHandler)
+		api.GET("/classes/my-bookings", perm(models.PermClassesBook), handlers.GetMyBookingsHandler)
+
+		// Personal Training (kredit sesi, slot trainer, janji)
+		api.GET("/trainers", perm(models.PermPTRead), handlers.GetTrainersHandler)
+		api.GET("/pt/slots", perm(models.PermPTRead), handlers.GetTrainerSlotsHandler)
+		api.POST("/pt/slots", perm(models.PermPTSlotsManage), handlers.CreateTrainerSlotHandler)
+		api.DELETE("/pt/slots/:id", perm(models.PermPTSlotsManage), handlers.CancelTrainerSlotHandler)
+		api.GET("/members/:id/pt-credits", perm(models.PermPTCreditsManage), handlers.GetPTCreditsHandler)
+		api.POST("/members/:id/pt-credits", perm(models.PermPTCreditsManage), handlers.CreatePTCreditHandler)
+		api.GET("/pt/appointments", perm(models.PermPTAppointmentsManage), handlers.GetPTAppointmentsHandler)
+		api.POST("/pt/appointments", perm(models.PermPTAppointmentsManage), handlers.CreatePTAppointmentHandler)
+		api.POST("/pt/appointments/:id/complete", perm(models.PermPTAppointmentsManage), handlers.CompletePTAppointmentHandler)
+		api.POST("/pt/appointments/:id/cancel", perm(models.PermPTAppointmentsCancel), handlers.CancelPTAppointmentHandler)
+		api.POST("/pt/slots/:id/book", perm(models.PermPTBook), handlers.BookTrainerSlotHandler)
+		api.GET("/pt/my-appointments", perm(models.PermPTBook), handlers.GetMyPTAppointmentsHandler)
+		api.GET("/member/pt-credits", perm(models.PermPTBook), handlers.GetMyPTCreditsHandler)
+
+		// Member self-service
+		api.GET("/attendance/my-history", perm(models.PermSelfPortal), handlers.GetMyHistoryHandler)
+		api.GET("/measurements/my-history", perm(models.PermSelfPortal), handlers.GetMyMeasurementsHandler)
+		api.GET("/member/card", perm(models.PermSelfPortal), handlers.GetMyCardHandler)
+		api.GET("/member/card.png", perm(models.PermSelfPortal), handlers.GetMyCardPNGHandler)
+		api.GET("/member/account", perm(models.PermSelfPortal), handlers.GetMyAccountHandler)
+		// api.GET("/member/profile", perm(models.PermSelfPortal), handlers.GetProfileHandler)
 	}
 
 	// Server Start
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	location     *time.Location
+	locationOnce sync.Once
+)
+
+// Location mengembalikan zona waktu operasional gym (APP_TIMEZONE, default Asia/Jakarta).
+// Semua batas hari (hari ini, jam buka, jadwal job) dihitung dalam zona ini.
+func Location() *time.Location {
+	locationOnce.Do(func() {
+		name := os.Getenv("APP_TIMEZONE")
+		if name == "" {
+			name = "Asia/Jakarta"
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Zona waktu %q tidak valid, menggunakan UTC: %v", name, err)
+			loc = time.UTC
+		}
+		location = loc
+	})
+	return location
+}
+
+// StartOfDay mengembalikan tengah malam (zona waktu gym) untuk hari yang memuat `t`.
+func StartOfDay(t time.Time) time.Time {
+	local := t.In(Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Location())
+}
+
+// GetEnvInt membaca variabel environment bertipe integer dengan nilai default.
+func GetEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Nilai %s tidak valid (%q), menggunakan default %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// GetEnvFloat membaca variabel environment bertipe desimal dengan nilai default.
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Nilai %s tidak valid (%q), menggunakan default %g", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// GetEnvBool membaca variabel environment bertipe boolean dengan nilai default.
+func GetEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Printf("Nilai %s tidak valid (%q), menggunakan default %t", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// GetEnvClock membaca jam dalam format HH:MM dan mengembalikannya sebagai offset dari tengah malam.
+func GetEnvClock(key string, defaultValue string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		log.Printf("Nilai %s tidak valid (%q), menggunakan default %s", key, value, defaultValue)
+		t, _ = time.Parse("15:04", defaultValue)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var accessCardService = service.NewAccessCardService()
+
+// GetAccessCardsHandler @route GET /api/members/:id/cards (Admin/Staff)
+func GetAccessCardsHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	cards, err := accessCardService.GetCards(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data kartu."})
+		return
+	}
+	c.JSON(http.StatusOK, cards)
+}
+
+// CreateAccessCardHandler @route POST /api/members/:id/cards (Admin/Staff)
+func CreateAccessCardHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.AccessCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	card, err := accessCardService.IssueCard(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Kartu berhasil didaftarkan.", "card": card})
+}
+
+// UpdateAccessCardHandler @route PUT /api/members/:id/cards/:cardId (Admin/Staff)
+// Menandai kartu hilang/nonaktif atau mengaktifkannya kembali.
+func UpdateAccessCardHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+	cardID, err := uuid.Parse(c.Param("cardId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID kartu tidak valid."})
+		return
+	}
+
+	var input models.AccessCardStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status kartu tidak valid.", "details": err.Error()})
+		return
+	}
+
+	card, err := accessCardService.UpdateCardStatus(memberID, cardID, input.Status, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status kartu berhasil diperbarui.", "card": card})
+}
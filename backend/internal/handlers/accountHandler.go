@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var accountService = service.NewAccountService()
+
+// GetAccountHandler @route GET /api/members/:id/account (Admin/Staff)
+func GetAccountHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	statement, err := accountService.GetStatement(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil tagihan member."})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+// CreateAccountEntryHandler @route POST /api/members/:id/account/entries (Admin/Staff)
+// Tagihan (type=charge) atau potongan (type=credit), mis. biaya loker, ganti kartu, denda.
+func CreateAccountEntryHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.AccountEntryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	entry, err := accountService.PostEntry(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// SettleAccountHandler @route POST /api/members/:id/account/settle (Admin/Staff)
+func SettleAccountHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.SettleAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	payment, statement, err := accountService.Settle(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Pembayaran tagihan tercatat.", "payment": payment, "account": statement})
+}
+
+// GetMyAccountHandler @route GET /api/member/account (Member Only)
+func GetMyAccountHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	statement, err := accountService.GetStatement(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil tagihan."})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
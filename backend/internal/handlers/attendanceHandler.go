@@ -1,26 +1,32 @@
 package handlers
 
 import (
+	"gym_management/internal/models"
 	"gym_management/internal/service"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// AttendanceInput: Member dikenali lewat salah satu dari email, kartu RFID/NFC, nomor telepon, atau nomor member
 type AttendanceInput struct {
-	MemberEmail string `json:"memberEmail" binding:"required,email"`
+	models.MemberIdentifier
+	// Staff boleh memasukkan member di luar jam operasional (ENFORCE_OPENING_HOURS)
+	OverrideOpeningHours bool `json:"overrideOpeningHours"`
 }
 
-// CheckInHandler @route POST /api/attendance/checkin (Staff Only)
+// CheckInHandler @route POST /api/attendance/checkin (Staff/Kiosk)
 func CheckInHandler(c *gin.Context) {
 	var input AttendanceInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email member diperlukan."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Identitas member tidak valid.", "details": err.Error()})
 		return
 	}
 
-	attendance, err := service.CheckInMember(input.MemberEmail)
+	attendance, err := service.CheckInMember(input.MemberIdentifier, input.OverrideOpeningHours, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -32,15 +38,41 @@ func CheckInHandler(c *gin.Context) {
 	})
 }
 
-// CheckOutHandler @route POST /api/attendance/checkout (Staff Only)
+// QRCheckInInput: Payload hasil scan QR kartu member
+type QRCheckInInput struct {
+	Payload              string `json:"payload" binding:"required"`
+	OverrideOpeningHours bool   `json:"overrideOpeningHours"`
+}
+
+// QRCheckInHandler @route POST /api/attendance/checkin/qr (Staff/Kiosk)
+func QRCheckInHandler(c *gin.Context) {
+	var input QRCheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload QR diperlukan."})
+		return
+	}
+
+	attendance, err := service.CheckInMemberByQR(input.Payload, input.OverrideOpeningHours, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    attendance.User.Name + " berhasil Check-In!",
+		"attendance": attendance,
+	})
+}
+
+// CheckOutHandler @route POST /api/attendance/checkout (Staff/Kiosk)
 func CheckOutHandler(c *gin.Context) {
 	var input AttendanceInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email member diperlukan."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Identitas member tidak valid.", "details": err.Error()})
 		return
 	}
 
-	attendance, err := service.CheckOutMember(input.MemberEmail)
+	attendance, err := service.CheckOutMember(input.MemberIdentifier, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -81,3 +113,48 @@ func GetAllHistoryHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, history)
 }
+
+// GetOccupancyHandler @route GET /api/attendance/occupancy (Admin/Staff Only)
+func GetOccupancyHandler(c *gin.Context) {
+	occupancy, err := service.GetOccupancy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data okupansi."})
+		return
+	}
+	c.JSON(http.StatusOK, occupancy)
+}
+
+// StreamOccupancyHandler @route GET /api/attendance/occupancy/stream (Admin/Staff Only)
+// Server-Sent Events untuk layar meja depan. Event "occupancy" dikirim setiap ada
+// Check-In/Check-Out, serta berkala agar perubahan dari replika lain ikut terlihat.
+func StreamOccupancyHandler(c *gin.Context) {
+	updates, unsubscribe := service.SubscribeOccupancy()
+	defer unsubscribe()
+
+	refresh := time.NewTicker(15 * time.Second)
+	defer refresh.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	if occupancy, err := service.GetOccupancy(); err == nil {
+		c.SSEvent("occupancy", occupancy)
+		c.Writer.Flush()
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case occupancy := <-updates:
+			c.SSEvent("occupancy", occupancy)
+		case <-refresh.C:
+			occupancy, err := service.GetOccupancy()
+			if err != nil {
+				return true
+			}
+			c.SSEvent("occupancy", occupancy)
+		}
+		return true
+	})
+}
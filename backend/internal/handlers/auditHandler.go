@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var auditService = service.NewAuditService()
+
+// GetAuditLogsHandler @route GET /api/audit-logs (Admin Only)
+// Query: actor_id, action, entity_type, entity_id, date_from, date_to, limit
+func GetAuditLogsHandler(c *gin.Context) {
+	logs, err := auditService.GetLogs(
+		c.Query("actor_id"),
+		c.Query("action"),
+		c.Query("entity_type"),
+		c.Query("entity_id"),
+		c.Query("date_from"),
+		c.Query("date_to"),
+		c.Query("limit"),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil audit log."})
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"gym_management/internal/models"
 	"gym_management/internal/service"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -18,15 +20,20 @@ func RegisterMemberHandler(c *gin.Context) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := service.RegisterMemberService(input)
+	user, accessToken, refreshToken, err := service.RegisterMemberService(input, currentActor(c), sessionClient(c, ""))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	message := "Registrasi berhasil. Silakan cek email Anda untuk verifikasi."
+	if accessToken == "" {
+		message = "Registrasi berhasil. Silakan verifikasi email Anda sebelum login."
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message":      "Registrasi berhasil.",
-		"user":         gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role},
+		"message":      message,
+		"user":         gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role, "emailVerified": user.EmailVerifiedAt != nil},
 		"accessToken":  accessToken,
 		"refreshToken": refreshToken,
 	})
@@ -40,21 +47,35 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := service.LoginService(input.Email, input.Password)
+	result, err := service.LoginService(input.Email, input.Password, sessionClient(c, input.DeviceName))
+	if abortIfLoginLocked(c, err) {
+		return
+	}
 	if err != nil {
 		status := http.StatusUnauthorized
-		if strings.Contains(err.Error(), "aktif") {
+		if strings.Contains(err.Error(), "aktif") || strings.Contains(err.Error(), "verifikasi") {
 			status = http.StatusForbidden
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Langkah kedua: kirim kode TOTP bersama challengeToken ke /api/auth/2fa/verify
+	if result.ChallengeToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "Masukkan kode 2FA untuk menyelesaikan login.",
+			"twoFactorRequired": true,
+			"challengeToken":    result.ChallengeToken,
+		})
+		return
+	}
+
+	user := result.User
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Login berhasil.",
-		"user":         gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role},
-		"accessToken":  accessToken,
-		"refreshToken": refreshToken,
+		"user":         gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role, "emailVerified": user.EmailVerifiedAt != nil},
+		"accessToken":  result.AccessToken,
+		"refreshToken": result.RefreshToken,
 	})
 }
 
@@ -67,7 +88,7 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := service.RefreshTokenService(input.RefreshToken)
+	accessToken, refreshToken, err := service.RefreshTokenService(input.RefreshToken, sessionClient(c, ""))
 	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
@@ -85,10 +106,130 @@ func RefreshTokenHandler(c *gin.Context) {
 func LogoutHandler(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	if err := service.LogoutService(userID); err != nil {
+	if err := service.LogoutService(userID, currentSessionID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal logout."})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logout berhasil."})
 }
+
+// @route POST /api/auth/forgot-password
+// @access Public
+func ForgotPasswordHandler(c *gin.Context) {
+	var input models.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	if err := service.ForgotPasswordService(input.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Respons sama untuk email terdaftar maupun tidak
+	c.JSON(http.StatusOK, gin.H{"message": "Jika email terdaftar, instruksi reset password telah dikirim."})
+}
+
+// @route POST /api/auth/reset-password
+// @access Public (with reset token)
+func ResetPasswordHandler(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	if err := service.ResetPasswordService(input.Token, input.NewPassword, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password berhasil direset. Silakan login kembali."})
+}
+
+// @route POST /api/auth/change-password
+// @access Protected (any role)
+func ChangePasswordHandler(c *gin.Context) {
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	if err := service.ChangePasswordService(userID, input.CurrentPassword, input.NewPassword, currentActor(c)); err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "gagal") {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password berhasil diubah. Silakan login kembali."})
+}
+
+// @route GET|POST /api/auth/verify-email
+// @access Public (with verification token; GET dari tautan email, POST dengan body JSON)
+func VerifyEmailHandler(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var input models.VerifyEmailInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Token verifikasi diperlukan."})
+			return
+		}
+		token = input.Token
+	}
+
+	if err := service.VerifyEmailService(token, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email berhasil diverifikasi."})
+}
+
+// @route POST /api/auth/resend-verification
+// @access Public
+func ResendVerificationHandler(c *gin.Context) {
+	var input models.ResendVerificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	if err := service.ResendVerificationService(input.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Respons sama untuk email terdaftar maupun tidak
+	c.JSON(http.StatusOK, gin.H{"message": "Jika email terdaftar dan belum diverifikasi, email verifikasi telah dikirim."})
+}
+
+// abortIfLoginLocked menjawab 429 + Retry-After jika login ditolak karena terlalu banyak percobaan gagal
+func abortIfLoginLocked(c *gin.Context, err error) bool {
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(locked.RetrySeconds()))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": locked.Error(), "retryAfter": locked.RetrySeconds()})
+	return true
+}
+
+// @route GET /.well-known/jwks.json
+// @access Public
+func JWKSHandler(c *gin.Context) {
+	jwks, err := service.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kunci JWT belum tersedia."})
+		return
+	}
+	// Cache singkat agar kunci baru hasil rotasi cepat terlihat oleh layanan lain
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
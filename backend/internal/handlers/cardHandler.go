@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"gym_management/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetMyCardHandler @route GET /api/member/card (Member Only)
+// Payload QR berumur pendek; aplikasi member harus memintanya ulang sebelum ExpiresAt.
+func GetMyCardHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	card, err := service.GenerateMemberCard(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+// GetMyCardPNGHandler @route GET /api/member/card.png?size=256 (Member Only)
+func GetMyCardPNGHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "256"))
+	if err != nil || size < 128 || size > 1024 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ukuran QR harus antara 128 dan 1024."})
+		return
+	}
+
+	png, card, err := service.GenerateMemberCardPNG(userID, size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Card-Expires-At", card.ExpiresAt.UTC().Format("2006-01-02T15:04:05Z07:00"))
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var classService = service.NewClassService()
+
+// --- Jenis Kelas ---
+
+// GetClassTypesHandler @route GET /api/classes/types (Authenticated)
+func GetClassTypesHandler(c *gin.Context) {
+	types, err := classService.GetClassTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jenis kelas."})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+// CreateClassTypeHandler @route POST /api/classes/types (Admin Only)
+func CreateClassTypeHandler(c *gin.Context) {
+	var input models.ClassTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	classType, err := classService.CreateClassType(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, classType)
+}
+
+// UpdateClassTypeHandler @route PUT /api/classes/types/:id (Admin Only)
+func UpdateClassTypeHandler(c *gin.Context) {
+	typeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID jenis kelas tidak valid."})
+		return
+	}
+
+	var input models.ClassTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	classType, err := classService.UpdateClassType(uint(typeID), input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Jenis kelas berhasil diperbarui.", "classType": classType})
+}
+
+// --- Jadwal Sesi ---
+
+// GetClassSessionsHandler @route GET /api/classes/sessions (Authenticated)
+// Query: date_from, date_to, class_type_id, instructor_id
+func GetClassSessionsHandler(c *gin.Context) {
+	sessions, err := classService.GetSessions(c.Query("date_from"), c.Query("date_to"), c.Query("class_type_id"), c.Query("instructor_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jadwal kelas."})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetClassSessionHandler @route GET /api/classes/sessions/:id (Authenticated)
+func GetClassSessionHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	session, err := classService.GetSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// CreateClassSessionHandler @route POST /api/classes/sessions (Admin/Staff)
+func CreateClassSessionHandler(c *gin.Context) {
+	var input models.ClassSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	session, err := classService.CreateSession(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// UpdateClassSessionHandler @route PUT /api/classes/sessions/:id (Admin/Staff)
+func UpdateClassSessionHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	var input models.UpdateClassSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	session, err := classService.UpdateSession(sessionID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Jadwal kelas berhasil diperbarui.", "session": session})
+}
+
+// CancelClassSessionHandler @route POST /api/classes/sessions/:id/cancel (Admin/Staff)
+func CancelClassSessionHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	var input models.CancelClassSessionInput
+	// Body opsional (alasan pembatalan)
+	_ = c.ShouldBindJSON(&input)
+
+	if err := classService.CancelSession(sessionID, input.Reason, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi kelas berhasil dibatalkan."})
+}
+
+// GetSessionBookingsHandler @route GET /api/classes/sessions/:id/bookings (Admin/Staff)
+func GetSessionBookingsHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	bookings, err := classService.GetSessionBookings(sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, bookings)
+}
+
+// --- Booking (Member) ---
+
+// BookClassSessionHandler @route POST /api/classes/sessions/:id/book (Member Only)
+func BookClassSessionHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	booking, err := classService.BookSession(sessionID, userID, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if booking.Status == models.BookingStatusWaitlisted {
+		c.JSON(http.StatusCreated, gin.H{
+			"message": fmt.Sprintf("Kelas penuh. Anda masuk daftar tunggu (urutan %d).", booking.WaitlistPosition),
+			"booking": booking,
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Booking kelas berhasil.", "booking": booking})
+}
+
+// CancelBookingHandler @route POST /api/classes/bookings/:id/cancel (Member Only)
+func CancelBookingHandler(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID booking tidak valid."})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	booking, err := classService.CancelBooking(bookingID, userID, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking kelas dibatalkan.", "booking": booking})
+}
+
+// GetMyBookingsHandler @route GET /api/classes/my-bookings (Member Only)
+func GetMyBookingsHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	bookings, err := classService.GetMyBookings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data booking."})
+		return
+	}
+	c.JSON(http.StatusOK, bookings)
+}
+
+// --- Jadwal Berulang ---
+
+// GetClassSchedulesHandler @route GET /api/classes/schedules (Admin/Staff)
+func GetClassSchedulesHandler(c *gin.Context) {
+	schedules, err := classService.GetSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jadwal berulang."})
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateClassScheduleHandler @route POST /api/classes/schedules (Admin/Staff)
+// Contoh rrule: "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261231"
+func CreateClassScheduleHandler(c *gin.Context) {
+	var input models.ClassScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	schedule, created, err := classService.CreateSchedule(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"schedule": schedule, "sessionsCreated": created})
+}
+
+// DeactivateClassScheduleHandler @route DELETE /api/classes/schedules/:id (Admin/Staff)
+func DeactivateClassScheduleHandler(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID jadwal tidak valid."})
+		return
+	}
+
+	if err := classService.DeactivateSchedule(scheduleID, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Jadwal berulang dihentikan dan sesi mendatang dibatalkan."})
+}
+
+// CancelClassOccurrenceHandler @route POST /api/classes/schedules/:id/cancel-occurrence (Admin/Staff)
+func CancelClassOccurrenceHandler(c *gin.Context) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID jadwal tidak valid."})
+		return
+	}
+
+	var input models.CancelOccurrenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tanggal kelas yang dibatalkan diperlukan."})
+		return
+	}
+
+	session, err := classService.CancelOccurrence(scheduleID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Kelas pada tanggal tersebut dibatalkan.", "session": session})
+}
+
+// SetClassDeliveredByHandler @route PUT /api/classes/sessions/:id/delivered-by (Admin/Staff)
+// Mencatat instruktur pengganti yang benar-benar mengajar sesi
+func SetClassDeliveredByHandler(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi kelas tidak valid."})
+		return
+	}
+
+	var input models.DeliveredByInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	session, err := classService.SetDeliveredBy(sessionID, input.StaffID, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Instruktur sesi kelas berhasil dicatat.", "session": session})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var commissionService = service.NewCommissionService()
+
+// GetCommissionRulesHandler @route GET /api/commission-rules (Admin Only)
+func GetCommissionRulesHandler(c *gin.Context) {
+	rules, err := commissionService.GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil aturan komisi."})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateCommissionRuleHandler @route POST /api/commission-rules (Admin Only)
+func CreateCommissionRuleHandler(c *gin.Context) {
+	var input models.CommissionRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	rule, err := commissionService.CreateRule(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateCommissionRuleHandler @route PUT /api/commission-rules/:id (Admin Only)
+func UpdateCommissionRuleHandler(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID aturan komisi tidak valid."})
+		return
+	}
+
+	var input models.CommissionRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	rule, err := commissionService.UpdateRule(uint(ruleID), input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan komisi berhasil diperbarui.", "rule": rule})
+}
+
+// DeleteCommissionRuleHandler @route DELETE /api/commission-rules/:id (Admin Only)
+func DeleteCommissionRuleHandler(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID aturan komisi tidak valid."})
+		return
+	}
+
+	if err := commissionService.DeleteRule(uint(ruleID), currentActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan komisi berhasil dihapus."})
+}
+
+// GetCommissionReportHandler @route GET /api/dashboard/commissions (Admin Only)
+// Query: date_from, date_to (YYYY-MM-DD), format=csv untuk ekspor payroll
+func GetCommissionReportHandler(c *gin.Context) {
+	report, err := commissionService.GetReport(c.Query("date_from"), c.Query("date_to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	data, err := commissionReportCSV(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat file CSV."})
+		return
+	}
+	filename := fmt.Sprintf("komisi_%s_%s.csv", report.From.Format("20060102"), report.To.Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// csvText menetralkan teks bebas (nama member/staff, deskripsi) yang diawali karakter formula
+// spreadsheet (=, +, -, @, tab, CR) dengan awalan ' agar tidak dieksekusi sebagai formula.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// commissionReportCSV: Satu baris per sesi, diikuti baris total per staff
+func commissionReportCSV(report *service.CommissionReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"staff_id", "staff_name", "staff_email", "session_type", "session_id", "start_time", "description", "status", "attendees", "member_paid", "rule_type", "rule_value", "commission"}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, staff := range report.Staff {
+		for _, line := range staff.Lines {
+			if err := w.Write([]string{
+				staff.StaffID.String(), csvText(staff.StaffName), csvText(staff.StaffEmail),
+				line.SessionType, line.SessionID.String(),
+				line.StartTime.In(config.Location()).Format("2006-01-02 15:04"),
+				csvText(line.Description), line.Status, strconv.Itoa(line.Attendees),
+				money(line.MemberPaid), line.RuleType, money(line.RuleValue), money(line.Commission),
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Write([]string{
+			staff.StaffID.String(), csvText(staff.StaffName), csvText(staff.StaffEmail),
+			"total", "", "", fmt.Sprintf("%d sesi PT, %d sesi kelas", staff.PTSessions, staff.ClassSessions), "", "",
+			money(staff.PTRevenue), "", "", money(staff.TotalCommission),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
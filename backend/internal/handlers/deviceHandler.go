@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var deviceService = service.NewDeviceService()
+
+// currentDeviceID mengembalikan ID kiosk jika request diautentikasi dengan API key device.
+func currentDeviceID(c *gin.Context) *uuid.UUID {
+	if value, exists := c.Get("deviceID"); exists {
+		if id, ok := value.(uuid.UUID); ok {
+			return &id
+		}
+	}
+	return nil
+}
+
+// GetDevicesHandler @route GET /api/devices (Admin Only)
+func GetDevicesHandler(c *gin.Context) {
+	devices, err := deviceService.GetDevices()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data device."})
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// CreateDeviceHandler @route POST /api/devices (Admin Only)
+func CreateDeviceHandler(c *gin.Context) {
+	var input models.DeviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nama device diperlukan."})
+		return
+	}
+
+	device, apiKey, err := deviceService.CreateDevice(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Device berhasil didaftarkan. Simpan API key ini, tidak akan ditampilkan lagi.",
+		"device":  device,
+		"apiKey":  apiKey,
+	})
+}
+
+// RevokeDeviceHandler @route DELETE /api/devices/:id (Admin Only)
+func RevokeDeviceHandler(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID device tidak valid."})
+		return
+	}
+
+	if err := deviceService.RevokeDevice(deviceID, currentActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device berhasil dicabut."})
+}
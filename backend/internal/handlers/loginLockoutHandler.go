@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var loginThrottleService = service.NewLoginThrottleService()
+
+// GetLoginLockoutsHandler @route GET /api/login-lockouts (Admin Only)
+// Akun & IP yang sedang dalam jeda backoff atau blokir login.
+func GetLoginLockoutsHandler(c *gin.Context) {
+	lockouts, err := loginThrottleService.GetLockouts(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// UnlockLoginHandler @route POST /api/login-lockouts/unlock (Admin Only)
+// Body: {"email": "..."} dan/atau {"ip": "..."}
+func UnlockLoginHandler(c *gin.Context) {
+	var input models.UnlockLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	count, err := loginThrottleService.Unlock(input.Email, input.IP, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Blokir login berhasil dibuka.", "cleared": count})
+}
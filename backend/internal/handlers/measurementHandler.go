@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var measurementService = service.NewMeasurementService()
+
+// GetMeasurementsHandler @route GET /api/members/:id/measurements (Admin/Staff)
+func GetMeasurementsHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	history, err := measurementService.GetHistory(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil hasil pengukuran."})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// CreateMeasurementHandler @route POST /api/members/:id/measurements (Admin/Staff)
+func CreateMeasurementHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.MeasurementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	measurement, err := measurementService.RecordMeasurement(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Hasil pengukuran tersimpan.", "measurement": measurement})
+}
+
+// DeleteMeasurementHandler @route DELETE /api/members/:id/measurements/:measurementId (Admin/Staff)
+func DeleteMeasurementHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+	measurementID, err := uuid.Parse(c.Param("measurementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID pengukuran tidak valid."})
+		return
+	}
+
+	if err := measurementService.DeleteMeasurement(memberID, measurementID, currentActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hasil pengukuran dihapus."})
+}
+
+// GetMyMeasurementsHandler @route GET /api/measurements/my-history (Member Only)
+func GetMyMeasurementsHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	history, err := measurementService.GetHistory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil hasil pengukuran."})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
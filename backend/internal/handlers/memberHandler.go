@@ -38,7 +38,7 @@ func CreateMemberHandler(c *gin.Context) {
 		return
 	}
 
-	member, err := memberService.CreateMember(input)
+	member, err := memberService.CreateMember(input, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -62,7 +62,7 @@ func UpdateMemberHandler(c *gin.Context) {
 		return
 	}
 
-	member, err := memberService.UpdateMember(memberID, input)
+	member, err := memberService.UpdateMember(memberID, input, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -80,7 +80,7 @@ func DeleteMemberHandler(c *gin.Context) {
 		return
 	}
 
-	if err := memberService.DeleteMember(memberID); err != nil {
+	if err := memberService.DeleteMember(memberID, currentActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus member."})
 		return
 	}
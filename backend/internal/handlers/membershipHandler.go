@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var membershipService = service.NewMembershipService()
+
+// GetMembershipsHandler @route GET /api/members/:id/memberships (Admin/Staff)
+func GetMembershipsHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	memberships, err := membershipService.GetMemberships(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data langganan."})
+		return
+	}
+	c.JSON(http.StatusOK, memberships)
+}
+
+// CreateMembershipHandler @route POST /api/members/:id/memberships (Admin/Staff)
+// Perpanjangan atau pembelian paket baru untuk member.
+func CreateMembershipHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.MembershipInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	membership, err := membershipService.Subscribe(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Langganan berhasil dibuat.", "membership": membership})
+}
+
+// GetFreezesHandler @route GET /api/members/:id/freezes (Admin/Staff)
+func GetFreezesHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	freezes, err := membershipService.GetFreezes(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data cuti."})
+		return
+	}
+	c.JSON(http.StatusOK, freezes)
+}
+
+// FreezeMembershipHandler @route POST /api/members/:id/freeze (Admin/Staff)
+func FreezeMembershipHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.FreezeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	freeze, err := membershipService.FreezeMembership(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Membership berhasil dibekukan.", "freeze": freeze})
+}
+
+// UnfreezeMembershipHandler @route POST /api/members/:id/unfreeze (Admin/Staff)
+func UnfreezeMembershipHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	freeze, err := membershipService.UnfreezeMembership(memberID, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cuti membership berhasil dihentikan.", "freeze": freeze})
+}
@@ -1,22 +1,39 @@
 package handlers
 
 import (
+	"gym_management/internal/models"
 	"gym_management/internal/service"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Kiosk self-service mengirim API key device, bukan JWT
+		if apiKey := c.GetHeader("X-Device-Key"); apiKey != "" {
+			device, err := deviceService.AuthenticateDevice(apiKey)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.Set("userRole", models.RoleDevice)
+			c.Set("deviceID", device.ID)
+
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Akses ditolak. Token tidak valid."})
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer")
+		tokenString := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
 
 		claims, err := service.ValidateToken(tokenString)
 		if err != nil {
@@ -27,13 +44,29 @@ func AuthMiddleware() gin.HandlerFunc {
 		// simpan claims ke context
 		c.Set("userRole", claims.Role)
 		c.Set("userID", claims.UserID)
+		c.Set("emailUnverified", claims.Unverified)
+		c.Set("twoFactor", claims.TwoFactor)
+
+		// Access token ikut tidak berlaku saat sesinya dicabut (logout, revoke, ganti password)
+		if claims.SessionID != uuid.Nil {
+			active, err := service.SessionActive(claims.SessionID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa sesi."})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sesi telah berakhir. Silakan login kembali."})
+				return
+			}
+			c.Set("sessionID", claims.SessionID)
+		}
 
 		c.Next()
 	}
 }
 
-// RoleMiddleware membatasi akses berdasarkan role yang dibutuhkan
-func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
+// RequirePermission membatasi akses berdasarkan permission milik role user (lihat RoleService)
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("userRole")
 		if !exists {
@@ -47,19 +80,74 @@ func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
 			return
 		}
 
-		hasPermission := false
-		for _, requiredRole := range requiredRoles {
-			if roleStr == requiredRole {
-				hasPermission = true
-				break
-			}
+		hasPermission, err := service.HasPermission(roleStr, permission)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa hak akses."})
+			return
 		}
-
 		if !hasPermission {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Akses terlarang."})
 			return
 		}
 
+		// Role yang mewajibkan 2FA: token harus berasal dari login dengan TOTP
+		if _, isUser := c.Get("userID"); isUser && !c.GetBool("twoFactor") {
+			required, err := service.RoleRequiresTwoFactor(roleStr)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa hak akses."})
+				return
+			}
+			if required {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Role Anda mewajibkan 2FA. Aktifkan 2FA melalui /api/auth/2fa/enroll atau login ulang dengan kode 2FA."})
+				return
+			}
+		}
+
+		// Akun yang belum verifikasi email hanya boleh memakai permission yang diizinkan
+		if c.GetBool("emailUnverified") && !service.UnverifiedAllowed(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Email belum diverifikasi. Silakan verifikasi email Anda terlebih dahulu."})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireUser menolak kiosk (device) untuk endpoint yang membutuhkan akun user, mis. logout
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("userID"); !exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Akses terlarang."})
+			return
+		}
 		c.Next()
 	}
 }
+
+// currentActor: Pelaku request (user atau kiosk) untuk audit log dan pemeriksaan kepemilikan
+func currentActor(c *gin.Context) service.Actor {
+	actor := service.Actor{
+		Role:     c.GetString("userRole"),
+		DeviceID: currentDeviceID(c),
+		IP:       c.ClientIP(),
+	}
+	if userID, exists := c.Get("userID"); exists {
+		actor.UserID, _ = userID.(uuid.UUID)
+	}
+	return actor
+}
+
+// currentSessionID: FamilyID sesi dari access token (uuid.Nil untuk token lama tanpa sesi)
+func currentSessionID(c *gin.Context) uuid.UUID {
+	if value, exists := c.Get("sessionID"); exists {
+		if id, ok := value.(uuid.UUID); ok {
+			return id
+		}
+	}
+	return uuid.Nil
+}
+
+// sessionClient: Informasi perangkat untuk sesi login baru atau rotasi refresh token
+func sessionClient(c *gin.Context, deviceName string) service.SessionClient {
+	return service.SessionClient{DeviceName: deviceName, UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
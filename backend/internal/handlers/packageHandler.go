@@ -29,7 +29,7 @@ func CreatePackageHandler(c *gin.Context) {
 		return
 	}
 
-	pkg, err := packageService.CreatePackage(input)
+	pkg, err := packageService.CreatePackage(input, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -53,7 +53,7 @@ func UpdatePackageHandler(c *gin.Context) {
 		return
 	}
 
-	pkg, err := packageService.UpdatePackage(uint(packageID), input)
+	pkg, err := packageService.UpdatePackage(uint(packageID), input, currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -71,7 +71,7 @@ func DeletePackageHandler(c *gin.Context) {
 		return
 	}
 
-	if err := packageService.DeletePackage(uint(packageID)); err != nil {
+	if err := packageService.DeletePackage(uint(packageID), currentActor(c)); err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()}) // Menggunakan 409 Conflict untuk FK constraint
 		return
 	}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var paymentService = service.NewPaymentService()
+
+// GetPaymentsHandler @route GET /api/payments (Admin/Staff)
+func GetPaymentsHandler(c *gin.Context) {
+	payments, err := paymentService.GetPayments(
+		c.Query("member_id"),
+		c.Query("method"),
+		c.Query("status"),
+		c.Query("date_from"),
+		c.Query("date_to"),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pembayaran."})
+		return
+	}
+	c.JSON(http.StatusOK, payments)
+}
+
+// CreatePaymentHandler @route POST /api/payments (Admin/Staff)
+func CreatePaymentHandler(c *gin.Context) {
+	var input models.PaymentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	payment, err := paymentService.RecordPayment(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Pembayaran berhasil dicatat.", "payment": payment})
+}
+
+// VoidPaymentHandler @route POST /api/payments/:id/void (Admin/Staff)
+func VoidPaymentHandler(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID pembayaran tidak valid."})
+		return
+	}
+
+	var input models.VoidPaymentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan pembatalan diperlukan."})
+		return
+	}
+
+	payment, err := paymentService.VoidPayment(paymentID, input.Reason, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pembayaran berhasil dibatalkan.", "payment": payment})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ptService = service.NewPTService()
+
+// --- Kredit Sesi PT ---
+
+// GetPTCreditsHandler @route GET /api/members/:id/pt-credits (Admin/Staff)
+func GetPTCreditsHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	credits, err := ptService.GetCredits(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kredit sesi PT."})
+		return
+	}
+	c.JSON(http.StatusOK, credits)
+}
+
+// CreatePTCreditHandler @route POST /api/members/:id/pt-credits (Admin/Staff)
+func CreatePTCreditHandler(c *gin.Context) {
+	memberID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID member tidak valid."})
+		return
+	}
+
+	var input models.PTCreditInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	credit, err := ptService.AddCredit(memberID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, credit)
+}
+
+// GetMyPTCreditsHandler @route GET /api/member/pt-credits (Member Only)
+func GetMyPTCreditsHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	credits, err := ptService.GetCredits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kredit sesi PT."})
+		return
+	}
+	c.JSON(http.StatusOK, credits)
+}
+
+// --- Slot Trainer ---
+
+// GetTrainerSlotsHandler @route GET /api/pt/slots (Authenticated)
+// Query: trainer_id, date_from, date_to. Tanpa permission pt.slots.manage hanya slot yang masih kosong.
+func GetTrainerSlotsHandler(c *gin.Context) {
+	canManage, _ := service.HasPermission(c.MustGet("userRole").(string), models.PermPTSlotsManage)
+	openOnly := !canManage
+	slots, err := ptService.GetSlots(c.Query("trainer_id"), c.Query("date_from"), c.Query("date_to"), openOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil slot trainer."})
+		return
+	}
+	c.JSON(http.StatusOK, slots)
+}
+
+// CreateTrainerSlotHandler @route POST /api/pt/slots (Admin/Staff)
+func CreateTrainerSlotHandler(c *gin.Context) {
+	var input models.TrainerSlotInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	slot, err := ptService.CreateSlot(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, slot)
+}
+
+// CancelTrainerSlotHandler @route DELETE /api/pt/slots/:id (Admin/Staff)
+func CancelTrainerSlotHandler(c *gin.Context) {
+	slotID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID slot tidak valid."})
+		return
+	}
+
+	if err := ptService.CancelSlot(slotID, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Slot trainer berhasil ditutup."})
+}
+
+// --- Janji Sesi PT ---
+
+// BookTrainerSlotHandler @route POST /api/pt/slots/:id/book (Member Only)
+func BookTrainerSlotHandler(c *gin.Context) {
+	slotID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID slot tidak valid."})
+		return
+	}
+
+	var input models.BookTrainerSlotInput
+	// Body opsional (catatan untuk trainer)
+	_ = c.ShouldBindJSON(&input)
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	appointment, err := ptService.BookSlot(slotID, userID, input.Notes, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Sesi personal training berhasil dibooking.", "appointment": appointment})
+}
+
+// CreatePTAppointmentHandler @route POST /api/pt/appointments (Admin/Staff)
+// Staff membookingkan slot untuk member
+func CreatePTAppointmentHandler(c *gin.Context) {
+	var input models.PTAppointmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	appointment, err := ptService.BookSlot(input.SlotID, input.MemberID, input.Notes, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Sesi personal training berhasil dibooking.", "appointment": appointment})
+}
+
+// GetPTAppointmentsHandler @route GET /api/pt/appointments (Admin/Staff)
+// Query: date_from, date_to, trainer_id, member_id, status
+func GetPTAppointmentsHandler(c *gin.Context) {
+	appointments, err := ptService.GetAppointments(c.Query("date_from"), c.Query("date_to"), c.Query("trainer_id"), c.Query("member_id"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil janji sesi PT."})
+		return
+	}
+	c.JSON(http.StatusOK, appointments)
+}
+
+// CancelPTAppointmentHandler @route POST /api/pt/appointments/:id/cancel (Authenticated)
+// Member membatalkan janjinya sendiri; trainer membatalkan janji dengan dirinya; admin semua.
+func CancelPTAppointmentHandler(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID janji tidak valid."})
+		return
+	}
+
+	var input models.CancelPTAppointmentInput
+	// Body opsional (alasan pembatalan)
+	_ = c.ShouldBindJSON(&input)
+
+	appointment, err := ptService.CancelAppointment(appointmentID, input.Reason, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Janji sesi personal training dibatalkan.", "appointment": appointment})
+}
+
+// CompletePTAppointmentHandler @route POST /api/pt/appointments/:id/complete (Admin/Staff)
+// Trainer menandai sesi selesai (atau member tidak hadir); satu kredit member dipotong.
+func CompletePTAppointmentHandler(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID janji tidak valid."})
+		return
+	}
+
+	var input models.CompletePTAppointmentInput
+	// Body opsional
+	_ = c.ShouldBindJSON(&input)
+
+	appointment, err := ptService.CompleteAppointment(appointmentID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi personal training selesai.", "appointment": appointment})
+}
+
+// GetMyPTAppointmentsHandler @route GET /api/pt/my-appointments (Member Only)
+func GetMyPTAppointmentsHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	appointments, err := ptService.GetMyAppointments(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil janji sesi PT."})
+		return
+	}
+	c.JSON(http.StatusOK, appointments)
+}
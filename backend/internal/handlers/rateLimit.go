@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow: Jumlah request satu IP dalam jendela waktu yang sedang berjalan
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit membatasi tiap IP maksimal `limit` request per `window` (jendela tetap, in-memory
+// per replika). Request berlebih ditolak 429 dengan header Retry-After.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		windows = make(map[string]*rateWindow)
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		// Bersihkan jendela yang sudah lewat agar map tidak terus membesar
+		if len(windows) > 10000 {
+			for k, w := range windows {
+				if now.Sub(w.start) >= window {
+					delete(windows, k)
+				}
+			}
+		}
+		w, ok := windows[key]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			windows[key] = w
+		}
+		w.count++
+		exceeded := w.count > limit
+		retryAfter := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Terlalu banyak permintaan. Silakan coba lagi nanti."})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var roleService = service.NewRoleService()
+
+// GetRolesHandler @route GET /api/roles (Admin Only)
+func GetRolesHandler(c *gin.Context) {
+	roles, err := roleService.GetRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data role."})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetPermissionsHandler @route GET /api/permissions (Admin Only)
+func GetPermissionsHandler(c *gin.Context) {
+	permissions, err := roleService.GetPermissions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil daftar permission."})
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+// CreateRoleHandler @route POST /api/roles (Admin Only)
+func CreateRoleHandler(c *gin.Context) {
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	role, err := roleService.CreateRole(input, currentActor(c))
+	if err != nil {
+		c.JSON(staffErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRoleHandler @route PUT /api/roles/:name (Admin Only)
+func UpdateRoleHandler(c *gin.Context) {
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	role, err := roleService.UpdateRole(c.Param("name"), input, currentActor(c))
+	if err != nil {
+		c.JSON(staffErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role berhasil diperbarui.", "role": role})
+}
+
+// DeleteRoleHandler @route DELETE /api/roles/:name (Admin Only)
+func DeleteRoleHandler(c *gin.Context) {
+	if err := roleService.DeleteRole(c.Param("name"), currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role berhasil dihapus."})
+}
+
+// SetRoleTwoFactorHandler @route PUT /api/roles/:name/two-factor (Admin Only)
+// Body: {"required": true} untuk mewajibkan 2FA bagi semua user dengan role ini.
+func SetRoleTwoFactorHandler(c *gin.Context) {
+	var input models.RoleTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	role, err := roleService.SetTwoFactorRequired(c.Param("name"), *input.Required, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Kebijakan 2FA role berhasil diperbarui.", "role": role})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var sessionService = service.NewSessionService()
+
+// GetSessionsHandler @route GET /api/auth/sessions (Any User)
+// Perangkat yang sedang login; sesi yang dipakai request ini ditandai "current".
+func GetSessionsHandler(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	sessions, err := sessionService.GetSessions(userID, currentSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessionHandler @route DELETE /api/auth/sessions/:id (Any User)
+// :id adalah familyId dari daftar sesi. Perangkat tersebut harus login ulang.
+func RevokeSessionHandler(c *gin.Context) {
+	familyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID sesi tidak valid."})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+	if err := sessionService.RevokeSession(userID, familyID, currentActor(c)); err != nil {
+		status := http.StatusNotFound
+		if err.Error() != "sesi tidak ditemukan" {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi berhasil dicabut."})
+}
+
+// RevokeMemberSessionsHandler @route DELETE /api/members/:id/sessions (Admin Only)
+// Mengeluarkan member dari semua perangkat, mis. karena perangkat hilang.
+func RevokeMemberSessionsHandler(c *gin.Context) {
+	revokeUserSessions(c, true)
+}
+
+// RevokeStaffSessionsHandler @route DELETE /api/staff/:id/sessions (Admin Only)
+func RevokeStaffSessionsHandler(c *gin.Context) {
+	revokeUserSessions(c, false)
+}
+
+func revokeUserSessions(c *gin.Context, member bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID user tidak valid."})
+		return
+	}
+
+	count, err := sessionService.RevokeUserSessions(id, member, currentActor(c))
+	if err != nil {
+		status := staffErrorStatus(err, http.StatusInternalServerError)
+		if err.Error() == "user tidak ditemukan" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Semua sesi user berhasil dicabut.", "revoked": count})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var shiftService = service.NewShiftService()
+
+// --- Roster Shift ---
+
+// GetShiftsHandler @route GET /api/shifts (Admin/Staff)
+// Query: week (YYYY-MM-DD, tanggal mana pun dalam minggu tsb), staff_id
+func GetShiftsHandler(c *gin.Context) {
+	roster, err := shiftService.GetWeek(c.Query("week"), c.Query("staff_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roster)
+}
+
+// CreateShiftHandler @route POST /api/shifts (Admin Only)
+func CreateShiftHandler(c *gin.Context) {
+	var input models.StaffShiftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	shift, err := shiftService.CreateShift(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, shift)
+}
+
+// UpdateShiftHandler @route PUT /api/shifts/:id (Admin Only)
+func UpdateShiftHandler(c *gin.Context) {
+	shiftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID shift tidak valid."})
+		return
+	}
+
+	var input models.StaffShiftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	shift, err := shiftService.UpdateShift(shiftID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shift berhasil diperbarui.", "shift": shift})
+}
+
+// DeleteShiftHandler @route DELETE /api/shifts/:id (Admin Only)
+func DeleteShiftHandler(c *gin.Context) {
+	shiftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID shift tidak valid."})
+		return
+	}
+
+	if err := shiftService.DeleteShift(shiftID, currentActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shift berhasil dihapus."})
+}
+
+// CopyShiftWeekHandler @route POST /api/shifts/copy-week (Admin Only)
+func CopyShiftWeekHandler(c *gin.Context) {
+	var input models.CopyShiftWeekInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Minggu asal dan tujuan diperlukan."})
+		return
+	}
+
+	created, skipped, err := shiftService.CopyWeek(input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"shiftsCreated": created, "shiftsSkipped": skipped})
+}
+
+// --- Time Clock ---
+
+// ClockInHandler @route POST /api/timeclock/clock-in (Admin/Staff)
+func ClockInHandler(c *gin.Context) {
+	entry, err := shiftService.ClockIn(currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if entry.LateMinutes > 0 {
+		c.JSON(http.StatusCreated, gin.H{"message": "Clock-in berhasil (terlambat).", "entry": entry})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Clock-in berhasil.", "entry": entry})
+}
+
+// ClockOutHandler @route POST /api/timeclock/clock-out (Admin/Staff)
+func ClockOutHandler(c *gin.Context) {
+	entry, err := shiftService.ClockOut(currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Clock-out berhasil.", "entry": entry})
+}
+
+// GetMyTimeClockHandler @route GET /api/timeclock/me (Admin/Staff)
+// Query: date_from, date_to
+func GetMyTimeClockHandler(c *gin.Context) {
+	staffID := c.MustGet("userID").(uuid.UUID)
+	entries, err := shiftService.GetMyEntries(staffID, c.Query("date_from"), c.Query("date_to"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat clock-in."})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetTimeClockHandler @route GET /api/timeclock (Admin Only)
+// Query: staff_id, date_from, date_to
+func GetTimeClockHandler(c *gin.Context) {
+	entries, err := shiftService.GetEntries(c.Query("staff_id"), c.Query("date_from"), c.Query("date_to"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat clock-in."})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// AdjustTimeClockHandler @route PUT /api/timeclock/:id (Admin Only)
+func AdjustTimeClockHandler(c *gin.Context) {
+	entryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID catatan tidak valid."})
+		return
+	}
+
+	var input models.AdjustTimeClockInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid.", "details": err.Error()})
+		return
+	}
+
+	entry, err := shiftService.AdjustEntry(entryID, input, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Catatan clock-in berhasil dikoreksi.", "entry": entry})
+}
+
+// GetShiftReportHandler @route GET /api/dashboard/shifts (Admin Only)
+// Query: date_from, date_to, staff_id. Jam terjadwal vs aktual beserta keterlambatan.
+func GetShiftReportHandler(c *gin.Context) {
+	report, err := shiftService.GetReport(c.Query("date_from"), c.Query("date_to"), c.Query("staff_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
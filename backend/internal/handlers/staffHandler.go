@@ -4,6 +4,7 @@ import (
 	"gym_management/internal/models"
 	"gym_management/internal/service"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,6 +12,14 @@ import (
 
 var staffService = service.NewStaffService()
 
+// staffErrorStatus: 403 untuk penolakan hak akses (target admin, role di luar permission actor)
+func staffErrorStatus(err error, fallback int) int {
+	if strings.HasPrefix(err.Error(), "hanya admin") || strings.HasPrefix(err.Error(), "tidak bisa memberikan") {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
 // GetStaffHandler @route GET /api/staff (Admin/Staff)
 func GetStaffHandler(c *gin.Context) {
 	staffs, err := staffService.GetStaffs()
@@ -30,7 +39,7 @@ func CreateStaffHandler(c *gin.Context) {
 	}
 
 	// Default role adalah 'staff'
-	newStaff, err := staffService.CreateStaff(input, "staff")
+	newStaff, err := staffService.CreateStaff(input, "staff", currentActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -54,9 +63,9 @@ func UpdateStaffHandler(c *gin.Context) {
 		return
 	}
 
-	staff, err := staffService.UpdateStaff(staffID, input)
+	staff, err := staffService.UpdateStaff(staffID, input, currentActor(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(staffErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -72,10 +81,72 @@ func DeleteStaffHandler(c *gin.Context) {
 		return
 	}
 
-	if err := staffService.DeleteStaff(staffID); err != nil {
+	if err := staffService.DeleteStaff(staffID, currentActor(c)); err != nil {
+		if status := staffErrorStatus(err, 0); status != 0 {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "admin terakhir") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus staff."})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Staff berhasil dihapus."})
 }
+
+// SetTrainerHandler @route PUT /api/staff/:id/trainer (Admin Only)
+func SetTrainerHandler(c *gin.Context) {
+	staffID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID staff tidak valid."})
+		return
+	}
+
+	var input models.TrainerFlagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid."})
+		return
+	}
+
+	staff, err := staffService.SetTrainer(staffID, input.IsTrainer, currentActor(c))
+	if err != nil {
+		c.JSON(staffErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Status trainer berhasil diperbarui.", "staff": staff})
+}
+
+// GetTrainersHandler @route GET /api/trainers (Authenticated)
+func GetTrainersHandler(c *gin.Context) {
+	trainers, err := staffService.GetTrainers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data trainer."})
+		return
+	}
+	c.JSON(http.StatusOK, trainers)
+}
+
+// SetStaffRoleHandler @route PUT /api/staff/:id/role (roles.manage)
+func SetStaffRoleHandler(c *gin.Context) {
+	staffID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID staff tidak valid."})
+		return
+	}
+
+	var input models.StaffRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role diperlukan."})
+		return
+	}
+
+	staff, err := staffService.SetRole(staffID, input.Role, currentActor(c))
+	if err != nil {
+		c.JSON(staffErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role staff berhasil diperbarui.", "staff": staff})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"gym_management/internal/models"
+	"gym_management/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var twoFactorService = service.NewTwoFactorService()
+
+// GetTwoFactorStatusHandler @route GET /api/auth/2fa (Any User)
+func GetTwoFactorStatusHandler(c *gin.Context) {
+	status, err := twoFactorService.GetStatus(c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactorHandler @route POST /api/auth/2fa/enroll (Any User)
+// Mengembalikan secret, URI otpauth:// dan QR code untuk aplikasi authenticator.
+func EnrollTwoFactorHandler(c *gin.Context) {
+	enrollment, err := twoFactorService.Enroll(c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTwoFactorHandler @route POST /api/auth/2fa/confirm (Any User)
+func ConfirmTwoFactorHandler(c *gin.Context) {
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	codes, accessToken, refreshToken, err := twoFactorService.Confirm(c.MustGet("userID").(uuid.UUID), input.Code, currentActor(c), sessionClient(c, ""))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "2FA berhasil diaktifkan. Simpan kode cadangan di tempat aman; kode hanya ditampilkan sekali.",
+		"recoveryCodes": codes,
+		"accessToken":   accessToken,
+		"refreshToken":  refreshToken,
+	})
+}
+
+// DisableTwoFactorHandler @route POST /api/auth/2fa/disable (Any User)
+func DisableTwoFactorHandler(c *gin.Context) {
+	var input models.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	if err := twoFactorService.Disable(c.MustGet("userID").(uuid.UUID), input.Password, input.Code, currentActor(c)); err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "mewajibkan") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "2FA berhasil dinonaktifkan."})
+}
+
+// RegenerateRecoveryCodesHandler @route POST /api/auth/2fa/recovery-codes (Any User)
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	codes, err := twoFactorService.RegenerateRecoveryCodes(c.MustGet("userID").(uuid.UUID), input.Code, currentActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Kode cadangan baru dibuat. Kode lama tidak berlaku lagi.", "recoveryCodes": codes})
+}
+
+// VerifyTwoFactorLoginHandler @route POST /api/auth/2fa/verify (Public, with challenge token)
+// Langkah kedua login: kode TOTP atau kode cadangan ditukar dengan access/refresh token.
+func VerifyTwoFactorLoginHandler(c *gin.Context) {
+	var input models.TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid", "details": err.Error()})
+		return
+	}
+
+	user, accessToken, refreshToken, err := twoFactorService.VerifyLogin(input.ChallengeToken, input.Code, sessionClient(c, input.DeviceName))
+	if abortIfLoginLocked(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Login berhasil.",
+		"user":         gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role, "emailVerified": user.EmailVerifiedAt != nil},
+		"accessToken":  accessToken,
+		"refreshToken": refreshToken,
+	})
+}
+
+// ResetTwoFactorHandler @route DELETE /api/staff/:id/2fa (Admin Only)
+// Mematikan 2FA staff yang kehilangan perangkat authenticator dan kode cadangan.
+func ResetTwoFactorHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID staff tidak valid."})
+		return
+	}
+
+	if err := twoFactorService.Reset(id, currentActor(c)); err != nil {
+		c.JSON(staffErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "2FA user berhasil direset."})
+}
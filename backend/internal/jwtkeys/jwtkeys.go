@@ -0,0 +1,255 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key adalah satu kunci penandatangan access token. Private nil berarti kunci hanya untuk verifikasi
+// (kunci lama yang sudah dirotasi tetapi token terbitannya mungkin masih beredar).
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet berisi satu kunci aktif untuk menandatangani dan semua kunci yang masih diterima untuk verifikasi.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// FromEnv memuat kunci dari JWT_KEYS_DIR: setiap file <kid>.pem berisi private key (PKCS#8 atau PKCS#1)
+// RSA >= 2048 bit (RS256) atau Ed25519 (EdDSA), atau public key (PKIX) untuk kunci yang hanya diverifikasi.
+// JWT_SIGNING_KEY_ID memilih kunci penandatangan; boleh kosong jika hanya ada satu private key.
+//
+// Rotasi: taruh kunci baru di direktori semua instance, pindahkan JWT_SIGNING_KEY_ID ke kunci baru,
+// lalu hapus kunci lama setelah access token terakhir yang ditandatanganinya kedaluwarsa.
+//
+// Tanpa JWT_KEYS_DIR dibuat kunci Ed25519 sementara (hanya untuk pengembangan): access token tidak
+// berlaku lagi setelah restart dan tidak bisa diverifikasi instance lain.
+func FromEnv() (*KeySet, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		log.Println("JWT_KEYS_DIR belum diatur, menggunakan kunci Ed25519 sementara (jangan dipakai di production)")
+		return Ephemeral()
+	}
+	return LoadDir(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+}
+
+// Ephemeral membuat KeySet dengan satu kunci Ed25519 acak.
+func Ephemeral() (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{Method: jwt.SigningMethodEdDSA, Private: private, Public: public}
+	key.ID = Thumbprint(key)
+	return NewKeySet(key.ID, key)
+}
+
+// LoadDir memuat semua file *.pem di dir; nama file (tanpa .pem) menjadi kid.
+func LoadDir(dir, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var keys []*Key
+	var privateKIDs []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("kunci JWT %s: %w", filepath.Base(path), err)
+		}
+		key.ID = strings.TrimSuffix(filepath.Base(path), ".pem")
+		if key.Private != nil {
+			privateKIDs = append(privateKIDs, key.ID)
+		}
+		keys = append(keys, key)
+	}
+
+	if signingKID == "" {
+		if len(privateKIDs) != 1 {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_ID wajib diatur (%d private key ditemukan di %s)", len(privateKIDs), dir)
+		}
+		signingKID = privateKIDs[0]
+	}
+	return NewKeySet(signingKID, keys...)
+}
+
+// NewKeySet menyusun KeySet; signingKID harus merujuk ke salah satu kunci yang memiliki private key.
+func NewKeySet(signingKID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("kunci JWT tanpa kid")
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("kid %q duplikat", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+	signing, ok := set.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("kunci penandatangan %q tidak ditemukan", signingKID)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("kunci penandatangan %q tidak memiliki private key", signingKID)
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// ParsePEM membaca private key (PKCS#8/PKCS#1) atau public key (PKIX) RSA atau Ed25519.
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("bukan file PEM")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipe PEM %q tidak didukung", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("kunci RSA minimal 2048 bit")
+		}
+		return &Key{Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("kunci RSA minimal 2048 bit")
+		}
+		return &Key{Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return nil, fmt.Errorf("jenis kunci %T tidak didukung (gunakan RSA atau Ed25519)", parsed)
+	}
+}
+
+// Sign menandatangani claims dengan kunci aktif; kid dicantumkan di header token.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.Private)
+}
+
+// Keyfunc memilih public key berdasarkan kid di header. Algoritma token harus sama dengan
+// algoritma kunci sehingga token HS256 (atau alg lain) tidak pernah diterima.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// Methods: Algoritma yang dipakai kunci-kunci di set ini (untuk jwt.WithValidMethods).
+func (s *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// JWK adalah public key dalam format JSON Web Key (RFC 7517/8037).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS adalah isi /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan semua public key (aktif dan yang masih diverifikasi), diurutkan berdasarkan kid.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// JWK mengubah public key menjadi JWK.
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// Thumbprint: kid turunan dari public key (16 karakter pertama SHA-256 PKIX, base64url).
+func Thumbprint(k *Key) string {
+	der, err := x509.MarshalPKIXPublicKey(k.Public)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+}
@@ -0,0 +1,140 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message adalah email yang siap dikirim.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender mengirim email. Implementasi dipilih lewat MAIL_DRIVER (lihat FromEnv).
+type Sender interface {
+	Send(msg Message) error
+}
+
+// FromEnv memilih Sender berdasarkan MAIL_DRIVER: smtp, file atau memory. MAIL_DRIVER wajib diisi
+// agar server production tidak diam-diam menulis token reset & tautan verifikasi ke file lokal;
+// file dan memory hanya untuk pengembangan.
+func FromEnv() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@gym.local"
+	}
+
+	switch driver := strings.ToLower(os.Getenv("MAIL_DRIVER")); driver {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, errors.New("SMTP_HOST belum diatur")
+		}
+		return &SMTPSender{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     config.GetEnvInt("SMTP_PORT", 587),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "memory":
+		return &MemorySender{}, nil
+	case "file":
+		path := os.Getenv("MAIL_FILE_PATH")
+		if path == "" {
+			path = "mail_outbox.log"
+		}
+		log.Printf("MAIL_DRIVER=file: email ditulis ke %s, tidak dikirim (jangan dipakai di production)", path)
+		return &FileSender{Path: path, From: from}, nil
+	case "":
+		return nil, errors.New("MAIL_DRIVER belum diatur (smtp, atau file/memory untuk pengembangan)")
+	default:
+		return nil, fmt.Errorf("MAIL_DRIVER %q tidak dikenal", driver)
+	}
+}
+
+// MemorySender menyimpan email di memori, untuk pengujian.
+type MemorySender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (s *MemorySender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// Sent mengembalikan salinan semua email yang sudah "dikirim".
+func (s *MemorySender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
+
+// FileSender menambahkan email ke sebuah file teks, untuk pengembangan lokal.
+type FileSender struct {
+	Path string
+	From string
+
+	mu sync.Mutex
+}
+
+func (s *FileSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\r\n%s\r\n\r\n", time.Now().Format(time.RFC1123Z), format(s.From, msg))
+	return err
+}
+
+// SMTPSender mengirim email lewat server SMTP (STARTTLS dipakai otomatis jika didukung server).
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	if s.Host == "" {
+		return fmt.Errorf("SMTP_HOST belum diatur")
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+	return smtp.SendMail(addr, auth, s.From, []string{msg.To}, []byte(format(s.From, msg)))
+}
+
+// headerValue membuang baris baru agar nilai header tidak bisa menyisipkan header lain
+var headerValue = strings.NewReplacer("\r", "", "\n", " ")
+
+// format menyusun header dan isi email dalam format RFC 5322 sederhana (teks polos).
+func format(from string, msg Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue.Replace(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.String()
+}
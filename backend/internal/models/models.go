@@ -14,14 +14,26 @@ type User struct {
 	Name         string    `gorm:"type:varchar(255);not null" json:"name"`
 	Email        string    `gorm:"type:varchar(255);unique;not null" json:"email"`
 	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
-	Role         string    `gorm:"type:role_enum;default:'member';not null" json:"role"`
+	Role         string    `gorm:"type:varchar(50);default:'member';not null;index" json:"role"` // Nama Role (lihat tabel roles)
 
 	// Member Specific
-	PhoneNumber  string `gorm:"type:varchar(50)" json:"phoneNumber"`
-	Address      string `gorm:"type:text" json:"address"`
-	PackageID    *uint  `json:"packageId"`
-	IsActive     bool   `gorm:"default:true" json:"isActive"`
-	RefreshToken string `gorm:"type:text" json:"-"`
+	MemberNumber *string `gorm:"type:varchar(20);uniqueIndex" json:"memberNumber"`
+	PhoneNumber  string  `gorm:"type:varchar(50)" json:"phoneNumber"`
+	Address      string  `gorm:"type:text" json:"address"`
+	PackageID    *uint   `json:"packageId"`
+	IsActive     bool    `gorm:"default:true" json:"isActive"`
+
+	// Verifikasi email: nil berarti belum terverifikasi (hanya untuk registrasi mandiri)
+	EmailVerifiedAt    *time.Time `json:"emailVerifiedAt"`
+	VerificationSentAt *time.Time `json:"-"` // Untuk jeda kirim ulang email verifikasi
+
+	// Two-factor authentication (TOTP). TOTPSecret terisi sejak enrollment, aktif setelah dikonfirmasi.
+	TwoFactorEnabled bool   `gorm:"default:false;not null" json:"twoFactorEnabled"`
+	TOTPSecret       string `gorm:"type:varchar(128)" json:"-"`  // Terenkripsi AES-GCM (kunci dari TWO_FACTOR_SECRET)
+	TOTPLastStep     int64  `gorm:"default:0;not null" json:"-"` // Periode kode terakhir yang dipakai (anti replay)
+
+	// Staff Specific: staff yang bisa menerima sesi personal training
+	IsTrainer bool `gorm:"default:false" json:"isTrainer"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -36,19 +48,738 @@ type GymPackage struct {
 	DurationDays int     `gorm:"not null" json:"durationDays"`
 	Benefits     string  `gorm:"type:text" json:"benefits"`
 
+	// Aturan cuti (freeze). MaxFreezes = 0 berarti paket tidak bisa dibekukan.
+	MinFreezeDays int `gorm:"default:7;not null" json:"minFreezeDays"`
+	MaxFreezeDays int `gorm:"default:30;not null" json:"maxFreezeDays"`
+	MaxFreezes    int `gorm:"default:0;not null" json:"maxFreezes"`
+
+	// Aturan akses. Nilai kosong/0 berarti tanpa batasan.
+	AllowedDays      []int        `gorm:"type:jsonb;serializer:json" json:"allowedDays"`   // ISO weekday: 1 = Senin ... 7 = Minggu
+	AccessWindows    []TimeWindow `gorm:"type:jsonb;serializer:json" json:"accessWindows"` // Mis. paket Off-Peak
+	MaxVisits        int          `gorm:"default:0;not null" json:"maxVisits"`             // Per periode langganan, mis. paket 10x Visit
+	MaxVisitsPerWeek int          `gorm:"default:0;not null" json:"maxVisitsPerWeek"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// TimeWindow: Rentang jam akses harian dalam format HH:MM (zona waktu gym), End eksklusif
+type TimeWindow struct {
+	Start string `json:"start" binding:"required"`
+	End   string `json:"end" binding:"required"`
+}
+
 type Attendance struct {
 	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"userId"`
 	CheckInTime  time.Time  `gorm:"not null" json:"checkInTime"`
 	CheckOutTime *time.Time `json:"checkOutTime"`
 
+	// Diisi jika Check-In/Check-Out dilakukan dari kiosk (bukan oleh staff)
+	CheckInDeviceID  *uuid.UUID `gorm:"type:uuid" json:"checkInDeviceId"`
+	CheckOutDeviceID *uuid.UUID `gorm:"type:uuid" json:"checkOutDeviceId"`
+
+	// true jika Check-Out dilakukan otomatis oleh sistem (jam tutup / sesi kadaluarsa)
+	AutoClosed bool `gorm:"default:false;not null" json:"autoClosed"`
+
+	// Peringatan untuk resepsionis saat Check-In (mis. tagihan belum lunas), tidak disimpan
+	Warnings []string `gorm:"-" json:"warnings,omitempty"`
+
 	User User `gorm:"foreignKey:UserID" json:"member"`
 }
 
+// Status kartu akses RFID/NFC
+const (
+	AccessCardStatusActive   = "active"
+	AccessCardStatusLost     = "lost"
+	AccessCardStatusReplaced = "replaced"
+	AccessCardStatusInactive = "inactive"
+)
+
+// AccessCard adalah kartu/key fob RFID/NFC milik member. Satu member bisa memiliki
+// beberapa kartu, tetapi hanya kartu berstatus active yang bisa dipakai Check-In.
+type AccessCard struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	CardNumber    string     `gorm:"type:varchar(100);uniqueIndex;not null" json:"cardNumber"`
+	Label         string     `gorm:"type:varchar(100)" json:"label"`
+	Status        string     `gorm:"type:varchar(20);default:'active';not null" json:"status"`
+	IssuedAt      time.Time  `gorm:"not null" json:"issuedAt"`
+	DeactivatedAt *time.Time `json:"deactivatedAt"`
+	ReplacedByID  *uuid.UUID `gorm:"type:uuid" json:"replacedById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UsedCheckInToken: jti token QR kartu member yang sudah dipakai Check-In. Primary key menolak
+// pemakaian ulang (mis. screenshot QR yang dibagikan); baris dihapus setelah token kedaluwarsa.
+type UsedCheckInToken struct {
+	JTI       string    `gorm:"type:varchar(64);primaryKey" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"userId"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expiresAt"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RoleDevice adalah role untuk kiosk self-service; hanya boleh mengakses endpoint absensi.
+const RoleDevice = "device"
+
+// Role bawaan sistem. Role lain (mis. trainer, finance) dibuat admin dan selalu bertipe karyawan.
+const (
+	RoleAdmin  = "admin"
+	RoleStaff  = "staff"
+	RoleMember = "member"
+)
+
+// IsStaffRole: Semua role selain member dan device adalah akun karyawan
+func IsStaffRole(role string) bool {
+	return role != RoleMember && role != RoleDevice && role != ""
+}
+
+// Kode permission. Katalog lengkap beserta deskripsinya ada di RoleService.
+const (
+	PermMembersRead          = "members.read"
+	PermMembersCreate        = "members.create"
+	PermMembersUpdate        = "members.update"
+	PermMembersDelete        = "members.delete"
+	PermMembershipsManage    = "memberships.manage"
+	PermCardsManage          = "cards.manage"
+	PermMeasurementsRead     = "measurements.read"
+	PermMeasurementsManage   = "measurements.manage"
+	PermAttendanceRead       = "attendance.read"
+	PermAttendanceCheckIn    = "attendance.checkin"
+	PermPaymentsRead         = "payments.read"
+	PermPaymentsCreate       = "payments.create"
+	PermPaymentsVoid         = "payments.void"
+	PermAccountsRead         = "accounts.read"
+	PermAccountsManage       = "accounts.manage"
+	PermPackagesRead         = "packages.read"
+	PermPackagesManage       = "packages.manage"
+	PermClassesRead          = "classes.read"
+	PermClassTypesManage     = "classes.types.manage"
+	PermClassSessionsManage  = "classes.sessions.manage"
+	PermClassesBook          = "classes.book"
+	PermPTRead               = "pt.read"
+	PermPTCreditsManage      = "pt.credits.manage"
+	PermPTSlotsManage        = "pt.slots.manage"
+	PermPTAppointmentsManage = "pt.appointments.manage"
+	PermPTAppointmentsCancel = "pt.appointments.cancel"
+	PermPTBook               = "pt.book"
+	PermPTManageAll          = "pt.manage_all"
+	PermSelfPortal           = "self.portal"
+	PermStaffRead            = "staff.read"
+	PermStaffManage          = "staff.manage"
+	PermRolesManage          = "roles.manage"
+	PermDashboardRead        = "dashboard.read"
+	PermCommissionsManage    = "commissions.manage"
+	PermDevicesManage        = "devices.manage"
+	PermShiftsRead           = "shifts.read"
+	PermShiftsManage         = "shifts.manage"
+	PermTimeClockUse         = "timeclock.use"
+	PermAuditRead            = "audit.read"
+	PermLoginLockoutsManage  = "auth.lockouts.manage"
+	PermSessionsManage       = "auth.sessions.manage"
+)
+
+// Permission: Hak akses granular (mis. members.read, payments.void)
+type Permission struct {
+	Code        string `gorm:"type:varchar(100);primaryKey" json:"code"`
+	Description string `gorm:"type:varchar(255)" json:"description"`
+}
+
+// Role: Kumpulan permission. Role sistem (admin, staff, member) tidak bisa dihapus;
+// admin selalu memiliki seluruh permission.
+type Role struct {
+	Name        string       `gorm:"type:varchar(50);primaryKey" json:"name"`
+	Description string       `gorm:"type:varchar(255)" json:"description"`
+	IsSystem    bool         `gorm:"default:false;not null" json:"isSystem"`
+	Permissions []Permission `gorm:"many2many:role_permissions;foreignKey:Name;joinForeignKey:RoleName;references:Code;joinReferences:PermissionCode" json:"permissions"`
+
+	// RequireTwoFactor: User dengan role ini wajib mengaktifkan 2FA sebelum bisa memakai permission-nya
+	RequireTwoFactor bool `gorm:"default:false;not null" json:"requireTwoFactor"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Device adalah kredensial kiosk (mis. tablet di pintu masuk). API key hanya
+// ditampilkan sekali saat dibuat; yang disimpan hanya hash SHA-256-nya.
+type Device struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	KeyPrefix   string     `gorm:"type:varchar(16);not null" json:"keyPrefix"`
+	KeyHash     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	IsActive    bool       `gorm:"default:true" json:"isActive"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null" json:"createdById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PasswordResetToken: Token lupa password sekali pakai. Token mentah hanya dikirim lewat
+// notifikasi; yang disimpan hanya hash SHA-256-nya.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RecoveryCode: Kode cadangan 2FA sekali pakai, disimpan sebagai hash SHA-256
+type RecoveryCode struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	CodeHash string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt   *time.Time `json:"usedAt"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Session: Satu refresh token yang pernah diterbitkan. Setiap refresh membuat baris baru dengan
+// FamilyID yang sama dan menandai baris lama "rotated"; satu keluarga = satu perangkat yang login.
+// Refresh token yang sudah dirotasi lalu dipakai lagi menandakan kebocoran sehingga seluruh
+// keluarga dicabut.
+type Session struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	FamilyID   uuid.UUID `gorm:"type:uuid;not null;index" json:"familyId"`
+	TokenHash  string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	DeviceName string    `gorm:"type:varchar(100)" json:"deviceName"`
+	UserAgent  string    `gorm:"type:varchar(255)" json:"userAgent"`
+	IPAddress  string    `gorm:"type:varchar(45)" json:"ipAddress"`
+	TwoFactor  bool      `gorm:"default:false;not null" json:"twoFactor"` // Login keluarga ini melewati 2FA
+
+	SignedInAt    time.Time  `gorm:"not null" json:"signedInAt"` // Login awal keluarga sesi
+	LastUsedAt    time.Time  `gorm:"not null" json:"lastUsedAt"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expiresAt"`
+	RevokedAt     *time.Time `json:"revokedAt"`
+	RevokedReason string     `gorm:"type:varchar(30)" json:"revokedReason,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Current bool `gorm:"-" json:"current"` // Sesi yang dipakai request ini (daftar sesi)
+}
+
+// Alasan pencabutan sesi
+const (
+	SessionRevokedRotated = "rotated" // Digantikan refresh token baru (bukan logout)
+	SessionRevokedLogout  = "logout"
+	SessionRevokedUser    = "revoked" // Dicabut user dari daftar sesinya
+	SessionRevokedAdmin   = "admin"
+	SessionRevokedReuse   = "reuse_detected"
+	SessionRevokedAuth    = "credentials_changed" // Password/2FA berubah
+)
+
+// LoginThrottle: Jumlah login gagal per akun ("account:<email>") atau per IP ("ip:<alamat>").
+// Disimpan di Postgres agar berlaku di semua replika API; masa blokir dihitung dari Failures & LastFailureAt.
+type LoginThrottle struct {
+	Key           string    `gorm:"type:varchar(320);primaryKey" json:"key"`
+	Failures      int       `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time `gorm:"not null;index" json:"lastFailureAt"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Status langganan (Membership)
+const (
+	MembershipStatusActive    = "active"
+	MembershipStatusExpired   = "expired"
+	MembershipStatusCancelled = "cancelled"
+)
+
+// Membership menyimpan periode langganan paket seorang member.
+// User.PackageID tetap menunjuk ke paket yang sedang berjalan.
+type Membership struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	PackageID uint      `gorm:"not null" json:"packageId"`
+	StartDate time.Time `gorm:"not null" json:"startDate"`
+	EndDate   time.Time `gorm:"not null;index" json:"endDate"`
+	Status    string    `gorm:"type:varchar(20);default:'active';not null;index" json:"status"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Package GymPackage `gorm:"foreignKey:PackageID" json:"package"`
+	User    *User      `gorm:"foreignKey:UserID" json:"member,omitempty"`
+}
+
+// MembershipFreeze mencatat periode cuti member. Selama periode ini member tidak bisa
+// Check-In dan EndDate langganan sudah diperpanjang sebanyak Days.
+type MembershipFreeze struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	MembershipID uuid.UUID  `gorm:"type:uuid;not null;index" json:"membershipId"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	StartDate    time.Time  `gorm:"not null" json:"startDate"`
+	EndDate      time.Time  `gorm:"not null" json:"endDate"`
+	EndedAt      *time.Time `json:"endedAt"` // Diisi jika cuti dihentikan lebih awal
+	Days         int        `gorm:"not null" json:"days"`
+	Reason       string     `gorm:"type:text" json:"reason"`
+	CreatedByID  uuid.UUID  `gorm:"type:uuid;not null" json:"createdById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Metode & status pembayaran
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodTransfer = "transfer"
+	PaymentMethodQRIS     = "qris"
+	PaymentMethodCard     = "card"
+
+	PaymentStatusPaid = "paid"
+	PaymentStatusVoid = "void"
+)
+
+// Payment adalah satu baris buku kas: uang yang benar-benar diterima dari member.
+type Payment struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	MembershipID *uuid.UUID `gorm:"type:uuid;index" json:"membershipId"`
+	Amount       float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Method       string     `gorm:"type:varchar(20);not null" json:"method"`
+	Reference    string     `gorm:"type:varchar(100)" json:"reference"`
+	Notes        string     `gorm:"type:text" json:"notes"`
+	Status       string     `gorm:"type:varchar(20);default:'paid';not null;index" json:"status"`
+	PaidAt       time.Time  `gorm:"not null;index" json:"paidAt"`
+	RecordedByID uuid.UUID  `gorm:"type:uuid;not null" json:"recordedById"`
+
+	VoidedAt   *time.Time `json:"voidedAt"`
+	VoidedByID *uuid.UUID `gorm:"type:uuid" json:"voidedById"`
+	VoidReason string     `gorm:"type:text" json:"voidReason"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	User       User        `gorm:"foreignKey:UserID" json:"member"`
+	Membership *Membership `gorm:"foreignKey:MembershipID" json:"membership,omitempty"`
+}
+
+// Tipe entri buku tagihan member
+const (
+	AccountEntryCharge  = "charge"  // Tagihan: menambah saldo terutang
+	AccountEntryCredit  = "credit"  // Potongan/pembebasan: mengurangi saldo terutang
+	AccountEntryPayment = "payment" // Pelunasan, terkait satu Payment
+)
+
+// AccountEntry: Satu baris buku tagihan member (biaya loker, ganti kartu, denda, dsb.).
+// Amount selalu positif, arahnya ditentukan Type. Saldo positif berarti member masih berutang.
+type AccountEntry struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Type        string     `gorm:"type:varchar(20);not null" json:"type"`
+	Category    string     `gorm:"type:varchar(50);not null" json:"category"`
+	Description string     `gorm:"type:text" json:"description"`
+	Amount      float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	PaymentID   *uuid.UUID `gorm:"type:uuid;index" json:"paymentId"`
+	PostedByID  uuid.UUID  `gorm:"type:uuid;not null" json:"postedById"`
+	PostedAt    time.Time  `gorm:"not null;index" json:"postedAt"`
+
+	// Saldo setelah entri ini, dihitung saat query
+	RunningBalance float64 `gorm:"-" json:"runningBalance"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Tipe, kanal & status notifikasi
+const (
+	NotificationTypeRenewalReminder  = "renewal_reminder"
+	NotificationTypeWaitlistPromoted = "waitlist_promoted"
+	NotificationTypePTCancelled      = "pt_appointment_cancelled"
+	NotificationTypePasswordReset    = "password_reset"
+	NotificationTypeEmailVerify      = "email_verification"
+
+	NotificationChannelEmail = "email"
+
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+)
+
+// Notification adalah antrean pesan keluar (outbox) untuk member.
+// Kombinasi Type + ReferenceID unik agar job tidak mengantrekan pesan yang sama dua kali.
+type Notification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Type        string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_notifications_type_reference" json:"type"`
+	ReferenceID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_notifications_type_reference" json:"referenceId"`
+	Channel     string     `gorm:"type:varchar(20);default:'email';not null" json:"channel"`
+	Recipient   string     `gorm:"type:varchar(255);not null" json:"recipient"`
+	Subject     string     `gorm:"type:varchar(255)" json:"subject"`
+	Body        string     `gorm:"type:text" json:"body"`
+	// Sensitive: Body berisi rahasia (token reset, tautan verifikasi); dikosongkan setelah terkirim atau gagal permanen
+	Sensitive   bool       `gorm:"default:false;not null" json:"sensitive"`
+	Status      string     `gorm:"type:varchar(20);default:'pending';not null;index" json:"status"`
+	ScheduledAt time.Time  `gorm:"not null" json:"scheduledAt"`
+	SentAt      *time.Time `json:"sentAt"`
+	Attempts    int        `gorm:"default:0;not null" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"lastError"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// JobRun menyimpan kapan job terjadwal terakhir dijalankan (dibagi antar replika API).
+// LastSuccessAt hanya maju jika run berhasil, sehingga run yang gagal dicoba lagi pada tick berikutnya.
+type JobRun struct {
+	Name          string     `gorm:"type:varchar(100);primaryKey" json:"name"`
+	LastRunAt     time.Time  `gorm:"not null" json:"lastRunAt"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt"`
+	LastError     string     `gorm:"type:text" json:"lastError"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Status sesi kelas
+const (
+	ClassSessionStatusScheduled = "scheduled"
+	ClassSessionStatusCancelled = "cancelled"
+)
+
+// ClassType: Jenis kelas grup (Yoga, Spinning, Zumba, ...)
+type ClassType struct {
+	ID              uint   `gorm:"primaryKey" json:"id"`
+	Name            string `gorm:"type:varchar(100);unique;not null" json:"name"`
+	Description     string `gorm:"type:text" json:"description"`
+	DurationMinutes int    `gorm:"not null" json:"durationMinutes"`
+	DefaultCapacity int    `gorm:"not null" json:"defaultCapacity"`
+	IsActive        bool   `gorm:"default:true" json:"isActive"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ClassSession: Satu jadwal kelas. Instructor adalah user staff/admin.
+type ClassSession struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ClassTypeID  uint      `gorm:"not null;index" json:"classTypeId"`
+	InstructorID uuid.UUID `gorm:"type:uuid;not null;index" json:"instructorId"`
+	Room         string    `gorm:"type:varchar(100)" json:"room"`
+	StartTime    time.Time `gorm:"not null;index" json:"startTime"`
+	EndTime      time.Time `gorm:"not null" json:"endTime"`
+	Capacity     int       `gorm:"not null" json:"capacity"`
+	Status       string    `gorm:"type:varchar(20);default:'scheduled';not null" json:"status"`
+	CancelReason string    `gorm:"type:text" json:"cancelReason"`
+	CreatedByID  uuid.UUID `gorm:"type:uuid;not null" json:"createdById"`
+
+	// Diisi jika sesi dibuat dari ClassSchedule. OccurrenceAt adalah jam mulai asli menurut
+	// aturan pengulangan (tidak berubah walau sesi dipindah jam) agar tidak dibuat dua kali.
+	ScheduleID   *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_class_sessions_occurrence" json:"scheduleId"`
+	OccurrenceAt *time.Time `gorm:"uniqueIndex:idx_class_sessions_occurrence" json:"occurrenceAt"`
+
+	// Waktu booking ditandai attended/no_show setelah kelas dimulai
+	AttendanceMarkedAt *time.Time `json:"attendanceMarkedAt"`
+
+	// Staff yang benar-benar mengajar (default instruktur terjadwal, bisa diganti jika ada pengganti).
+	// Diisi saat kehadiran kelas ditandai; dipakai untuk laporan komisi.
+	DeliveredByID *uuid.UUID `gorm:"type:uuid;index" json:"deliveredById"`
+
+	// Jumlah booking aktif dan daftar tunggu, dihitung saat query (tidak disimpan)
+	BookedCount   int `gorm:"-" json:"bookedCount"`
+	WaitlistCount int `gorm:"-" json:"waitlistCount"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ClassType  ClassType `gorm:"foreignKey:ClassTypeID" json:"classType"`
+	Instructor User      `gorm:"foreignKey:InstructorID" json:"instructor"`
+}
+
+// Status booking kelas
+const (
+	BookingStatusBooked     = "booked"
+	BookingStatusWaitlisted = "waitlisted"
+	BookingStatusCancelled  = "cancelled"
+	BookingStatusAttended   = "attended" // Member Check-In sebelum kelas dimulai
+	BookingStatusNoShow     = "no_show"
+	BookingStatusExpired    = "expired" // Masih di daftar tunggu saat kelas dimulai
+)
+
+// BookingSpotStatuses: Status booking yang menempati kursi kelas
+var BookingSpotStatuses = []string{BookingStatusBooked, BookingStatusAttended, BookingStatusNoShow}
+
+// Booking: Reservasi member pada satu sesi kelas. Satu baris per member per sesi;
+// booking ulang setelah dibatalkan memakai baris yang sama.
+type Booking struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SessionID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_bookings_session_user" json:"sessionId"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_bookings_session_user;index" json:"userId"`
+	Status      string     `gorm:"type:varchar(20);default:'booked';not null" json:"status"`
+	BookedAt    time.Time  `gorm:"not null" json:"bookedAt"` // Juga urutan daftar tunggu
+	PromotedAt  *time.Time `json:"promotedAt"`
+	CancelledAt *time.Time `json:"cancelledAt"`
+
+	// Absensi yang membuktikan kehadiran (status attended)
+	AttendanceID *uuid.UUID `gorm:"type:uuid" json:"attendanceId"`
+
+	// Posisi di daftar tunggu (1 = berikutnya), dihitung saat query
+	WaitlistPosition int `gorm:"-" json:"waitlistPosition,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Session *ClassSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	User    *User         `gorm:"foreignKey:UserID" json:"member,omitempty"`
+}
+
+// ClassSchedule: Jadwal kelas berulang. RRule memakai subset RFC 5545
+// (FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=1;COUNT=10 atau UNTIL=20261231).
+// Sesi dibuat (materialized) beberapa minggu ke depan oleh job terjadwal.
+type ClassSchedule struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ClassTypeID     uint      `gorm:"not null;index" json:"classTypeId"`
+	InstructorID    uuid.UUID `gorm:"type:uuid;not null" json:"instructorId"`
+	Room            string    `gorm:"type:varchar(100)" json:"room"`
+	StartsAt        time.Time `gorm:"not null" json:"startsAt"` // DTSTART: tanggal & jam sesi pertama
+	DurationMinutes int       `gorm:"not null" json:"durationMinutes"`
+	Capacity        int       `gorm:"not null" json:"capacity"`
+	RRule           string    `gorm:"type:varchar(255);not null" json:"rrule"`
+	IsActive        bool      `gorm:"default:true" json:"isActive"`
+	CreatedByID     uuid.UUID `gorm:"type:uuid;not null" json:"createdById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ClassType  ClassType `gorm:"foreignKey:ClassTypeID" json:"classType"`
+	Instructor User      `gorm:"foreignKey:InstructorID" json:"instructor"`
+}
+
+// Measurement: Hasil asesmen fisik member (berat, komposisi tubuh, lingkar, tekanan darah).
+// Semua nilai opsional; BMI dan perubahan dihitung saat query.
+type Measurement struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	RecordedByID uuid.UUID `gorm:"type:uuid;not null" json:"recordedById"`
+	MeasuredAt   time.Time `gorm:"not null;index" json:"measuredAt"`
+
+	HeightCm       *float64 `gorm:"type:decimal(5,1)" json:"heightCm"`
+	WeightKg       *float64 `gorm:"type:decimal(5,1)" json:"weightKg"`
+	BodyFatPercent *float64 `gorm:"type:decimal(4,1)" json:"bodyFatPercent"`
+
+	// Lingkar tubuh (cm)
+	ChestCm *float64 `gorm:"type:decimal(5,1)" json:"chestCm"`
+	WaistCm *float64 `gorm:"type:decimal(5,1)" json:"waistCm"`
+	HipCm   *float64 `gorm:"type:decimal(5,1)" json:"hipCm"`
+	ArmCm   *float64 `gorm:"type:decimal(5,1)" json:"armCm"`
+	ThighCm *float64 `gorm:"type:decimal(5,1)" json:"thighCm"`
+
+	// Tekanan darah (mmHg)
+	SystolicBP  *int `json:"systolicBp"`
+	DiastolicBP *int `json:"diastolicBp"`
+
+	Notes string `gorm:"type:text" json:"notes"`
+
+	// Nilai turunan (tidak disimpan)
+	BMI         *float64           `gorm:"-" json:"bmi"`
+	BMICategory string             `gorm:"-" json:"bmiCategory,omitempty"`
+	Change      *MeasurementChange `gorm:"-" json:"changeSinceLast,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	RecordedBy *User `gorm:"foreignKey:RecordedByID" json:"recordedBy,omitempty"`
+}
+
+// MeasurementChange: Selisih terhadap nilai terakhir yang tercatat pada asesmen sebelumnya
+type MeasurementChange struct {
+	WeightKg       *float64 `json:"weightKg,omitempty"`
+	BodyFatPercent *float64 `json:"bodyFatPercent,omitempty"`
+	BMI            *float64 `json:"bmi,omitempty"`
+	ChestCm        *float64 `json:"chestCm,omitempty"`
+	WaistCm        *float64 `json:"waistCm,omitempty"`
+	HipCm          *float64 `json:"hipCm,omitempty"`
+	ArmCm          *float64 `json:"armCm,omitempty"`
+	ThighCm        *float64 `json:"thighCm,omitempty"`
+	DaysSinceLast  int      `json:"daysSinceLast"`
+}
+
+// PTCredit: Paket sesi personal training milik member (mis. 10 sesi). Jika TrainerID diisi,
+// kredit hanya bisa dipakai dengan trainer tersebut. Kredit dipotong saat sesi selesai.
+type PTCredit struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	TrainerID     *uuid.UUID `gorm:"type:uuid;index" json:"trainerId"`
+	Name          string     `gorm:"type:varchar(100);not null" json:"name"`
+	TotalSessions int        `gorm:"not null" json:"totalSessions"`
+	UsedSessions  int        `gorm:"default:0;not null" json:"usedSessions"`
+	Price         float64    `gorm:"type:decimal(12,2);default:0;not null" json:"price"` // Harga yang dibayar member untuk seluruh paket
+	PaymentID     *uuid.UUID `gorm:"type:uuid" json:"paymentId"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	CreatedByID   uuid.UUID  `gorm:"type:uuid;not null" json:"createdById"`
+
+	// Sesi yang sudah dibooking tetapi belum selesai, dihitung saat query
+	ReservedSessions int `gorm:"-" json:"reservedSessions"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Trainer *User `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
+}
+
+// Status slot ketersediaan trainer
+const (
+	TrainerSlotStatusOpen      = "open"
+	TrainerSlotStatusBooked    = "booked"
+	TrainerSlotStatusCancelled = "cancelled"
+)
+
+// TrainerSlot: Jam kosong yang dibuka trainer untuk sesi personal training
+type TrainerSlot struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TrainerID uuid.UUID `gorm:"type:uuid;not null;index" json:"trainerId"`
+	StartTime time.Time `gorm:"not null;index" json:"startTime"`
+	EndTime   time.Time `gorm:"not null" json:"endTime"`
+	Status    string    `gorm:"type:varchar(20);default:'open';not null" json:"status"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Trainer User `gorm:"foreignKey:TrainerID" json:"trainer"`
+}
+
+// Status janji sesi personal training
+const (
+	PTAppointmentStatusBooked    = "booked"
+	PTAppointmentStatusCancelled = "cancelled"
+	PTAppointmentStatusCompleted = "completed"
+	PTAppointmentStatusNoShow    = "no_show"
+)
+
+// PTAppointment: Janji sesi personal training antara member dan trainer pada satu slot
+type PTAppointment struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	SlotID     uuid.UUID `gorm:"type:uuid;not null;index" json:"slotId"`
+	TrainerID  uuid.UUID `gorm:"type:uuid;not null;index" json:"trainerId"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	CreditID   uuid.UUID `gorm:"type:uuid;not null;index" json:"creditId"`
+	StartTime  time.Time `gorm:"not null;index" json:"startTime"`
+	EndTime    time.Time `gorm:"not null" json:"endTime"`
+	Status     string    `gorm:"type:varchar(20);default:'booked';not null" json:"status"`
+	BookedByID uuid.UUID `gorm:"type:uuid;not null" json:"bookedById"`
+	Notes      string    `gorm:"type:text" json:"notes"`
+
+	CancelledAt   *time.Time `json:"cancelledAt"`
+	CancelledByID *uuid.UUID `gorm:"type:uuid" json:"cancelledById"`
+	CancelReason  string     `gorm:"type:text" json:"cancelReason"`
+	CompletedAt   *time.Time `json:"completedAt"`
+
+	// Staff yang memberikan sesi (default trainer, bisa trainer pengganti); dipakai untuk laporan komisi
+	DeliveredByID *uuid.UUID `gorm:"type:uuid;index" json:"deliveredById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Trainer *User     `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
+	User    *User     `gorm:"foreignKey:UserID" json:"member,omitempty"`
+	Credit  *PTCredit `gorm:"foreignKey:CreditID" json:"credit,omitempty"`
+}
+
+// Jenis sesi & tipe aturan komisi
+const (
+	CommissionSessionPT    = "pt"
+	CommissionSessionClass = "class"
+
+	CommissionTypeFlat       = "flat"       // Nominal tetap per sesi
+	CommissionTypePercentage = "percentage" // Persentase dari harga yang dibayar member per sesi
+)
+
+// CommissionRule: Aturan komisi per jenis sesi. StaffID kosong = aturan default untuk semua staff;
+// aturan khusus staff lebih diutamakan.
+type CommissionRule struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	StaffID     *uuid.UUID `gorm:"type:uuid;index" json:"staffId"`
+	SessionType string     `gorm:"type:varchar(20);not null" json:"sessionType"`
+	Type        string     `gorm:"type:varchar(20);not null" json:"type"`
+	Value       float64    `gorm:"type:decimal(12,2);not null" json:"value"`
+	IsActive    bool       `gorm:"default:true" json:"isActive"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Staff *User `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
+}
+
+// StaffShift: Jadwal kerja staff (roster mingguan disusun admin)
+type StaffShift struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	StaffID     uuid.UUID `gorm:"type:uuid;not null;index" json:"staffId"`
+	StartTime   time.Time `gorm:"not null;index" json:"startTime"`
+	EndTime     time.Time `gorm:"not null" json:"endTime"`
+	Position    string    `gorm:"type:varchar(100)" json:"position"` // mis. Resepsionis, Floor Trainer
+	Notes       string    `gorm:"type:text" json:"notes"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"createdById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Staff User `gorm:"foreignKey:StaffID" json:"staff"`
+}
+
+// TimeClockEntry: Clock-in/clock-out karyawan (pola yang sama dengan Attendance member).
+// ShiftID diisi jika clock-in cocok dengan shift terjadwal.
+type TimeClockEntry struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	StaffID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"staffId"`
+	ShiftID      *uuid.UUID `gorm:"type:uuid;index" json:"shiftId"`
+	ClockInTime  time.Time  `gorm:"not null;index" json:"clockInTime"`
+	ClockOutTime *time.Time `json:"clockOutTime"`
+	LateMinutes  int        `gorm:"default:0;not null" json:"lateMinutes"`
+
+	// Diisi jika admin mengoreksi jam (mis. lupa clock-out)
+	AdjustedByID *uuid.UUID `gorm:"type:uuid" json:"adjustedById"`
+	AdjustNote   string     `gorm:"type:text" json:"adjustNote"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Staff User        `gorm:"foreignKey:StaffID" json:"staff"`
+	Shift *StaffShift `gorm:"foreignKey:ShiftID" json:"shift,omitempty"`
+}
+
+// Aksi audit log
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	// Perubahan password tidak terlihat di diff (hash disembunyikan), jadi dicatat sebagai aksi tersendiri
+	AuditActionPasswordReset  = "password_reset"
+	AuditActionPasswordChange = "password_change"
+	AuditActionTwoFactorOn    = "two_factor_enable"
+	AuditActionTwoFactorOff   = "two_factor_disable"
+	AuditActionRecoveryCodes  = "recovery_codes_regenerate"
+)
+
+// AuditChange: Nilai sebuah field sebelum dan sesudah perubahan
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditLog: Jejak setiap operasi tulis (siapa, apa, kapan, dari mana).
+// Changes hanya berisi field yang berubah; create tidak memiliki Before, delete tidak memiliki After.
+type AuditLog struct {
+	ID         uuid.UUID              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActorID    *uuid.UUID             `gorm:"type:uuid;index" json:"actorId"`
+	ActorRole  string                 `gorm:"type:varchar(50)" json:"actorRole"`
+	DeviceID   *uuid.UUID             `gorm:"type:uuid" json:"deviceId"` // Diisi jika aksi berasal dari kiosk
+	Action     string                 `gorm:"type:varchar(20);not null;index" json:"action"`
+	EntityType string                 `gorm:"type:varchar(50);not null;index:idx_audit_logs_entity" json:"entityType"`
+	EntityID   string                 `gorm:"type:varchar(100);not null;index:idx_audit_logs_entity" json:"entityId"`
+	Changes    map[string]AuditChange `gorm:"type:jsonb;serializer:json" json:"changes"`
+	IPAddress  string                 `gorm:"type:varchar(45)" json:"ipAddress"`
+	CreatedAt  time.Time              `gorm:"index" json:"createdAt"`
+
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
 // --- INPUT STRUCTS ---
 
 type RegisterInput struct {
@@ -61,24 +792,305 @@ type RegisterInput struct {
 }
 
 type LoginInput struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"max=100"` // Opsional, ditampilkan di daftar sesi
 }
 
 type RefreshTokenInput struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
+// UnlockLoginInput: Minimal salah satu diisi
+type UnlockLoginInput struct {
+	Email string `json:"email" binding:"omitempty,email"`
+	IP    string `json:"ip" binding:"omitempty,ip"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// TwoFactorCodeInput: Kode 6 digit dari aplikasi authenticator (atau kode cadangan jika diizinkan)
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	DeviceName     string `json:"deviceName" binding:"max=100"`
+}
+
+type DisableTwoFactorInput struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RoleTwoFactorInput struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
 type CreatePackageInput struct {
-	Name         string  `json:"name" binding:"required"`
-	Price        float64 `json:"price" binding:"required,gt=0"`
-	DurationDays int     `json:"durationDays" binding:"required,gt=0"`
-	Benefits     string  `json:"benefits"`
+	Name          string  `json:"name" binding:"required"`
+	Price         float64 `json:"price" binding:"required,gt=0"`
+	DurationDays  int     `json:"durationDays" binding:"required,gt=0"`
+	Benefits      string  `json:"benefits"`
+	MinFreezeDays int     `json:"minFreezeDays" binding:"gte=0"`
+	MaxFreezeDays int     `json:"maxFreezeDays" binding:"gte=0"`
+	MaxFreezes    int     `json:"maxFreezes" binding:"gte=0"`
+
+	AllowedDays      []int        `json:"allowedDays" binding:"omitempty,dive,min=1,max=7"`
+	AccessWindows    []TimeWindow `json:"accessWindows" binding:"omitempty,dive"`
+	MaxVisits        int          `json:"maxVisits" binding:"gte=0"`
+	MaxVisitsPerWeek int          `json:"maxVisitsPerWeek" binding:"gte=0"`
 }
 
 type UpdatePackageInput struct {
-	Name         string  `json:"name"`
-	Price        float64 `json:"price,omitempty"`
-	DurationDays int     `json:"durationDays,omitempty"`
-	Benefits     string  `json:"benefits"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price,omitempty"`
+	DurationDays  int     `json:"durationDays,omitempty"`
+	Benefits      string  `json:"benefits"`
+	MinFreezeDays *int    `json:"minFreezeDays" binding:"omitempty,gte=0"`
+	MaxFreezeDays *int    `json:"maxFreezeDays" binding:"omitempty,gte=0"`
+	MaxFreezes    *int    `json:"maxFreezes" binding:"omitempty,gte=0"`
+
+	// nil = tidak diubah, array kosong = hapus batasan
+	AllowedDays      *[]int        `json:"allowedDays" binding:"omitempty,dive,min=1,max=7"`
+	AccessWindows    *[]TimeWindow `json:"accessWindows" binding:"omitempty,dive"`
+	MaxVisits        *int          `json:"maxVisits" binding:"omitempty,gte=0"`
+	MaxVisitsPerWeek *int          `json:"maxVisitsPerWeek" binding:"omitempty,gte=0"`
+}
+
+type MembershipInput struct {
+	PackageID uint       `json:"packageId" binding:"required"`
+	StartDate *time.Time `json:"startDate"`
+}
+
+type PaymentInput struct {
+	MemberID     uuid.UUID  `json:"memberId" binding:"required"`
+	MembershipID *uuid.UUID `json:"membershipId"`
+	Amount       float64    `json:"amount" binding:"required,gt=0"`
+	Method       string     `json:"method" binding:"required,oneof=cash transfer qris card"`
+	Reference    string     `json:"reference"`
+	Notes        string     `json:"notes"`
+	PaidAt       *time.Time `json:"paidAt"`
+}
+
+type VoidPaymentInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type FreezeInput struct {
+	StartDate *time.Time `json:"startDate"`
+	Days      int        `json:"days" binding:"required,gt=0"`
+	Reason    string     `json:"reason"`
+}
+
+type DeviceInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AccessCardInput struct {
+	CardNumber     string     `json:"cardNumber" binding:"required"`
+	Label          string     `json:"label"`
+	ReplacesCardID *uuid.UUID `json:"replacesCardId"`
+}
+
+type AccessCardStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=active lost inactive"`
+}
+
+// MemberIdentifier: Salah satu cara mengenali member di meja depan atau kiosk.
+// Tepat satu field harus diisi.
+type MemberIdentifier struct {
+	MemberEmail  string `json:"memberEmail" binding:"omitempty,email"`
+	CardNumber   string `json:"cardNumber"`
+	PhoneNumber  string `json:"phoneNumber"`
+	MemberNumber string `json:"memberNumber"`
+}
+
+type ClassTypeInput struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	DurationMinutes int    `json:"durationMinutes" binding:"required,gt=0"`
+	DefaultCapacity int    `json:"defaultCapacity" binding:"required,gt=0"`
+	IsActive        *bool  `json:"isActive"`
+}
+
+// ClassSessionInput: EndTime dan Capacity opsional, default dari ClassType
+type ClassSessionInput struct {
+	ClassTypeID  uint       `json:"classTypeId" binding:"required"`
+	InstructorID uuid.UUID  `json:"instructorId" binding:"required"`
+	Room         string     `json:"room"`
+	StartTime    time.Time  `json:"startTime" binding:"required"`
+	EndTime      *time.Time `json:"endTime"`
+	Capacity     int        `json:"capacity" binding:"omitempty,gt=0"`
+}
+
+type UpdateClassSessionInput struct {
+	InstructorID *uuid.UUID `json:"instructorId"`
+	Room         *string    `json:"room"`
+	StartTime    *time.Time `json:"startTime"`
+	EndTime      *time.Time `json:"endTime"`
+	Capacity     *int       `json:"capacity" binding:"omitempty,gt=0"`
+}
+
+type CancelClassSessionInput struct {
+	Reason string `json:"reason"`
+}
+
+// ClassScheduleInput: DurationMinutes dan Capacity opsional, default dari ClassType
+type ClassScheduleInput struct {
+	ClassTypeID     uint      `json:"classTypeId" binding:"required"`
+	InstructorID    uuid.UUID `json:"instructorId" binding:"required"`
+	Room            string    `json:"room"`
+	StartsAt        time.Time `json:"startsAt" binding:"required"`
+	DurationMinutes int       `json:"durationMinutes" binding:"omitempty,gt=0"`
+	Capacity        int       `json:"capacity" binding:"omitempty,gt=0"`
+	RRule           string    `json:"rrule" binding:"required"`
+}
+
+// CancelOccurrenceInput: Membatalkan satu kejadian jadwal berulang pada tanggal tertentu (YYYY-MM-DD)
+type CancelOccurrenceInput struct {
+	Date   string `json:"date" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type MeasurementInput struct {
+	MeasuredAt     *time.Time `json:"measuredAt"`
+	HeightCm       *float64   `json:"heightCm" binding:"omitempty,gt=0,lte=300"`
+	WeightKg       *float64   `json:"weightKg" binding:"omitempty,gt=0,lte=500"`
+	BodyFatPercent *float64   `json:"bodyFatPercent" binding:"omitempty,gte=0,lte=100"`
+	ChestCm        *float64   `json:"chestCm" binding:"omitempty,gt=0,lte=300"`
+	WaistCm        *float64   `json:"waistCm" binding:"omitempty,gt=0,lte=300"`
+	HipCm          *float64   `json:"hipCm" binding:"omitempty,gt=0,lte=300"`
+	ArmCm          *float64   `json:"armCm" binding:"omitempty,gt=0,lte=300"`
+	ThighCm        *float64   `json:"thighCm" binding:"omitempty,gt=0,lte=300"`
+	SystolicBP     *int       `json:"systolicBp" binding:"omitempty,gt=0,lte=300"`
+	DiastolicBP    *int       `json:"diastolicBp" binding:"omitempty,gt=0,lte=200"`
+	Notes          string     `json:"notes"`
+}
+
+// AccountEntryInput: Staff memposting tagihan atau potongan
+type AccountEntryInput struct {
+	Type        string  `json:"type" binding:"required,oneof=charge credit"`
+	Category    string  `json:"category" binding:"required"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// SettleAccountInput: Amount kosong berarti melunasi seluruh saldo terutang
+type SettleAccountInput struct {
+	Amount    float64 `json:"amount" binding:"omitempty,gt=0"`
+	Method    string  `json:"method" binding:"required,oneof=cash transfer qris card"`
+	Reference string  `json:"reference"`
+	Notes     string  `json:"notes"`
+}
+
+type TrainerFlagInput struct {
+	IsTrainer bool `json:"isTrainer"`
+}
+
+type PTCreditInput struct {
+	Name          string     `json:"name" binding:"required"`
+	TotalSessions int        `json:"totalSessions" binding:"required,gt=0"`
+	TrainerID     *uuid.UUID `json:"trainerId"`
+	Price         float64    `json:"price" binding:"gte=0"`
+	PaymentID     *uuid.UUID `json:"paymentId"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+}
+
+// TrainerSlotInput: TrainerID hanya dipakai admin; staff membuka slot untuk dirinya sendiri
+type TrainerSlotInput struct {
+	TrainerID *uuid.UUID `json:"trainerId"`
+	StartTime time.Time  `json:"startTime" binding:"required"`
+	EndTime   time.Time  `json:"endTime" binding:"required"`
+}
+
+// BookTrainerSlotInput: Catatan opsional dari member untuk trainer
+type BookTrainerSlotInput struct {
+	Notes string `json:"notes"`
+}
+
+// PTAppointmentInput: Staff membooking slot untuk member
+type PTAppointmentInput struct {
+	SlotID   uuid.UUID `json:"slotId" binding:"required"`
+	MemberID uuid.UUID `json:"memberId" binding:"required"`
+	Notes    string    `json:"notes"`
+}
+
+type CancelPTAppointmentInput struct {
+	Reason string `json:"reason"`
+}
+
+// CompletePTAppointmentInput: NoShow = member tidak datang (kredit tetap dipotong).
+// DeliveredByID diisi jika sesi diberikan oleh trainer pengganti.
+type CompletePTAppointmentInput struct {
+	NoShow        bool       `json:"noShow"`
+	Notes         string     `json:"notes"`
+	DeliveredByID *uuid.UUID `json:"deliveredById"`
+}
+
+// DeliveredByInput: Staff pengganti yang mengajar sesi kelas
+type DeliveredByInput struct {
+	StaffID uuid.UUID `json:"staffId" binding:"required"`
+}
+
+type CommissionRuleInput struct {
+	StaffID     *uuid.UUID `json:"staffId"`
+	SessionType string     `json:"sessionType" binding:"required,oneof=pt class"`
+	Type        string     `json:"type" binding:"required,oneof=flat percentage"`
+	Value       float64    `json:"value" binding:"gte=0"`
+	IsActive    *bool      `json:"isActive"`
+}
+
+type StaffShiftInput struct {
+	StaffID   uuid.UUID `json:"staffId" binding:"required"`
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Position  string    `json:"position"`
+	Notes     string    `json:"notes"`
+}
+
+// CopyShiftWeekInput: Salin roster dari minggu yang memuat FromWeek ke minggu yang memuat ToWeek (YYYY-MM-DD)
+type CopyShiftWeekInput struct {
+	FromWeek string `json:"fromWeek" binding:"required"`
+	ToWeek   string `json:"toWeek" binding:"required"`
+}
+
+// AdjustTimeClockInput: Koreksi jam oleh admin
+type AdjustTimeClockInput struct {
+	ClockInTime  *time.Time `json:"clockInTime"`
+	ClockOutTime *time.Time `json:"clockOutTime"`
+	Note         string     `json:"note" binding:"required"`
+}
+
+// RoleInput: Name hanya dipakai saat membuat role baru
+type RoleInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type StaffRoleInput struct {
+	Role string `json:"role" binding:"required"`
 }
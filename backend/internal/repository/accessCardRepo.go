@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccessCardRepository interface {
+	FindByUserID(userID uuid.UUID) ([]models.AccessCard, error)
+	FindByID(id uuid.UUID) (*models.AccessCard, error)
+	FindByCardNumber(cardNumber string) (*models.AccessCard, error)
+	Create(card *models.AccessCard) error
+	Update(card *models.AccessCard) error
+}
+
+type accessCardRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessCardRepository() AccessCardRepository {
+	return &accessCardRepository{db: config.DB}
+}
+
+// FindByUserID implements AccessCardRepository.
+func (r *accessCardRepository) FindByUserID(userID uuid.UUID) ([]models.AccessCard, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var cards []models.AccessCard
+	if err := r.db.Where("user_id = ?", userID).Order("issued_at DESC").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// FindByID implements AccessCardRepository.
+func (r *accessCardRepository) FindByID(id uuid.UUID) (*models.AccessCard, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var card models.AccessCard
+	if err := r.db.First(&card, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+// FindByCardNumber implements AccessCardRepository.
+func (r *accessCardRepository) FindByCardNumber(cardNumber string) (*models.AccessCard, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var card models.AccessCard
+	if err := r.db.First(&card, "card_number = ?", cardNumber).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Create implements AccessCardRepository.
+func (r *accessCardRepository) Create(card *models.AccessCard) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(card).Error
+}
+
+// Update implements AccessCardRepository.
+func (r *accessCardRepository) Update(card *models.AccessCard) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(card).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	Create(entry *models.AccountEntry) error
+	FindByUserID(userID uuid.UUID) ([]models.AccountEntry, error)
+	Balance(userID uuid.UUID) (float64, error)
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository() AccountRepository {
+	return &accountRepository{db: config.DB}
+}
+
+// Create implements AccountRepository.
+func (r *accountRepository) Create(entry *models.AccountEntry) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(entry).Error
+}
+
+// FindByUserID: Buku tagihan member urut kronologis (untuk saldo berjalan)
+func (r *accountRepository) FindByUserID(userID uuid.UUID) ([]models.AccountEntry, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var entries []models.AccountEntry
+	if err := r.db.Where("user_id = ?", userID).
+		Order("posted_at ASC, created_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Balance: Saldo terutang member (tagihan dikurangi potongan dan pembayaran)
+func (r *accountRepository) Balance(userID uuid.UUID) (float64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var balance float64
+	err := r.db.Model(&models.AccountEntry{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", models.AccountEntryCharge).
+		Where("user_id = ?", userID).
+		Scan(&balance).Error
+	return balance, err
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gym_management/config"
 	"gym_management/internal/models"
+	"hash/fnv"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,11 +12,22 @@ import (
 )
 
 type AttendanceRepository interface {
-	FindUncheckedOutByUserID(userID uuid.UUID) (*models.Attendance, error)
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo AttendanceRepository) error) error
+	// LockCheckIns: pg_advisory_xact_lock yang menyerialkan Check-In di semua replika,
+	// hanya bermakna di dalam Transaction.
+	LockCheckIns() error
+
+	FindUncheckedOutByUserID(userID uuid.UUID, since time.Time) (*models.Attendance, error)
 	Create(attendance *models.Attendance) error
 	Update(attendance *models.Attendance) error
 	FindHistoryByUserID(userID uuid.UUID, limit int) ([]models.Attendance, error)
 	FindAllHistory(filterUserID *uuid.UUID, dateFrom, dateTo *time.Time) ([]models.Attendance, error)
+	FindOpen(since time.Time) ([]models.Attendance, error)
+	CountOpen(since time.Time) (int64, error)
+	FindAllOpen() ([]models.Attendance, error)
+	CountByUserBetween(userID uuid.UUID, from, to time.Time) (int64, error)
+	FindFirstCheckInBetween(userID uuid.UUID, from, to time.Time) (*models.Attendance, error)
 }
 
 type attendanceRepository struct {
@@ -26,16 +38,39 @@ func NewAttendanceRepository() AttendanceRepository {
 	return &attendanceRepository{db: config.DB}
 }
 
-// FindUncheckedOutByUserID: Mencari absensi hari ini yang belum CheckOut
-func (r *attendanceRepository) FindUncheckedOutByUserID(userID uuid.UUID) (*models.Attendance, error) {
+// checkInLockKey: Kunci advisory lock Check-In (kapasitas gym bersifat global)
+var checkInLockKey = func() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("gym_management:check-in"))
+	return int64(h.Sum64())
+}()
+
+// Transaction implements AttendanceRepository.
+func (r *attendanceRepository) Transaction(fn func(repo AttendanceRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&attendanceRepository{db: tx})
+	})
+}
+
+// LockCheckIns implements AttendanceRepository. Lock dilepas otomatis saat transaksi selesai.
+func (r *attendanceRepository) LockCheckIns() error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Exec("SELECT pg_advisory_xact_lock(?)", checkInLockKey).Error
+}
+
+// FindUncheckedOutByUserID: Mencari absensi sejak `since` yang belum CheckOut
+func (r *attendanceRepository) FindUncheckedOutByUserID(userID uuid.UUID, since time.Time) (*models.Attendance, error) {
 	if r.db == nil {
 		return nil, errors.New("database connection not established")
 	}
 	var attendance models.Attendance
 
-	todayStart := time.Now().Truncate(24 * time.Hour) // Awal hari ini
-
-	err := r.db.Where("user_id = ? AND check_out_time IS NULL AND check_in_time >= ?", userID, todayStart).
+	err := r.db.Where("user_id = ? AND check_out_time IS NULL AND check_in_time >= ?", userID, since).
 		Order("check_in_time DESC").
 		First(&attendance).Error
 
@@ -113,3 +148,72 @@ func (r *attendanceRepository) FindAllHistory(filterUserID *uuid.UUID, dateFrom,
 	}
 	return history, nil
 }
+
+// FindOpen: Absensi yang belum Check-Out sejak `since` (member yang sedang berada di gym)
+func (r *attendanceRepository) FindOpen(since time.Time) ([]models.Attendance, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var open []models.Attendance
+	if err := r.db.Preload("User").
+		Where("check_out_time IS NULL AND check_in_time >= ?", since).
+		Order("check_in_time ASC").
+		Find(&open).Error; err != nil {
+		return nil, err
+	}
+	return open, nil
+}
+
+// CountOpen: Jumlah member yang sedang berada di gym
+func (r *attendanceRepository) CountOpen(since time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.Attendance{}).
+		Where("check_out_time IS NULL AND check_in_time >= ?", since).
+		Count(&count).Error
+	return count, err
+}
+
+// FindAllOpen: Semua absensi yang belum Check-Out tanpa batas tanggal (untuk auto Check-Out)
+func (r *attendanceRepository) FindAllOpen() ([]models.Attendance, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var open []models.Attendance
+	if err := r.db.Where("check_out_time IS NULL").Order("check_in_time ASC").Find(&open).Error; err != nil {
+		return nil, err
+	}
+	return open, nil
+}
+
+// CountByUserBetween: Jumlah kunjungan member dengan Check-In pada [from, to)
+func (r *attendanceRepository) CountByUserBetween(userID uuid.UUID, from, to time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.Attendance{}).
+		Where("user_id = ? AND check_in_time >= ? AND check_in_time < ?", userID, from, to).
+		Count(&count).Error
+	return count, err
+}
+
+// FindFirstCheckInBetween: Check-In pertama member pada [from, to]
+func (r *attendanceRepository) FindFirstCheckInBetween(userID uuid.UUID, from, to time.Time) (*models.Attendance, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var attendance models.Attendance
+	err := r.db.Where("user_id = ? AND check_in_time >= ? AND check_in_time <= ?", userID, from, to).
+		Order("check_in_time ASC").
+		First(&attendance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attendance, nil
+}
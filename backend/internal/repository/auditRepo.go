@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter: Filter opsional untuk daftar audit log
+type AuditLogFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	DateFrom   *time.Time
+	DateTo     *time.Time
+	Limit      int
+}
+
+type AuditRepository interface {
+	Create(entry *models.AuditLog) error
+	FindAll(filter AuditLogFilter) ([]models.AuditLog, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository() AuditRepository {
+	return &auditRepository{db: config.DB}
+}
+
+// Create implements AuditRepository.
+func (r *auditRepository) Create(entry *models.AuditLog) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Actor").Create(entry).Error
+}
+
+// FindAll: Audit log terbaru di atas, dengan filter opsional
+func (r *auditRepository) FindAll(filter AuditLogFilter) ([]models.AuditLog, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var logs []models.AuditLog
+
+	query := r.db.Preload("Actor").Order("created_at DESC")
+
+	if filter.ActorID != nil && *filter.ActorID != uuid.Nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("created_at <= ?", *filter.DateTo)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gym_management/config"
 	"gym_management/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -14,6 +15,12 @@ type AuthRepository interface {
 	Create(user *models.User) error
 	Update(user *models.User) error
 	FindByID(id uuid.UUID) (*models.User, error)
+
+	// Token reset password
+	CreateResetToken(token *models.PasswordResetToken) error
+	FindResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error)
+	MarkResetTokenUsed(id uuid.UUID, usedAt time.Time) (bool, error)
+	InvalidateResetTokens(userID uuid.UUID, usedAt time.Time) error
 }
 
 type authRepository struct {
@@ -63,4 +70,51 @@ func (r *authRepository) FindByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// CreateResetToken implements AuthRepository.
+func (r *authRepository) CreateResetToken(token *models.PasswordResetToken) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(token).Error
+}
+
+// FindResetTokenByHash implements AuthRepository.
+func (r *authRepository) FindResetTokenByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var token models.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkResetTokenUsed: Menandai token terpakai secara atomik. false jika token sudah dipakai lebih dulu.
+func (r *authRepository) MarkResetTokenUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// InvalidateResetTokens: Menghanguskan semua token reset user yang belum terpakai
+func (r *authRepository) InvalidateResetTokens(userID uuid.UUID, usedAt time.Time) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", usedAt).Error
+}
+
 // Tambahkan repository untuk member, package, staff, dan attendance di file terpisah
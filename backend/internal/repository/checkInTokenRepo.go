@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CheckInTokenRepository interface {
+	// Consume menandai jti token QR sudah dipakai. false jika jti itu sudah pernah dipakai.
+	Consume(token *models.UsedCheckInToken) (bool, error)
+	// Release membatalkan Consume ketika Check-In ditolak, agar QR yang sama bisa dipindai ulang
+	Release(jti string) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type checkInTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewCheckInTokenRepository() CheckInTokenRepository {
+	return &checkInTokenRepository{db: config.DB}
+}
+
+// Consume implements CheckInTokenRepository.
+func (r *checkInTokenRepository) Consume(token *models.UsedCheckInToken) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release implements CheckInTokenRepository.
+func (r *checkInTokenRepository) Release(jti string) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Where("jti = ?", jti).Delete(&models.UsedCheckInToken{}).Error
+}
+
+// DeleteExpired: jti token yang sudah kedaluwarsa tidak perlu diingat lagi
+func (r *checkInTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	result := r.db.Where("expires_at < ?", before).Delete(&models.UsedCheckInToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,453 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClassSessionFilter: Filter opsional untuk daftar jadwal kelas
+type ClassSessionFilter struct {
+	From         *time.Time
+	To           *time.Time
+	ClassTypeID  *uint
+	InstructorID *uuid.UUID
+	Status       string
+}
+
+type ClassRepository interface {
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo ClassRepository) error) error
+
+	FindAllTypes() ([]models.ClassType, error)
+	FindTypeByID(id uint) (*models.ClassType, error)
+	CreateType(classType *models.ClassType) error
+	UpdateType(classType *models.ClassType) error
+
+	FindSessions(filter ClassSessionFilter) ([]models.ClassSession, error)
+	FindSessionByID(id uuid.UUID) (*models.ClassSession, error)
+	LockSessionByID(id uuid.UUID) (*models.ClassSession, error)
+	CreateSession(session *models.ClassSession) error
+	CreateSessionIfAbsent(session *models.ClassSession) (bool, error)
+	UpdateSession(session *models.ClassSession) error
+	FindSessionByOccurrence(scheduleID uuid.UUID, occurrenceAt time.Time) (*models.ClassSession, error)
+	FindSessionsByScheduleFrom(scheduleID uuid.UUID, from time.Time) ([]models.ClassSession, error)
+	FindSessionsToMark(startedBefore time.Time) ([]models.ClassSession, error)
+
+	FindSchedules(activeOnly bool) ([]models.ClassSchedule, error)
+	FindScheduleByID(id uuid.UUID) (*models.ClassSchedule, error)
+	CreateSchedule(schedule *models.ClassSchedule) error
+	UpdateSchedule(schedule *models.ClassSchedule) error
+
+	FindBookingByID(id uuid.UUID) (*models.Booking, error)
+	FindBooking(sessionID, userID uuid.UUID) (*models.Booking, error)
+	FindBookingsBySessionID(sessionID uuid.UUID) ([]models.Booking, error)
+	FindBookingsByUserID(userID uuid.UUID) ([]models.Booking, error)
+	SaveBooking(booking *models.Booking) error
+	CancelBookingsBySessionID(sessionID uuid.UUID, at time.Time) error
+	FindNextWaitlisted(sessionID uuid.UUID) (*models.Booking, error)
+	CountBooked(sessionID uuid.UUID) (int64, error)
+	CountWaitlistedBefore(sessionID uuid.UUID, before time.Time) (int64, error)
+	CountBookingsBySessionIDs(sessionIDs []uuid.UUID, statuses []string) (map[uuid.UUID]int, error)
+}
+
+type classRepository struct {
+	db *gorm.DB
+}
+
+func NewClassRepository() ClassRepository {
+	return &classRepository{db: config.DB}
+}
+
+// Transaction implements ClassRepository.
+func (r *classRepository) Transaction(fn func(repo ClassRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&classRepository{db: tx})
+	})
+}
+
+// --- Class Types ---
+
+// FindAllTypes implements ClassRepository.
+func (r *classRepository) FindAllTypes() ([]models.ClassType, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var types []models.ClassType
+	if err := r.db.Order("name ASC").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// FindTypeByID implements ClassRepository.
+func (r *classRepository) FindTypeByID(id uint) (*models.ClassType, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var classType models.ClassType
+	if err := r.db.First(&classType, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &classType, nil
+}
+
+// CreateType implements ClassRepository.
+func (r *classRepository) CreateType(classType *models.ClassType) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(classType).Error
+}
+
+// UpdateType implements ClassRepository.
+func (r *classRepository) UpdateType(classType *models.ClassType) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(classType).Error
+}
+
+// --- Class Sessions ---
+
+// FindSessions implements ClassRepository.
+func (r *classRepository) FindSessions(filter ClassSessionFilter) ([]models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var sessions []models.ClassSession
+
+	query := r.db.Preload("ClassType").Preload("Instructor").Order("start_time ASC")
+	if filter.From != nil {
+		query = query.Where("start_time >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_time <= ?", *filter.To)
+	}
+	if filter.ClassTypeID != nil {
+		query = query.Where("class_type_id = ?", *filter.ClassTypeID)
+	}
+	if filter.InstructorID != nil {
+		query = query.Where("instructor_id = ?", *filter.InstructorID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindSessionByID implements ClassRepository.
+func (r *classRepository) FindSessionByID(id uuid.UUID) (*models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var session models.ClassSession
+	if err := r.db.Preload("ClassType").Preload("Instructor").First(&session, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LockSessionByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction.
+// Booking untuk sesi yang sama akan antre di sini sehingga kuota tidak terlampaui.
+func (r *classRepository) LockSessionByID(id uuid.UUID) (*models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var session models.ClassSession
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CreateSession implements ClassRepository.
+func (r *classRepository) CreateSession(session *models.ClassSession) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("ClassType", "Instructor").Create(session).Error
+}
+
+// UpdateSession implements ClassRepository.
+func (r *classRepository) UpdateSession(session *models.ClassSession) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("ClassType", "Instructor").Save(session).Error
+}
+
+// CreateSessionIfAbsent: Insert sesi hasil jadwal berulang; false jika kejadian tersebut
+// sudah pernah dibuat (termasuk yang sudah dibatalkan).
+func (r *classRepository) CreateSessionIfAbsent(session *models.ClassSession) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Omit("ClassType", "Instructor").Clauses(clause.OnConflict{DoNothing: true}).Create(session)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindSessionByOccurrence: Sesi untuk satu kejadian jadwal berulang
+func (r *classRepository) FindSessionByOccurrence(scheduleID uuid.UUID, occurrenceAt time.Time) (*models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var session models.ClassSession
+	if err := r.db.Where("schedule_id = ? AND occurrence_at = ?", scheduleID, occurrenceAt).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindSessionsByScheduleFrom: Sesi jadwal berulang yang dimulai sejak `from`
+func (r *classRepository) FindSessionsByScheduleFrom(scheduleID uuid.UUID, from time.Time) ([]models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var sessions []models.ClassSession
+	if err := r.db.Where("schedule_id = ? AND start_time >= ?", scheduleID, from).
+		Order("start_time ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindSessionsToMark: Sesi yang sudah dimulai tetapi kehadirannya belum ditandai
+func (r *classRepository) FindSessionsToMark(startedBefore time.Time) ([]models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var sessions []models.ClassSession
+	if err := r.db.Where("status = ? AND start_time <= ? AND attendance_marked_at IS NULL", models.ClassSessionStatusScheduled, startedBefore).
+		Order("start_time ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// --- Class Schedules (Berulang) ---
+
+// FindSchedules implements ClassRepository.
+func (r *classRepository) FindSchedules(activeOnly bool) ([]models.ClassSchedule, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var schedules []models.ClassSchedule
+	query := r.db.Preload("ClassType").Preload("Instructor").Order("created_at ASC")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// FindScheduleByID implements ClassRepository.
+func (r *classRepository) FindScheduleByID(id uuid.UUID) (*models.ClassSchedule, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var schedule models.ClassSchedule
+	if err := r.db.Preload("ClassType").Preload("Instructor").First(&schedule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule implements ClassRepository.
+func (r *classRepository) CreateSchedule(schedule *models.ClassSchedule) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("ClassType", "Instructor").Create(schedule).Error
+}
+
+// UpdateSchedule implements ClassRepository.
+func (r *classRepository) UpdateSchedule(schedule *models.ClassSchedule) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("ClassType", "Instructor").Save(schedule).Error
+}
+
+// --- Bookings ---
+
+// FindBookingByID implements ClassRepository.
+func (r *classRepository) FindBookingByID(id uuid.UUID) (*models.Booking, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var booking models.Booking
+	if err := r.db.First(&booking, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// FindBooking: Booking member pada sesi tertentu (apa pun statusnya)
+func (r *classRepository) FindBooking(sessionID, userID uuid.UUID) (*models.Booking, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var booking models.Booking
+	if err := r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// FindBookingsBySessionID: Daftar peserta sesi (Admin/Staff)
+func (r *classRepository) FindBookingsBySessionID(sessionID uuid.UUID) ([]models.Booking, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var bookings []models.Booking
+	if err := r.db.Preload("User").
+		Where("session_id = ?", sessionID).
+		Order("booked_at ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// FindBookingsByUserID: Booking milik member, terbaru dulu
+func (r *classRepository) FindBookingsByUserID(userID uuid.UUID) ([]models.Booking, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var bookings []models.Booking
+	if err := r.db.Preload("Session.ClassType").Preload("Session.Instructor").
+		Joins("JOIN class_sessions ON class_sessions.id = bookings.session_id").
+		Where("bookings.user_id = ?", userID).
+		Order("class_sessions.start_time DESC").
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// SaveBooking: Insert atau update booking
+func (r *classRepository) SaveBooking(booking *models.Booking) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Session", "User").Save(booking).Error
+}
+
+// CancelBookingsBySessionID: Membatalkan semua booking aktif dan daftar tunggu ketika sesi dibatalkan
+func (r *classRepository) CancelBookingsBySessionID(sessionID uuid.UUID, at time.Time) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.Booking{}).
+		Where("session_id = ? AND status IN ?", sessionID, []string{models.BookingStatusBooked, models.BookingStatusWaitlisted}).
+		Updates(map[string]interface{}{"status": models.BookingStatusCancelled, "cancelled_at": at}).Error
+}
+
+// FindNextWaitlisted: Antrean terdepan daftar tunggu (urut waktu mendaftar)
+func (r *classRepository) FindNextWaitlisted(sessionID uuid.UUID) (*models.Booking, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var booking models.Booking
+	if err := r.db.Where("session_id = ? AND status = ?", sessionID, models.BookingStatusWaitlisted).
+		Order("booked_at ASC, created_at ASC").
+		First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// CountBooked: Jumlah kursi yang terisi pada sesi
+func (r *classRepository) CountBooked(sessionID uuid.UUID) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.Booking{}).
+		Where("session_id = ? AND status IN ?", sessionID, models.BookingSpotStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// CountWaitlistedBefore: Jumlah antrean daftar tunggu yang mendaftar sebelum `before`
+func (r *classRepository) CountWaitlistedBefore(sessionID uuid.UUID, before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.Booking{}).
+		Where("session_id = ? AND status = ? AND booked_at < ?", sessionID, models.BookingStatusWaitlisted, before).
+		Count(&count).Error
+	return count, err
+}
+
+// CountBookingsBySessionIDs: Jumlah booking per sesi dengan status tertentu (untuk daftar jadwal)
+func (r *classRepository) CountBookingsBySessionIDs(sessionIDs []uuid.UUID, statuses []string) (map[uuid.UUID]int, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	counts := make(map[uuid.UUID]int, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		SessionID uuid.UUID
+		Count     int
+	}
+	if err := r.db.Model(&models.Booking{}).
+		Select("session_id, COUNT(*) AS count").
+		Where("session_id IN ? AND status IN ?", sessionIDs, statuses).
+		Group("session_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.SessionID] = row.Count
+	}
+	return counts, nil
+}
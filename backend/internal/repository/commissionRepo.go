@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommissionRepository interface {
+	FindRules() ([]models.CommissionRule, error)
+	FindRuleByID(id uint) (*models.CommissionRule, error)
+	FindActiveRule(staffID *uuid.UUID, sessionType string, excludeID uint) (*models.CommissionRule, error)
+	CreateRule(rule *models.CommissionRule) error
+	UpdateRule(rule *models.CommissionRule) error
+	DeleteRule(id uint) error
+
+	FindDeliveredPTAppointments(from, to time.Time) ([]models.PTAppointment, error)
+	FindDeliveredClassSessions(from, to time.Time) ([]models.ClassSession, error)
+	CountAttendedBySessionIDs(sessionIDs []uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+type commissionRepository struct {
+	db *gorm.DB
+}
+
+func NewCommissionRepository() CommissionRepository {
+	return &commissionRepository{db: config.DB}
+}
+
+// FindRules implements CommissionRepository.
+func (r *commissionRepository) FindRules() ([]models.CommissionRule, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var rules []models.CommissionRule
+	if err := r.db.Preload("Staff").Order("session_type ASC, staff_id ASC NULLS FIRST").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FindRuleByID implements CommissionRepository.
+func (r *commissionRepository) FindRuleByID(id uint) (*models.CommissionRule, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var rule models.CommissionRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindActiveRule: Aturan aktif untuk staff (nil = aturan default) dan jenis sesi, selain excludeID
+func (r *commissionRepository) FindActiveRule(staffID *uuid.UUID, sessionType string, excludeID uint) (*models.CommissionRule, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	query := r.db.Where("session_type = ? AND is_active = ? AND id <> ?", sessionType, true, excludeID)
+	if staffID != nil {
+		query = query.Where("staff_id = ?", *staffID)
+	} else {
+		query = query.Where("staff_id IS NULL")
+	}
+
+	var rule models.CommissionRule
+	if err := query.First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule implements CommissionRepository.
+func (r *commissionRepository) CreateRule(rule *models.CommissionRule) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff").Create(rule).Error
+}
+
+// UpdateRule implements CommissionRepository.
+func (r *commissionRepository) UpdateRule(rule *models.CommissionRule) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff").Save(rule).Error
+}
+
+// DeleteRule implements CommissionRepository.
+func (r *commissionRepository) DeleteRule(id uint) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Delete(&models.CommissionRule{}, id).Error
+}
+
+// FindDeliveredPTAppointments: Sesi PT yang sudah diberikan (selesai / member tidak hadir) dalam periode
+func (r *commissionRepository) FindDeliveredPTAppointments(from, to time.Time) ([]models.PTAppointment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var appointments []models.PTAppointment
+	if err := r.db.Preload("User").Preload("Credit").
+		Where("status IN ? AND delivered_by_id IS NOT NULL AND start_time >= ? AND start_time <= ?",
+			[]string{models.PTAppointmentStatusCompleted, models.PTAppointmentStatusNoShow}, from, to).
+		Order("start_time ASC").
+		Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindDeliveredClassSessions: Sesi kelas yang sudah berjalan (kehadiran sudah ditandai) dalam periode
+func (r *commissionRepository) FindDeliveredClassSessions(from, to time.Time) ([]models.ClassSession, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var sessions []models.ClassSession
+	if err := r.db.Preload("ClassType").
+		Where("status = ? AND delivered_by_id IS NOT NULL AND start_time >= ? AND start_time <= ?",
+			models.ClassSessionStatusScheduled, from, to).
+		Order("start_time ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// CountAttendedBySessionIDs: Jumlah peserta yang hadir per sesi kelas
+func (r *commissionRepository) CountAttendedBySessionIDs(sessionIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	counts := make(map[uuid.UUID]int, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		SessionID uuid.UUID
+		Total     int
+	}
+	if err := r.db.Model(&models.Booking{}).
+		Select("session_id, COUNT(*) AS total").
+		Where("session_id IN ? AND status = ?", sessionIDs, models.BookingStatusAttended).
+		Group("session_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.SessionID] = row.Total
+	}
+	return counts, nil
+}
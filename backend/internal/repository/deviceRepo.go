@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DeviceRepository interface {
+	FindAll() ([]models.Device, error)
+	FindByID(id uuid.UUID) (*models.Device, error)
+	FindByKeyHash(keyHash string) (*models.Device, error)
+	Create(device *models.Device) error
+	Update(device *models.Device) error
+	TouchLastUsed(id uuid.UUID, at time.Time) error
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceRepository() DeviceRepository {
+	return &deviceRepository{db: config.DB}
+}
+
+// FindAll implements DeviceRepository.
+func (r *deviceRepository) FindAll() ([]models.Device, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var devices []models.Device
+	if err := r.db.Order("created_at DESC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// FindByID implements DeviceRepository.
+func (r *deviceRepository) FindByID(id uuid.UUID) (*models.Device, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var device models.Device
+	if err := r.db.First(&device, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+// FindByKeyHash implements DeviceRepository.
+func (r *deviceRepository) FindByKeyHash(keyHash string) (*models.Device, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var device models.Device
+	if err := r.db.First(&device, "key_hash = ?", keyHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+// Create implements DeviceRepository.
+func (r *deviceRepository) Create(device *models.Device) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(device).Error
+}
+
+// Update implements DeviceRepository.
+func (r *deviceRepository) Update(device *models.Device) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(device).Error
+}
+
+// TouchLastUsed hanya memperbarui kolom last_used_at (dipanggil di setiap request kiosk)
+func (r *deviceRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.Device{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FreezeRepository: Pembacaan data cuti (riwayat & status Check-In). Penulisan dan pemeriksaan batas
+// cuti dilakukan lewat MembershipRepository agar satu transaksi dengan pergeseran masa aktif langganan.
+type FreezeRepository interface {
+	FindActiveByUserID(userID uuid.UUID, at time.Time) (*models.MembershipFreeze, error)
+	FindByUserID(userID uuid.UUID) ([]models.MembershipFreeze, error)
+}
+
+type freezeRepository struct {
+	db *gorm.DB
+}
+
+func NewFreezeRepository() FreezeRepository {
+	return &freezeRepository{db: config.DB}
+}
+
+// FindActiveByUserID: Cuti yang sedang berlangsung pada waktu `at`
+func (r *freezeRepository) FindActiveByUserID(userID uuid.UUID, at time.Time) (*models.MembershipFreeze, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var freeze models.MembershipFreeze
+	err := r.db.Where("user_id = ? AND ended_at IS NULL AND start_date <= ? AND end_date > ?", userID, at, at).
+		First(&freeze).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &freeze, nil
+}
+
+// FindByUserID: Riwayat cuti member, terbaru di atas
+func (r *freezeRepository) FindByUserID(userID uuid.UUID) ([]models.MembershipFreeze, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var freezes []models.MembershipFreeze
+	if err := r.db.Where("user_id = ?", userID).Order("start_date DESC").Find(&freezes).Error; err != nil {
+		return nil, err
+	}
+	return freezes, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginThrottleRepository menyimpan hitungan login gagal. Implementasi default memakai Postgres;
+// store lain (mis. Redis) cukup memenuhi interface ini dan dipasang lewat NewLoginThrottleServiceWithStore.
+type LoginThrottleRepository interface {
+	Find(keys ...string) ([]models.LoginThrottle, error)
+	// RecordFailure menambah hitungan gagal secara atomik. Hitungan dimulai ulang dari 1 jika
+	// kegagalan terakhir terjadi sebelum windowStart.
+	RecordFailure(key string, at, windowStart time.Time) (*models.LoginThrottle, error)
+	Delete(keys ...string) (int64, error)
+	FindSince(since time.Time) ([]models.LoginThrottle, error)
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository() LoginThrottleRepository {
+	return &loginThrottleRepository{db: config.DB}
+}
+
+// Find implements LoginThrottleRepository.
+func (r *loginThrottleRepository) Find(keys ...string) ([]models.LoginThrottle, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var rows []models.LoginThrottle
+	if err := r.db.Where("key IN ?", keys).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// RecordFailure implements LoginThrottleRepository.
+func (r *loginThrottleRepository) RecordFailure(key string, at, windowStart time.Time) (*models.LoginThrottle, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	row := models.LoginThrottle{Key: key, Failures: 1, LastFailureAt: at}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END", windowStart),
+				"last_failure_at": at,
+				"updated_at":      at,
+			}),
+		},
+		clause.Returning{},
+	).Create(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// Delete implements LoginThrottleRepository.
+func (r *loginThrottleRepository) Delete(keys ...string) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	result := r.db.Where("key IN ?", keys).Delete(&models.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
+
+// FindSince: Hitungan dengan kegagalan terakhir setelah `since`, terbaru di atas
+func (r *loginThrottleRepository) FindSince(since time.Time) ([]models.LoginThrottle, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var rows []models.LoginThrottle
+	if err := r.db.Where("last_failure_at >= ?", since).Order("last_failure_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// DeleteBefore: Membersihkan hitungan yang sudah tidak berpengaruh
+func (r *loginThrottleRepository) DeleteBefore(before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	result := r.db.Where("last_failure_at < ?", before).Delete(&models.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
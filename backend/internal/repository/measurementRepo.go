@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MeasurementRepository interface {
+	Create(measurement *models.Measurement) error
+	FindByID(id uuid.UUID) (*models.Measurement, error)
+	FindByUserID(userID uuid.UUID) ([]models.Measurement, error)
+	Delete(id uuid.UUID) error
+}
+
+type measurementRepository struct {
+	db *gorm.DB
+}
+
+func NewMeasurementRepository() MeasurementRepository {
+	return &measurementRepository{db: config.DB}
+}
+
+// Create implements MeasurementRepository.
+func (r *measurementRepository) Create(measurement *models.Measurement) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("RecordedBy").Create(measurement).Error
+}
+
+// FindByID implements MeasurementRepository.
+func (r *measurementRepository) FindByID(id uuid.UUID) (*models.Measurement, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var measurement models.Measurement
+	if err := r.db.First(&measurement, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &measurement, nil
+}
+
+// FindByUserID: Semua asesmen member, urut kronologis (terlama dulu) untuk grafik tren
+func (r *measurementRepository) FindByUserID(userID uuid.UUID) ([]models.Measurement, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var measurements []models.Measurement
+	if err := r.db.Preload("RecordedBy").
+		Where("user_id = ?", userID).
+		Order("measured_at ASC, created_at ASC").
+		Find(&measurements).Error; err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// Delete implements MeasurementRepository.
+func (r *measurementRepository) Delete(id uuid.UUID) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Delete(&models.Measurement{}, "id = ?", id).Error
+}
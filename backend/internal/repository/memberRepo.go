@@ -13,6 +13,10 @@ type MemberRepository interface {
 	FindAll(search string, isActive *bool) ([]models.User, error)
 	FindByID(id uuid.UUID) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
+	FindByMemberNumber(memberNumber string) (*models.User, error)
+	FindByPhoneNumber(phoneNumber string) ([]models.User, error)
+	FindByCardNumber(cardNumber string) (*models.User, error)
+	NextMemberNumber() (string, error)
 	Create(member *models.User) error
 	Update(member *models.User) error
 	Delete(id uuid.UUID) error
@@ -36,7 +40,7 @@ func (r *memberRepository) FindAll(search string, isActive *bool) ([]models.User
 
 	if search != "" {
 		// ILIKE untuk case-insensitive di PostgreSQL
-		query = query.Where("name ILIKE ? OR email ILIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("name ILIKE ? OR email ILIKE ? OR member_number ILIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
 
 	if isActive != nil {
@@ -99,3 +103,63 @@ func (r *memberRepository) FindByEmail(email string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// FindByMemberNumber implements MemberRepository.
+func (r *memberRepository) FindByMemberNumber(memberNumber string) (*models.User, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var user models.User
+	if err := r.db.Where("UPPER(member_number) = UPPER(?) AND role = ?", memberNumber, "member").First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByPhoneNumber: Nomor telepon tidak unik, sehingga bisa mengembalikan lebih dari satu member.
+// Perbandingan dilakukan pada digit saja dengan awalan 62 dianggap sama dengan 0.
+func (r *memberRepository) FindByPhoneNumber(phoneNumber string) ([]models.User, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var users []models.User
+	err := r.db.Where("regexp_replace(regexp_replace(phone_number, '[^0-9]', '', 'g'), '^62', '0') = ? AND role = ?", phoneNumber, "member").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindByCardNumber: Mencari pemilik kartu RFID/NFC yang masih aktif
+func (r *memberRepository) FindByCardNumber(cardNumber string) (*models.User, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var user models.User
+	err := r.db.Joins("INNER JOIN access_cards ON access_cards.user_id = users.id").
+		Where("access_cards.card_number = ? AND access_cards.status = ? AND users.role = ?", cardNumber, models.AccessCardStatusActive, "member").
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// NextMemberNumber mengambil nomor member berikutnya dari sequence member_number_seq (mis. M000123)
+func (r *memberRepository) NextMemberNumber() (string, error) {
+	if r.db == nil {
+		return "", errors.New("database connection not established")
+	}
+	var number string
+	if err := r.db.Raw("SELECT 'M' || lpad(nextval('member_number_seq')::text, 6, '0')").Scan(&number).Error; err != nil {
+		return "", err
+	}
+	return number, nil
+}
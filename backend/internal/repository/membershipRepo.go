@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository interface {
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo MembershipRepository) error) error
+	// CreateMember/UpdateMember menyimpan data member (paket aktif) bersama perubahan langganannya
+	CreateMember(member *models.User) error
+	UpdateMember(member *models.User) error
+	// CreateFreeze/UpdateFreeze menyimpan data cuti bersama pergeseran masa aktif langganan
+	CreateFreeze(freeze *models.MembershipFreeze) error
+	UpdateFreeze(freeze *models.MembershipFreeze) error
+	// LockMember/LockByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction.
+	// Cuti & unfreeze seorang member diserialkan lewat baris user-nya.
+	LockMember(userID uuid.UUID) error
+	LockByID(id uuid.UUID) (*models.Membership, error)
+	// FindOpenFreeze/CountFreezes dibaca di dalam transaksi yang sama dengan penyimpanan cuti
+	FindOpenFreeze(userID uuid.UUID, at time.Time) (*models.MembershipFreeze, error)
+	CountFreezes(membershipID uuid.UUID) (int64, error)
+
+	Create(membership *models.Membership) error
+	Update(membership *models.Membership) error
+	FindByID(id uuid.UUID) (*models.Membership, error)
+	FindCurrentByUserID(userID uuid.UUID, at time.Time) (*models.Membership, error)
+	FindLatestByUserID(userID uuid.UUID) (*models.Membership, error)
+	FindByUserID(userID uuid.UUID) ([]models.Membership, error)
+	FindStartingFrom(userID uuid.UUID, from time.Time) ([]models.Membership, error)
+	FindEnded(at time.Time) ([]models.Membership, error)
+	FindEndingBetween(from, to time.Time) ([]models.Membership, error)
+}
+
+type membershipRepository struct {
+	db *gorm.DB
+}
+
+func NewMembershipRepository() MembershipRepository {
+	return &membershipRepository{db: config.DB}
+}
+
+// Transaction implements MembershipRepository.
+func (r *membershipRepository) Transaction(fn func(repo MembershipRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&membershipRepository{db: tx})
+	})
+}
+
+// CreateMember implements MembershipRepository.
+func (r *membershipRepository) CreateMember(member *models.User) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(member).Error
+}
+
+// UpdateMember implements MembershipRepository.
+func (r *membershipRepository) UpdateMember(member *models.User) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(member).Error
+}
+
+// CreateFreeze implements MembershipRepository.
+func (r *membershipRepository) CreateFreeze(freeze *models.MembershipFreeze) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(freeze).Error
+}
+
+// UpdateFreeze implements MembershipRepository.
+func (r *membershipRepository) UpdateFreeze(freeze *models.MembershipFreeze) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(freeze).Error
+}
+
+// LockMember implements MembershipRepository.
+func (r *membershipRepository) LockMember(userID uuid.UUID) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	var user models.User
+	return r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error
+}
+
+// LockByID implements MembershipRepository. Package tidak dimuat.
+func (r *membershipRepository) LockByID(id uuid.UUID) (*models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var membership models.Membership
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&membership, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindOpenFreeze: Cuti yang sedang berlangsung atau yang sudah dijadwalkan (belum selesai)
+func (r *membershipRepository) FindOpenFreeze(userID uuid.UUID, at time.Time) (*models.MembershipFreeze, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var freeze models.MembershipFreeze
+	err := r.db.Where("user_id = ? AND ended_at IS NULL AND end_date > ?", userID, at).
+		Order("start_date ASC").
+		First(&freeze).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &freeze, nil
+}
+
+// CountFreezes: Jumlah cuti yang sudah diambil pada satu langganan (termasuk yang dihentikan awal)
+func (r *membershipRepository) CountFreezes(membershipID uuid.UUID) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.MembershipFreeze{}).Where("membership_id = ? AND days > 0", membershipID).Count(&count).Error
+	return count, err
+}
+
+// Create implements MembershipRepository.
+func (r *membershipRepository) Create(membership *models.Membership) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(membership).Error
+}
+
+// Update implements MembershipRepository.
+func (r *membershipRepository) Update(membership *models.Membership) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(membership).Error
+}
+
+// FindByID implements MembershipRepository.
+func (r *membershipRepository) FindByID(id uuid.UUID) (*models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var membership models.Membership
+	if err := r.db.Preload("Package").First(&membership, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindCurrentByUserID: Mencari langganan aktif yang periodenya mencakup waktu `at`
+func (r *membershipRepository) FindCurrentByUserID(userID uuid.UUID, at time.Time) (*models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var membership models.Membership
+	err := r.db.Preload("Package").
+		Where("user_id = ? AND status = ? AND start_date <= ? AND end_date > ?", userID, models.MembershipStatusActive, at, at).
+		Order("end_date DESC").
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindLatestByUserID: Langganan (aktif atau kadaluarsa) dengan tanggal berakhir paling akhir, termasuk yang belum dimulai
+func (r *membershipRepository) FindLatestByUserID(userID uuid.UUID) (*models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var membership models.Membership
+	err := r.db.Preload("Package").
+		Where("user_id = ? AND status IN ?", userID, []string{models.MembershipStatusActive, models.MembershipStatusExpired}).
+		Order("end_date DESC").
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindByUserID: Riwayat seluruh langganan member, terbaru di atas
+func (r *membershipRepository) FindByUserID(userID uuid.UUID) ([]models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var memberships []models.Membership
+	if err := r.db.Preload("Package").Where("user_id = ?", userID).
+		Order("start_date DESC").
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// FindStartingFrom: Langganan aktif member yang dimulai pada atau setelah `from` (perpanjangan yang sudah dibeli)
+func (r *membershipRepository) FindStartingFrom(userID uuid.UUID, from time.Time) ([]models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var memberships []models.Membership
+	if err := r.db.Where("user_id = ? AND status = ? AND start_date >= ?", userID, models.MembershipStatusActive, from).
+		Order("start_date ASC").
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// FindEnded: Langganan berstatus aktif yang periodenya sudah lewat pada waktu `at`
+func (r *membershipRepository) FindEnded(at time.Time) ([]models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var memberships []models.Membership
+	if err := r.db.Where("status = ? AND end_date <= ?", models.MembershipStatusActive, at).
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// FindEndingBetween: Langganan aktif yang berakhir dalam rentang [from, to] dan belum
+// disusul langganan lain milik member yang sama (belum diperpanjang).
+func (r *membershipRepository) FindEndingBetween(from, to time.Time) ([]models.Membership, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var memberships []models.Membership
+	err := r.db.Preload("Package").Preload("User").
+		Where("memberships.status = ? AND memberships.end_date > ? AND memberships.end_date <= ?", models.MembershipStatusActive, from, to).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM memberships next
+			WHERE next.user_id = memberships.user_id AND next.status = ? AND next.end_date > memberships.end_date
+		)`, models.MembershipStatusActive).
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
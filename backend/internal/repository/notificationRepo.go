@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	// CreateIfAbsent menyimpan notifikasi, diabaikan jika Type + ReferenceID sudah ada.
+	CreateIfAbsent(notification *models.Notification) (bool, error)
+	FindDue(now time.Time, limit int) ([]models.Notification, error)
+	MarkSent(id uuid.UUID, sentAt time.Time) error
+	MarkFailed(id uuid.UUID, lastError string, retryAt *time.Time) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository() NotificationRepository {
+	return &notificationRepository{db: config.DB}
+}
+
+// CreateIfAbsent implements NotificationRepository.
+func (r *notificationRepository) CreateIfAbsent(notification *models.Notification) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindDue: Notifikasi pending yang jadwal kirimnya sudah lewat, terlama lebih dulu
+func (r *notificationRepository) FindDue(now time.Time, limit int) ([]models.Notification, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var notifications []models.Notification
+	err := r.db.Where("status = ? AND scheduled_at <= ?", models.NotificationStatusPending, now).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkSent implements NotificationRepository.
+func (r *notificationRepository) MarkSent(id uuid.UUID, sentAt time.Time) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.NotificationStatusSent,
+		"sent_at":    sentAt,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": "",
+		"body":       scrubSensitiveBody,
+	}).Error
+}
+
+// scrubSensitiveBody: Body notifikasi sensitif tidak disimpan lagi setelah tidak akan dikirim ulang
+var scrubSensitiveBody = gorm.Expr("CASE WHEN sensitive THEN '' ELSE body END")
+
+// MarkFailed mencatat percobaan gagal. Notifikasi dicoba lagi pada retryAt, atau ditandai
+// failed jika retryAt nil (batas percobaan habis).
+func (r *notificationRepository) MarkFailed(id uuid.UUID, lastError string, retryAt *time.Time) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	updates := map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": lastError,
+	}
+	if retryAt != nil {
+		updates["scheduled_at"] = *retryAt
+	} else {
+		updates["status"] = models.NotificationStatusFailed
+		updates["body"] = scrubSensitiveBody
+	}
+	return r.db.Model(&models.Notification{}).Where("id = ?", id).Updates(updates).Error
+}
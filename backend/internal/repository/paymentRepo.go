@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentFilter: Filter opsional untuk daftar pembayaran
+type PaymentFilter struct {
+	UserID   *uuid.UUID
+	Method   string
+	Status   string
+	DateFrom *time.Time
+	DateTo   *time.Time
+}
+
+type PaymentRepository interface {
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo PaymentRepository) error) error
+	// LockByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction
+	LockByID(id uuid.UUID) (*models.Payment, error)
+	// CreateAccountEntry/FindAccountEntries menulis buku tagihan bersama pembayarannya
+	CreateAccountEntry(entry *models.AccountEntry) error
+	FindAccountEntries(paymentID uuid.UUID) ([]models.AccountEntry, error)
+
+	Create(payment *models.Payment) error
+	Update(payment *models.Payment) error
+	FindByID(id uuid.UUID) (*models.Payment, error)
+	FindAll(filter PaymentFilter) ([]models.Payment, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository() PaymentRepository {
+	return &paymentRepository{db: config.DB}
+}
+
+// Transaction implements PaymentRepository.
+func (r *paymentRepository) Transaction(fn func(repo PaymentRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&paymentRepository{db: tx})
+	})
+}
+
+// LockByID implements PaymentRepository.
+func (r *paymentRepository) LockByID(id uuid.UUID) (*models.Payment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var payment models.Payment
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CreateAccountEntry implements PaymentRepository.
+func (r *paymentRepository) CreateAccountEntry(entry *models.AccountEntry) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(entry).Error
+}
+
+// FindAccountEntries: Entri buku tagihan yang terkait sebuah pembayaran
+func (r *paymentRepository) FindAccountEntries(paymentID uuid.UUID) ([]models.AccountEntry, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var entries []models.AccountEntry
+	if err := r.db.Where("payment_id = ?", paymentID).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Create implements PaymentRepository.
+func (r *paymentRepository) Create(payment *models.Payment) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(payment).Error
+}
+
+// Update implements PaymentRepository.
+func (r *paymentRepository) Update(payment *models.Payment) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Save(payment).Error
+}
+
+// FindByID implements PaymentRepository.
+func (r *paymentRepository) FindByID(id uuid.UUID) (*models.Payment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var payment models.Payment
+	if err := r.db.Preload("User").Preload("Membership.Package").First(&payment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindAll: Daftar pembayaran terbaru di atas, dengan filter opsional
+func (r *paymentRepository) FindAll(filter PaymentFilter) ([]models.Payment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var payments []models.Payment
+
+	query := r.db.Preload("User").Preload("Membership.Package").Order("paid_at DESC")
+
+	if filter.UserID != nil && *filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Method != "" {
+		query = query.Where("method = ?", filter.Method)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("paid_at >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("paid_at <= ?", *filter.DateTo)
+	}
+
+	if err := query.Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
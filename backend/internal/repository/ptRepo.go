@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PTAppointmentFilter: Filter opsional untuk daftar janji personal training
+type PTAppointmentFilter struct {
+	From      *time.Time
+	To        *time.Time
+	TrainerID *uuid.UUID
+	UserID    *uuid.UUID
+	Status    string
+}
+
+type PTRepository interface {
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo PTRepository) error) error
+
+	FindCreditsByUserID(userID uuid.UUID) ([]models.PTCredit, error)
+	FindCreditByID(id uuid.UUID) (*models.PTCredit, error)
+	LockCreditsByUserID(userID uuid.UUID) ([]models.PTCredit, error)
+	LockCreditByID(id uuid.UUID) (*models.PTCredit, error)
+	CreateCredit(credit *models.PTCredit) error
+	UpdateCredit(credit *models.PTCredit) error
+	CountReservedByCreditIDs(creditIDs []uuid.UUID) (map[uuid.UUID]int, error)
+
+	FindSlots(trainerID *uuid.UUID, from, to *time.Time, status string) ([]models.TrainerSlot, error)
+	FindSlotByID(id uuid.UUID) (*models.TrainerSlot, error)
+	LockSlotByID(id uuid.UUID) (*models.TrainerSlot, error)
+	HasOverlappingSlot(trainerID uuid.UUID, start, end time.Time) (bool, error)
+	CreateSlot(slot *models.TrainerSlot) error
+	UpdateSlot(slot *models.TrainerSlot) error
+
+	FindAppointments(filter PTAppointmentFilter) ([]models.PTAppointment, error)
+	FindAppointmentByID(id uuid.UUID) (*models.PTAppointment, error)
+	LockAppointmentByID(id uuid.UUID) (*models.PTAppointment, error)
+	FindBookedAppointmentBySlotID(slotID uuid.UUID) (*models.PTAppointment, error)
+	CreateAppointment(appointment *models.PTAppointment) error
+	UpdateAppointment(appointment *models.PTAppointment) error
+}
+
+type ptRepository struct {
+	db *gorm.DB
+}
+
+func NewPTRepository() PTRepository {
+	return &ptRepository{db: config.DB}
+}
+
+// Transaction implements PTRepository.
+func (r *ptRepository) Transaction(fn func(repo PTRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&ptRepository{db: tx})
+	})
+}
+
+// --- Kredit Sesi ---
+
+// FindCreditsByUserID implements PTRepository.
+func (r *ptRepository) FindCreditsByUserID(userID uuid.UUID) ([]models.PTCredit, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var credits []models.PTCredit
+	if err := r.db.Preload("Trainer").Where("user_id = ?", userID).Order("created_at DESC").Find(&credits).Error; err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+// FindCreditByID implements PTRepository.
+func (r *ptRepository) FindCreditByID(id uuid.UUID) (*models.PTCredit, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var credit models.PTCredit
+	if err := r.db.First(&credit, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credit, nil
+}
+
+// LockCreditsByUserID: SELECT ... FOR UPDATE atas seluruh kredit member, hanya bermakna di dalam
+// Transaction. Booking paralel untuk member yang sama akan antre sehingga kredit tidak terpakai ganda.
+func (r *ptRepository) LockCreditsByUserID(userID uuid.UUID) ([]models.PTCredit, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var credits []models.PTCredit
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Order("expires_at ASC NULLS LAST, created_at ASC").
+		Find(&credits).Error; err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+// LockCreditByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction.
+func (r *ptRepository) LockCreditByID(id uuid.UUID) (*models.PTCredit, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var credit models.PTCredit
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&credit, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credit, nil
+}
+
+// CreateCredit implements PTRepository.
+func (r *ptRepository) CreateCredit(credit *models.PTCredit) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer").Create(credit).Error
+}
+
+// UpdateCredit implements PTRepository.
+func (r *ptRepository) UpdateCredit(credit *models.PTCredit) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer").Save(credit).Error
+}
+
+// CountReservedByCreditIDs: Jumlah janji berstatus booked per kredit
+func (r *ptRepository) CountReservedByCreditIDs(creditIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	counts := make(map[uuid.UUID]int, len(creditIDs))
+	if len(creditIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CreditID uuid.UUID
+		Total    int
+	}
+	if err := r.db.Model(&models.PTAppointment{}).
+		Select("credit_id, COUNT(*) AS total").
+		Where("credit_id IN ? AND status = ?", creditIDs, models.PTAppointmentStatusBooked).
+		Group("credit_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.CreditID] = row.Total
+	}
+	return counts, nil
+}
+
+// --- Slot Trainer ---
+
+// FindSlots implements PTRepository.
+func (r *ptRepository) FindSlots(trainerID *uuid.UUID, from, to *time.Time, status string) ([]models.TrainerSlot, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var slots []models.TrainerSlot
+
+	query := r.db.Preload("Trainer").Order("start_time ASC")
+	if trainerID != nil {
+		query = query.Where("trainer_id = ?", *trainerID)
+	}
+	if from != nil {
+		query = query.Where("start_time >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("start_time <= ?", *to)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// FindSlotByID implements PTRepository.
+func (r *ptRepository) FindSlotByID(id uuid.UUID) (*models.TrainerSlot, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var slot models.TrainerSlot
+	if err := r.db.Preload("Trainer").First(&slot, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// LockSlotByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction.
+// Dua member yang membooking slot yang sama akan antre di sini.
+func (r *ptRepository) LockSlotByID(id uuid.UUID) (*models.TrainerSlot, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var slot models.TrainerSlot
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// HasOverlappingSlot: Apakah trainer sudah memiliki slot aktif yang beririsan dengan [start, end)
+func (r *ptRepository) HasOverlappingSlot(trainerID uuid.UUID, start, end time.Time) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	var count int64
+	if err := r.db.Model(&models.TrainerSlot{}).
+		Where("trainer_id = ? AND status <> ? AND start_time < ? AND end_time > ?",
+			trainerID, models.TrainerSlotStatusCancelled, end, start).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateSlot implements PTRepository.
+func (r *ptRepository) CreateSlot(slot *models.TrainerSlot) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer").Create(slot).Error
+}
+
+// UpdateSlot implements PTRepository.
+func (r *ptRepository) UpdateSlot(slot *models.TrainerSlot) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer").Save(slot).Error
+}
+
+// --- Janji Personal Training ---
+
+// FindAppointments implements PTRepository.
+func (r *ptRepository) FindAppointments(filter PTAppointmentFilter) ([]models.PTAppointment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var appointments []models.PTAppointment
+
+	query := r.db.Preload("Trainer").Preload("User").Order("start_time ASC")
+	if filter.From != nil {
+		query = query.Where("start_time >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_time <= ?", *filter.To)
+	}
+	if filter.TrainerID != nil {
+		query = query.Where("trainer_id = ?", *filter.TrainerID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// FindAppointmentByID implements PTRepository.
+func (r *ptRepository) FindAppointmentByID(id uuid.UUID) (*models.PTAppointment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var appointment models.PTAppointment
+	if err := r.db.Preload("Trainer").Preload("User").Preload("Credit").First(&appointment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// LockAppointmentByID: SELECT ... FOR UPDATE, hanya bermakna di dalam Transaction.
+func (r *ptRepository) LockAppointmentByID(id uuid.UUID) (*models.PTAppointment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var appointment models.PTAppointment
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&appointment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// FindBookedAppointmentBySlotID: Janji aktif (booked) pada satu slot
+func (r *ptRepository) FindBookedAppointmentBySlotID(slotID uuid.UUID) (*models.PTAppointment, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var appointment models.PTAppointment
+	if err := r.db.Where("slot_id = ? AND status = ?", slotID, models.PTAppointmentStatusBooked).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// CreateAppointment implements PTRepository.
+func (r *ptRepository) CreateAppointment(appointment *models.PTAppointment) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer", "User", "Credit").Create(appointment).Error
+}
+
+// UpdateAppointment implements PTRepository.
+func (r *ptRepository) UpdateAppointment(appointment *models.PTAppointment) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Trainer", "User", "Credit").Save(appointment).Error
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	FindAll() ([]models.Role, error)
+	FindByName(name string) (*models.Role, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(name string) error
+	CountUsers(name string) (int64, error)
+	SetRequireTwoFactor(name string, required bool) error
+	RolesRequiringTwoFactor() ([]string, error)
+
+	FindAllPermissions() ([]models.Permission, error)
+	UpsertPermissions(permissions []models.Permission) error
+	PermissionCodesByRole() (map[string][]string, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository() RoleRepository {
+	return &roleRepository{db: config.DB}
+}
+
+// FindAll implements RoleRepository.
+func (r *roleRepository) FindAll() ([]models.Role, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var roles []models.Role
+	if err := r.db.Preload("Permissions").Order("is_system DESC, name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// FindByName implements RoleRepository.
+func (r *roleRepository) FindByName(name string) (*models.Role, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var role models.Role
+	if err := r.db.Preload("Permissions").First(&role, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Create menyimpan role beserta daftar permission-nya
+func (r *roleRepository) Create(role *models.Role) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Permissions.*").Create(role).Error
+}
+
+// Update menyimpan role dan mengganti seluruh daftar permission-nya
+func (r *roleRepository) Update(role *models.Role) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+// Delete menghapus role beserta relasi permission-nya
+func (r *roleRepository) Delete(name string) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		role := models.Role{Name: name}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+// CountUsers: Jumlah user yang memakai role
+func (r *roleRepository) CountUsers(name string) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	if err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindAllPermissions implements RoleRepository.
+func (r *roleRepository) FindAllPermissions() ([]models.Permission, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var permissions []models.Permission
+	if err := r.db.Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// UpsertPermissions menyinkronkan katalog permission (deskripsi ikut diperbarui)
+func (r *roleRepository) UpsertPermissions(permissions []models.Permission) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).Create(&permissions).Error
+}
+
+// PermissionCodesByRole: Peta nama role -> kode permission (untuk cache middleware)
+func (r *roleRepository) PermissionCodesByRole() (map[string][]string, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var rows []struct {
+		RoleName       string
+		PermissionCode string
+	}
+	if err := r.db.Table("role_permissions").Select("role_name, permission_code").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	codes := map[string][]string{}
+	for _, row := range rows {
+		codes[row.RoleName] = append(codes[row.RoleName], row.PermissionCode)
+	}
+	return codes, nil
+}
+
+// SetRequireTwoFactor implements RoleRepository.
+func (r *roleRepository) SetRequireTwoFactor(name string, required bool) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.Role{}).Where("name = ?", name).Update("require_two_factor", required).Error
+}
+
+// RolesRequiringTwoFactor implements RoleRepository.
+func (r *roleRepository) RolesRequiringTwoFactor() ([]string, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var names []string
+	err := r.db.Model(&models.Role{}).Where("require_two_factor = ?", true).Pluck("name", &names).Error
+	return names, err
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByTokenHash(tokenHash string) (*models.Session, error)
+	// Rotate mencabut sesi lama (alasan rotated) dan menyimpan penggantinya dalam satu transaksi.
+	// false jika sesi lama ternyata sudah dicabut lebih dulu (mis. dipakai request lain bersamaan).
+	Rotate(old *models.Session, next *models.Session) (bool, error)
+	FindActiveByUser(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	IsFamilyActive(familyID uuid.UUID, now time.Time) (bool, error)
+	RevokeFamily(userID, familyID uuid.UUID, reason string, at time.Time) (int64, error)
+	RevokeAllByUser(userID uuid.UUID, reason string, at time.Time) (int64, error)
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository() SessionRepository {
+	return &sessionRepository{db: config.DB}
+}
+
+// Create implements SessionRepository.
+func (r *sessionRepository) Create(session *models.Session) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Create(session).Error
+}
+
+// FindByTokenHash implements SessionRepository.
+func (r *sessionRepository) FindByTokenHash(tokenHash string) (*models.Session, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var session models.Session
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Rotate implements SessionRepository.
+func (r *sessionRepository) Rotate(old *models.Session, next *models.Session) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{"revoked_at": next.CreatedAt, "revoked_reason": models.SessionRevokedRotated})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		rotated = true
+		return tx.Create(next).Error
+	})
+	return rotated, err
+}
+
+// FindActiveByUser: Sesi aktif user (satu baris per keluarga), terakhir dipakai di atas
+func (r *sessionRepository) FindActiveByUser(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// IsFamilyActive implements SessionRepository.
+func (r *sessionRepository) IsFamilyActive(familyID uuid.UUID, now time.Time) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeFamily: Mencabut satu keluarga sesi milik user
+func (r *sessionRepository) RevokeFamily(userID, familyID uuid.UUID, reason string, at time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAllByUser implements SessionRepository.
+func (r *sessionRepository) RevokeAllByUser(userID uuid.UUID, reason string, at time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteBefore: Menghapus keluarga sesi yang seluruh barisnya sudah kedaluwarsa sebelum `before`.
+// Baris rotated/dicabut dari keluarga yang masih bisa dipakai tetap disimpan agar refresh token
+// lama yang dicuri tetap dikenali sebagai pemakaian ulang dan mencabut keluarganya.
+func (r *sessionRepository) DeleteBefore(before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	expiredFamilies := r.db.Model(&models.Session{}).
+		Select("family_id").
+		Group("family_id").
+		Having("MAX(expires_at) < ?", before)
+	result := r.db.Where("family_id IN (?)", expiredFamilies).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
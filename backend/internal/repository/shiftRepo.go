@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"hash/fnv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShiftRepository interface {
+	// Transaction menjalankan fn dengan repository yang terikat pada satu transaksi database
+	Transaction(fn func(repo ShiftRepository) error) error
+	// LockTimeClock: pg_advisory_xact_lock per staff yang menyerialkan clock-in/clock-out
+	// di semua replika, hanya bermakna di dalam Transaction.
+	LockTimeClock(staffID uuid.UUID) error
+
+	FindShifts(staffID *uuid.UUID, from, to time.Time) ([]models.StaffShift, error)
+	FindShiftByID(id uuid.UUID) (*models.StaffShift, error)
+	HasOverlappingShift(staffID uuid.UUID, start, end time.Time, excludeID *uuid.UUID) (bool, error)
+	FindShiftForClockIn(staffID uuid.UUID, at time.Time, earlyWindow time.Duration) (*models.StaffShift, error)
+	CreateShift(shift *models.StaffShift) error
+	UpdateShift(shift *models.StaffShift) error
+	DeleteShift(id uuid.UUID) error
+
+	FindEntryByID(id uuid.UUID) (*models.TimeClockEntry, error)
+	FindOpenEntry(staffID uuid.UUID, since time.Time) (*models.TimeClockEntry, error)
+	FindEntries(staffID *uuid.UUID, from, to *time.Time) ([]models.TimeClockEntry, error)
+	CreateEntry(entry *models.TimeClockEntry) error
+	UpdateEntry(entry *models.TimeClockEntry) error
+}
+
+type shiftRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository() ShiftRepository {
+	return &shiftRepository{db: config.DB}
+}
+
+// Transaction implements ShiftRepository.
+func (r *shiftRepository) Transaction(fn func(repo ShiftRepository) error) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&shiftRepository{db: tx})
+	})
+}
+
+// LockTimeClock implements ShiftRepository. Lock dilepas otomatis saat transaksi selesai.
+func (r *shiftRepository) LockTimeClock(staffID uuid.UUID) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	h := fnv.New64a()
+	h.Write([]byte("gym_management:time-clock:" + staffID.String()))
+	return r.db.Exec("SELECT pg_advisory_xact_lock(?)", int64(h.Sum64())).Error
+}
+
+// --- Shift ---
+
+// FindShifts: Shift yang dimulai dalam [from, to)
+func (r *shiftRepository) FindShifts(staffID *uuid.UUID, from, to time.Time) ([]models.StaffShift, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var shifts []models.StaffShift
+
+	query := r.db.Preload("Staff").Where("start_time >= ? AND start_time < ?", from, to).Order("start_time ASC")
+	if staffID != nil {
+		query = query.Where("staff_id = ?", *staffID)
+	}
+	if err := query.Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+// FindShiftByID implements ShiftRepository.
+func (r *shiftRepository) FindShiftByID(id uuid.UUID) (*models.StaffShift, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var shift models.StaffShift
+	if err := r.db.Preload("Staff").First(&shift, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// HasOverlappingShift: Apakah staff sudah memiliki shift yang beririsan dengan [start, end)
+func (r *shiftRepository) HasOverlappingShift(staffID uuid.UUID, start, end time.Time, excludeID *uuid.UUID) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	query := r.db.Model(&models.StaffShift{}).Where("staff_id = ? AND start_time < ? AND end_time > ?", staffID, end, start)
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindShiftForClockIn: Shift staff yang sedang/akan berjalan (boleh clock-in `earlyWindow` sebelum mulai)
+// dan belum memiliki catatan clock-in
+func (r *shiftRepository) FindShiftForClockIn(staffID uuid.UUID, at time.Time, earlyWindow time.Duration) (*models.StaffShift, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var shift models.StaffShift
+	err := r.db.Where("staff_id = ? AND start_time <= ? AND end_time > ?", staffID, at.Add(earlyWindow), at).
+		Where("NOT EXISTS (SELECT 1 FROM time_clock_entries e WHERE e.shift_id = staff_shifts.id)").
+		Order("start_time ASC").
+		First(&shift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// CreateShift implements ShiftRepository.
+func (r *shiftRepository) CreateShift(shift *models.StaffShift) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff").Create(shift).Error
+}
+
+// UpdateShift implements ShiftRepository.
+func (r *shiftRepository) UpdateShift(shift *models.StaffShift) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff").Save(shift).Error
+}
+
+// DeleteShift implements ShiftRepository.
+func (r *shiftRepository) DeleteShift(id uuid.UUID) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Delete(&models.StaffShift{}, "id = ?", id).Error
+}
+
+// --- Time Clock ---
+
+// FindEntryByID implements ShiftRepository.
+func (r *shiftRepository) FindEntryByID(id uuid.UUID) (*models.TimeClockEntry, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var entry models.TimeClockEntry
+	if err := r.db.Preload("Staff").Preload("Shift").First(&entry, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindOpenEntry: Clock-in terakhir sejak `since` yang belum clock-out
+func (r *shiftRepository) FindOpenEntry(staffID uuid.UUID, since time.Time) (*models.TimeClockEntry, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var entry models.TimeClockEntry
+	err := r.db.Where("staff_id = ? AND clock_out_time IS NULL AND clock_in_time >= ?", staffID, since).
+		Order("clock_in_time DESC").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindEntries: Catatan clock-in dengan filter opsional (terbaru dulu)
+func (r *shiftRepository) FindEntries(staffID *uuid.UUID, from, to *time.Time) ([]models.TimeClockEntry, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var entries []models.TimeClockEntry
+
+	query := r.db.Preload("Staff").Preload("Shift").Order("clock_in_time DESC")
+	if staffID != nil {
+		query = query.Where("staff_id = ?", *staffID)
+	}
+	if from != nil {
+		query = query.Where("clock_in_time >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("clock_in_time <= ?", *to)
+	}
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// CreateEntry implements ShiftRepository.
+func (r *shiftRepository) CreateEntry(entry *models.TimeClockEntry) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff", "Shift").Create(entry).Error
+}
+
+// UpdateEntry implements ShiftRepository.
+func (r *shiftRepository) UpdateEntry(entry *models.TimeClockEntry) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Omit("Staff", "Shift").Save(entry).Error
+}
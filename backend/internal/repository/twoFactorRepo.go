@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	ReplaceRecoveryCodes(userID uuid.UUID, codes []models.RecoveryCode) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error)
+	DeleteRecoveryCodes(userID uuid.UUID) error
+	// ConsumeStep menyimpan periode TOTP terakhir yang dipakai. false jika periode itu (atau
+	// yang lebih baru) sudah pernah dipakai, mis. kode yang sama dikirim dua kali.
+	ConsumeStep(userID uuid.UUID, step int64) (bool, error)
+	// FindLegacySecrets: User dengan secret TOTP yang belum terenkripsi (tanpa awalan prefix)
+	FindLegacySecrets(prefix string) ([]models.User, error)
+	UpdateSecret(userID uuid.UUID, secret string) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository() TwoFactorRepository {
+	return &twoFactorRepository{db: config.DB}
+}
+
+// ReplaceRecoveryCodes: Menghapus kode cadangan lama dan menyimpan set baru dalam satu transaksi
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID, codes []models.RecoveryCode) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode: Menandai kode cadangan terpakai secara atomik. false jika tidak cocok atau sudah dipakai.
+func (r *twoFactorRepository) UseRecoveryCode(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes implements TwoFactorRepository.
+func (r *twoFactorRepository) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	if r.db == nil {
+		return 0, errors.New("database connection not established")
+	}
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteRecoveryCodes implements TwoFactorRepository.
+func (r *twoFactorRepository) DeleteRecoveryCodes(userID uuid.UUID) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// ConsumeStep implements TwoFactorRepository.
+func (r *twoFactorRepository) ConsumeStep(userID uuid.UUID, step int64) (bool, error) {
+	if r.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindLegacySecrets implements TwoFactorRepository.
+func (r *twoFactorRepository) FindLegacySecrets(prefix string) ([]models.User, error) {
+	if r.db == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var users []models.User
+	if err := r.db.Where("totp_secret <> '' AND totp_secret NOT LIKE ?", prefix+"%").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateSecret implements TwoFactorRepository.
+func (r *twoFactorRepository) UpdateSecret(userID uuid.UUID, secret string) error {
+	if r.db == nil {
+		return errors.New("database connection not established")
+	}
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("totp_secret", secret).Error
+}
@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"gym_management/internal/models"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Clock memungkinkan waktu "sekarang" diganti saat pengujian.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock memakai jam sistem.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// Locker menjamin sebuah job hanya dijalankan oleh satu replika API dalam satu waktu.
+// TryLock mengembalikan false (tanpa error) jika lock sedang dipegang replika lain.
+type Locker interface {
+	TryLock(key int64, fn func() error) (bool, error)
+}
+
+// RunStore menyimpan waktu terakhir sebuah job dijalankan. LastSuccess hanya mengembalikan
+// run yang berhasil; run yang gagal tetap dicatat (beserta error-nya) tetapi tidak menggeser jadwal.
+type RunStore interface {
+	LastSuccess(name string) (time.Time, error)
+	SaveRun(name string, at time.Time, runErr error) error
+}
+
+// Job adalah pekerjaan harian yang dijalankan pada jam tertentu (zona waktu scheduler),
+// atau setiap interval tertentu jika Every diisi.
+type Job struct {
+	Name  string
+	At    time.Duration // Offset dari tengah malam, mis. 5*time.Minute untuk 00:05
+	Every time.Duration // Jika > 0: dijalankan setiap interval, dihitung dari tengah malam
+	Run   func(now time.Time) error
+}
+
+type Scheduler struct {
+	clock    Clock
+	locker   Locker
+	store    RunStore
+	loc      *time.Location
+	interval time.Duration
+	jobs     []Job
+}
+
+// New membuat scheduler yang memakai Postgres untuk advisory lock dan pencatatan run.
+func New(db *gorm.DB, clock Clock, loc *time.Location) *Scheduler {
+	return NewWithStore(clock, &PostgresLocker{db: db}, &PostgresRunStore{db: db}, loc)
+}
+
+// NewWithStore membuat scheduler dengan Locker/RunStore kustom (mis. in-memory untuk pengujian).
+func NewWithStore(clock Clock, locker Locker, store RunStore, loc *time.Location) *Scheduler {
+	return &Scheduler{clock: clock, locker: locker, store: store, loc: loc, interval: time.Minute}
+}
+
+// Daily mendaftarkan job yang dijalankan sekali sehari pada offset `at` dari tengah malam.
+func (s *Scheduler) Daily(name string, at time.Duration, run func(now time.Time) error) {
+	s.jobs = append(s.jobs, Job{Name: name, At: at, Run: run})
+}
+
+// Every mendaftarkan job yang dijalankan setiap `interval` (mis. 5 menit), diselaraskan ke tengah malam.
+func (s *Scheduler) Every(name string, interval time.Duration, run func(now time.Time) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Every: interval, Run: run})
+}
+
+// Start menjalankan loop scheduler di goroutine terpisah sampai ctx dibatalkan.
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunDue()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue()
+			}
+		}
+	}()
+}
+
+// RunDue menjalankan semua job yang sudah jatuh tempo pada waktu clock saat ini.
+func (s *Scheduler) RunDue() {
+	now := s.clock.Now()
+	for _, job := range s.jobs {
+		if err := s.runIfDue(job, now); err != nil {
+			log.Printf("Scheduler: job %s gagal: %v", job.Name, err)
+		}
+	}
+}
+
+// scheduledAt menghitung jadwal terakhir job yang seharusnya sudah berjalan pada `now`.
+func (s *Scheduler) scheduledAt(job Job, now time.Time) time.Time {
+	local := now.In(s.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.loc)
+	if job.Every > 0 {
+		return midnight.Add(local.Sub(midnight) / job.Every * job.Every)
+	}
+	scheduled := midnight.Add(job.At)
+	if scheduled.After(local) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+	return scheduled
+}
+
+func (s *Scheduler) runIfDue(job Job, now time.Time) error {
+	scheduled := s.scheduledAt(job, now)
+
+	_, err := s.locker.TryLock(lockKey(job.Name), func() error {
+		// Dibaca ulang di dalam lock agar replika lain yang baru selesai tidak diulang
+		// Hanya run yang berhasil dihitung: job yang gagal dicoba lagi pada tick berikutnya
+		lastSuccess, err := s.store.LastSuccess(job.Name)
+		if err != nil {
+			return err
+		}
+		if !lastSuccess.Before(scheduled) {
+			return nil
+		}
+
+		runErr := job.Run(now)
+		if err := s.store.SaveRun(job.Name, now, runErr); err != nil {
+			return err
+		}
+		return runErr
+	})
+	return err
+}
+
+// lockKey menurunkan kunci advisory lock 64-bit dari nama job.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("gym_management:job:" + name))
+	return int64(h.Sum64())
+}
+
+// PostgresLocker memakai pg_try_advisory_xact_lock; lock dilepas otomatis saat transaksi selesai.
+type PostgresLocker struct {
+	db *gorm.DB
+}
+
+func (l *PostgresLocker) TryLock(key int64, fn func() error) (bool, error) {
+	if l.db == nil {
+		return false, errors.New("database connection not established")
+	}
+	acquired := false
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		return fn()
+	})
+	return acquired, err
+}
+
+// PostgresRunStore menyimpan waktu run pada tabel job_runs.
+type PostgresRunStore struct {
+	db *gorm.DB
+}
+
+func (r *PostgresRunStore) LastSuccess(name string) (time.Time, error) {
+	var run models.JobRun
+	if err := r.db.First(&run, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	if run.LastSuccessAt != nil {
+		return *run.LastSuccessAt, nil
+	}
+	// Baris lama (sebelum kolom last_success_at ada): run terakhir dianggap berhasil jika tanpa error
+	if run.LastError == "" {
+		return run.LastRunAt, nil
+	}
+	return time.Time{}, nil
+}
+
+func (r *PostgresRunStore) SaveRun(name string, at time.Time, runErr error) error {
+	run := models.JobRun{Name: name, LastRunAt: at}
+	columns := []string{"last_run_at", "last_error", "updated_at"}
+	if runErr != nil {
+		run.LastError = runErr.Error()
+	} else {
+		run.LastSuccessAt = &at
+		columns = append(columns, "last_success_at")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&run).Error
+}
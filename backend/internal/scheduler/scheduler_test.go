@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// fakeLocker meniru advisory lock: busy mensimulasikan lock yang dipegang replika lain.
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[int64]bool
+	busy map[int64]bool
+}
+
+func newFakeLocker() *fakeLocker {
+	return &fakeLocker{held: map[int64]bool{}, busy: map[int64]bool{}}
+}
+
+func (l *fakeLocker) TryLock(key int64, fn func() error) (bool, error) {
+	l.mu.Lock()
+	if l.busy[key] || l.held[key] {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.held[key] = true
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.held, key)
+		l.mu.Unlock()
+	}()
+	return true, fn()
+}
+
+func (l *fakeLocker) isHeld(key int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held[key]
+}
+
+type memRunStore struct {
+	runs   map[string]time.Time
+	errors map[string]string
+}
+
+func newMemRunStore() *memRunStore {
+	return &memRunStore{runs: map[string]time.Time{}, errors: map[string]string{}}
+}
+
+func (s *memRunStore) LastSuccess(name string) (time.Time, error) {
+	return s.runs[name], nil
+}
+
+func (s *memRunStore) SaveRun(name string, at time.Time, runErr error) error {
+	s.errors[name] = ""
+	if runErr != nil {
+		s.errors[name] = runErr.Error()
+		return nil
+	}
+	s.runs[name] = at
+	return nil
+}
+
+var jakarta = time.FixedZone("WIB", 7*3600)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2025, 1, day, hour, minute, 0, 0, jakarta)
+}
+
+func TestScheduledAtDaily(t *testing.T) {
+	s := NewWithStore(&fakeClock{}, newFakeLocker(), newMemRunStore(), jakarta)
+	job := Job{Name: "daily", At: 5 * time.Minute}
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{at(10, 0, 4), at(9, 0, 5)},  // Sebelum jadwal hari ini: jadwal kemarin
+		{at(10, 0, 5), at(10, 0, 5)}, // Tepat pada jadwal
+		{at(10, 23, 59), at(10, 0, 5)},
+		{at(10, 0, 4).UTC(), at(9, 0, 5)}, // Waktu UTC dihitung dalam zona scheduler
+	}
+	for _, tc := range cases {
+		if got := s.scheduledAt(job, tc.now); !got.Equal(tc.want) {
+			t.Errorf("scheduledAt(%s) = %s, want %s", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestScheduledAtEvery(t *testing.T) {
+	s := NewWithStore(&fakeClock{}, newFakeLocker(), newMemRunStore(), jakarta)
+	job := Job{Name: "every", Every: 5 * time.Minute}
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{at(10, 0, 0), at(10, 0, 0)},
+		{at(10, 0, 4), at(10, 0, 0)},
+		{at(10, 13, 7), at(10, 13, 5)},
+	}
+	for _, tc := range cases {
+		if got := s.scheduledAt(job, tc.now); !got.Equal(tc.want) {
+			t.Errorf("scheduledAt(%s) = %s, want %s", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestRunDueRunsOncePerSchedule(t *testing.T) {
+	clock := &fakeClock{now: at(10, 0, 6)}
+	s := NewWithStore(clock, newFakeLocker(), newMemRunStore(), jakarta)
+	runs := 0
+	s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+		runs++
+		return nil
+	})
+
+	s.RunDue()
+	clock.now = at(10, 12, 0)
+	s.RunDue()
+	if runs != 1 {
+		t.Fatalf("runs = %d after two ticks on the same day, want 1", runs)
+	}
+
+	clock.now = at(11, 0, 5)
+	s.RunDue()
+	if runs != 2 {
+		t.Fatalf("runs = %d on the next day, want 2", runs)
+	}
+}
+
+func TestRunDueCatchesUpMissedRun(t *testing.T) {
+	// API mati saat jadwal 00:05: job tetap dijalankan saat scheduler menyala lagi
+	store := newMemRunStore()
+	store.runs["expire"] = at(9, 0, 5)
+	s := NewWithStore(&fakeClock{now: at(10, 9, 30)}, newFakeLocker(), store, jakarta)
+	runs := 0
+	s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+		runs++
+		return nil
+	})
+
+	s.RunDue()
+	if runs != 1 {
+		t.Fatalf("runs = %d, want 1", runs)
+	}
+}
+
+func TestRunDueDeduplicatesAcrossReplicas(t *testing.T) {
+	clock := &fakeClock{now: at(10, 0, 6)}
+	locker := newFakeLocker()
+	store := newMemRunStore()
+	runs := 0
+	for range 3 {
+		s := NewWithStore(clock, locker, store, jakarta)
+		s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+			runs++
+			return nil
+		})
+		s.RunDue()
+	}
+	if runs != 1 {
+		t.Fatalf("runs = %d across replicas sharing job_runs, want 1", runs)
+	}
+	if got := store.runs["expire"]; !got.Equal(clock.now) {
+		t.Fatalf("last run = %s, want %s", got, clock.now)
+	}
+}
+
+func TestRunDueHoldsLockWhileRunning(t *testing.T) {
+	locker := newFakeLocker()
+	s := NewWithStore(&fakeClock{now: at(10, 0, 6)}, locker, newMemRunStore(), jakarta)
+	held := false
+	s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+		held = locker.isHeld(lockKey("expire"))
+		return nil
+	})
+
+	s.RunDue()
+	if !held {
+		t.Fatal("job ran without holding its lock")
+	}
+	if locker.isHeld(lockKey("expire")) {
+		t.Fatal("lock still held after job finished")
+	}
+}
+
+func TestRunDueSkipsWhenLockedElsewhere(t *testing.T) {
+	locker := newFakeLocker()
+	locker.busy[lockKey("expire")] = true
+	store := newMemRunStore()
+	s := NewWithStore(&fakeClock{now: at(10, 0, 6)}, locker, store, jakarta)
+	runs := 0
+	s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+		runs++
+		return nil
+	})
+
+	s.RunDue()
+	if runs != 0 {
+		t.Fatalf("runs = %d while another replica holds the lock, want 0", runs)
+	}
+	if _, ok := store.runs["expire"]; ok {
+		t.Fatal("run recorded although the job did not run")
+	}
+}
+
+func TestRunDueRecordsFailure(t *testing.T) {
+	clock := &fakeClock{now: at(10, 0, 6)}
+	store := newMemRunStore()
+	s := NewWithStore(clock, newFakeLocker(), store, jakarta)
+	runs := 0
+	fail := true
+	s.Daily("expire", 5*time.Minute, func(now time.Time) error {
+		runs++
+		if fail {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	s.RunDue()
+	if store.errors["expire"] != "boom" {
+		t.Fatalf("last error = %q, want boom", store.errors["expire"])
+	}
+	// Kegagalan dicoba lagi pada tick berikutnya, bukan menunggu jadwal besok
+	clock.now = at(10, 0, 7)
+	fail = false
+	s.RunDue()
+	if runs != 2 {
+		t.Fatalf("runs = %d, want 2 (retried after failure)", runs)
+	}
+	if store.errors["expire"] != "" {
+		t.Fatalf("last error = %q, want cleared after success", store.errors["expire"])
+	}
+	// Setelah berhasil, job tidak dijalankan lagi sampai jadwal berikutnya
+	clock.now = at(10, 0, 8)
+	s.RunDue()
+	if runs != 2 {
+		t.Fatalf("runs = %d, want 2", runs)
+	}
+}
+
+func TestLockKeyPerJob(t *testing.T) {
+	if lockKey("a") == lockKey("b") {
+		t.Fatal("different jobs share a lock key")
+	}
+	if lockKey("a") != lockKey("a") {
+		t.Fatal("lock key is not stable")
+	}
+}
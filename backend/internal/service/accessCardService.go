@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccessCardService struct {
+	repo       repository.AccessCardRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewAccessCardService() *AccessCardService {
+	return &AccessCardService{
+		repo:       repository.NewAccessCardRepository(),
+		memberRepo: repository.NewMemberRepository(),
+	}
+}
+
+// normalizeCardNumber: Pembaca RFID bisa mengirim huruf kecil/besar dan spasi
+func normalizeCardNumber(cardNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(cardNumber))
+}
+
+// GetCards: Daftar kartu milik member
+func (s *AccessCardService) GetCards(memberID uuid.UUID) ([]models.AccessCard, error) {
+	return s.repo.FindByUserID(memberID)
+}
+
+// IssueCard mendaftarkan kartu baru untuk member. Jika ReplacesCardID diisi,
+// kartu lama ditandai replaced dan tidak bisa dipakai lagi.
+func (s *AccessCardService) IssueCard(memberID uuid.UUID, input models.AccessCardInput, actor Actor) (*models.AccessCard, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	cardNumber := normalizeCardNumber(input.CardNumber)
+	if cardNumber == "" {
+		return nil, errors.New("nomor kartu wajib diisi")
+	}
+	existing, err := s.repo.FindByCardNumber(cardNumber)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa nomor kartu")
+	}
+	if existing != nil {
+		return nil, errors.New("nomor kartu sudah terdaftar")
+	}
+
+	var replaced *models.AccessCard
+	if input.ReplacesCardID != nil {
+		replaced, err = s.repo.FindByID(*input.ReplacesCardID)
+		if err != nil || replaced == nil || replaced.UserID != member.ID {
+			return nil, errors.New("kartu yang diganti tidak ditemukan")
+		}
+	}
+
+	card := models.AccessCard{
+		UserID:     member.ID,
+		CardNumber: cardNumber,
+		Label:      input.Label,
+		Status:     models.AccessCardStatusActive,
+		IssuedAt:   time.Now(),
+	}
+	if err := s.repo.Create(&card); err != nil {
+		return nil, errors.New("gagal menyimpan kartu")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityAccessCard, card.ID, nil, card)
+
+	if replaced != nil {
+		before := *replaced
+		now := time.Now()
+		replaced.Status = models.AccessCardStatusReplaced
+		replaced.DeactivatedAt = &now
+		replaced.ReplacedByID = &card.ID
+		if err := s.repo.Update(replaced); err != nil {
+			return nil, errors.New("gagal menonaktifkan kartu lama")
+		}
+		recordAudit(actor, models.AuditActionUpdate, AuditEntityAccessCard, replaced.ID, before, replaced)
+	}
+	return &card, nil
+}
+
+// UpdateCardStatus menandai kartu hilang/nonaktif, atau mengaktifkannya kembali.
+func (s *AccessCardService) UpdateCardStatus(memberID, cardID uuid.UUID, status string, actor Actor) (*models.AccessCard, error) {
+	card, err := s.repo.FindByID(cardID)
+	if err != nil || card == nil || card.UserID != memberID {
+		return nil, errors.New("kartu tidak ditemukan")
+	}
+	if card.Status == models.AccessCardStatusReplaced {
+		return nil, errors.New("kartu yang sudah diganti tidak bisa diubah")
+	}
+
+	before := *card
+	card.Status = status
+	if status == models.AccessCardStatusActive {
+		card.DeactivatedAt = nil
+	} else if card.DeactivatedAt == nil {
+		now := time.Now()
+		card.DeactivatedAt = &now
+	}
+
+	if err := s.repo.Update(card); err != nil {
+		return nil, errors.New("gagal memperbarui kartu")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityAccessCard, card.ID, before, card)
+	return card, nil
+}
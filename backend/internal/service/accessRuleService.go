@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var weekdayNames = [...]string{"", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// RemainingVisits: Sisa kuota kunjungan member. Nilai nil berarti paket tanpa batas.
+type RemainingVisits struct {
+	PackageName string `json:"packageName"`
+	Total       *int   `json:"total"`
+	ThisWeek    *int   `json:"thisWeek"`
+}
+
+// isoWeekday: 1 = Senin ... 7 = Minggu (zona waktu gym)
+func isoWeekday(t time.Time) int {
+	weekday := int(t.In(config.Location()).Weekday())
+	if weekday == 0 {
+		return 7
+	}
+	return weekday
+}
+
+// startOfWeek: Senin 00:00 (zona waktu gym) untuk minggu yang memuat `t`
+func startOfWeek(t time.Time) time.Time {
+	return config.StartOfDay(t).AddDate(0, 0, 1-isoWeekday(t))
+}
+
+// parseClock membaca jam HH:MM sebagai offset dari tengah malam
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// validateAccessRules memastikan rentang jam akses paket valid
+func validateAccessRules(pkg *models.GymPackage) error {
+	for _, window := range pkg.AccessWindows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return fmt.Errorf("jam mulai akses %q tidak valid, gunakan format HH:MM", window.Start)
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return fmt.Errorf("jam selesai akses %q tidak valid, gunakan format HH:MM", window.End)
+		}
+		if start >= end {
+			return fmt.Errorf("jam akses %s-%s tidak valid: jam mulai harus sebelum jam selesai", window.Start, window.End)
+		}
+	}
+	return nil
+}
+
+// checkAccessRules menolak Check-In di luar hari/jam yang diizinkan paket atau jika kuota kunjungan habis.
+// repo harus terikat transaksi yang memegang LockCheckIns agar kuota tidak terlampaui oleh
+// Check-In bersamaan di replika lain.
+func checkAccessRules(repo repository.AttendanceRepository, membership *models.Membership, at time.Time) error {
+	pkg := &membership.Package
+
+	if len(pkg.AllowedDays) > 0 {
+		today := isoWeekday(at)
+		allowed := false
+		names := make([]string, 0, len(pkg.AllowedDays))
+		for _, day := range pkg.AllowedDays {
+			if day == today {
+				allowed = true
+			}
+			if day >= 1 && day <= 7 {
+				names = append(names, weekdayNames[day])
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("paket %s hanya berlaku pada hari %s", pkg.Name, strings.Join(names, ", "))
+		}
+	}
+
+	if len(pkg.AccessWindows) > 0 {
+		offset := at.Sub(config.StartOfDay(at))
+		allowed := false
+		ranges := make([]string, 0, len(pkg.AccessWindows))
+		for _, window := range pkg.AccessWindows {
+			start, errStart := parseClock(window.Start)
+			end, errEnd := parseClock(window.End)
+			if errStart == nil && errEnd == nil && offset >= start && offset < end {
+				allowed = true
+			}
+			ranges = append(ranges, window.Start+"-"+window.End)
+		}
+		if !allowed {
+			return fmt.Errorf("paket %s hanya berlaku pada jam %s", pkg.Name, strings.Join(ranges, ", "))
+		}
+	}
+
+	remaining, err := remainingVisits(repo, membership, at)
+	if err != nil {
+		return errors.New("gagal memeriksa kuota kunjungan member")
+	}
+	if remaining.Total != nil && *remaining.Total <= 0 {
+		return fmt.Errorf("kuota kunjungan paket %s sudah habis (%d kali)", pkg.Name, pkg.MaxVisits)
+	}
+	if remaining.ThisWeek != nil && *remaining.ThisWeek <= 0 {
+		return fmt.Errorf("batas kunjungan paket %s minggu ini sudah tercapai (%d kali per minggu)", pkg.Name, pkg.MaxVisitsPerWeek)
+	}
+	return nil
+}
+
+// remainingVisits menghitung sisa kunjungan pada periode langganan dan minggu berjalan.
+func remainingVisits(repo repository.AttendanceRepository, membership *models.Membership, at time.Time) (*RemainingVisits, error) {
+	pkg := &membership.Package
+	remaining := &RemainingVisits{PackageName: pkg.Name}
+
+	if pkg.MaxVisits > 0 {
+		used, err := repo.CountByUserBetween(membership.UserID, membership.StartDate, membership.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		total := max(pkg.MaxVisits-int(used), 0)
+		remaining.Total = &total
+	}
+	if pkg.MaxVisitsPerWeek > 0 {
+		weekStart := startOfWeek(at)
+		used, err := repo.CountByUserBetween(membership.UserID, weekStart, weekStart.AddDate(0, 0, 7))
+		if err != nil {
+			return nil, err
+		}
+		thisWeek := max(pkg.MaxVisitsPerWeek-int(used), 0)
+		remaining.ThisWeek = &thisWeek
+	}
+	return remaining, nil
+}
+
+// GetRemainingVisits: Sisa kunjungan untuk langganan member yang sedang berjalan (nil jika tidak ada)
+func GetRemainingVisits(userID uuid.UUID) (*RemainingVisits, error) {
+	now := time.Now()
+	current, err := checkInMemberships.repo.FindCurrentByUserID(userID, now)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa langganan member")
+	}
+	if current == nil {
+		return nil, nil
+	}
+	return remainingVisits(attendanceRepo, current, now)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var accountRepo = repository.NewAccountRepository()
+
+// Mode pemeriksaan saldo saat Check-In (BALANCE_CHECK_MODE)
+const (
+	BalanceCheckOff   = "off"
+	BalanceCheckWarn  = "warn"
+	BalanceCheckBlock = "block"
+)
+
+type AccountService struct {
+	repo       repository.AccountRepository
+	memberRepo repository.MemberRepository
+	payments   *PaymentService
+}
+
+func NewAccountService() *AccountService {
+	return &AccountService{
+		repo:       repository.NewAccountRepository(),
+		memberRepo: repository.NewMemberRepository(),
+		payments:   NewPaymentService(),
+	}
+}
+
+// AccountStatement: Saldo terutang member beserta riwayat entri dan saldo berjalan
+type AccountStatement struct {
+	Balance float64               `json:"balance"`
+	Entries []models.AccountEntry `json:"entries"`
+}
+
+// GetStatement: Buku tagihan member (Admin/Staff, atau member sendiri)
+func (s *AccountService) GetStatement(memberID uuid.UUID) (*AccountStatement, error) {
+	entries, err := s.repo.FindByUserID(memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := 0.0
+	for i := range entries {
+		balance = roundMoney(balance + signedAmount(&entries[i]))
+		entries[i].RunningBalance = balance
+	}
+	return &AccountStatement{Balance: balance, Entries: entries}, nil
+}
+
+// PostEntry memposting tagihan (biaya loker, ganti kartu, denda) atau potongan (Admin/Staff)
+func (s *AccountService) PostEntry(memberID uuid.UUID, input models.AccountEntryInput, actor Actor) (*models.AccountEntry, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+	category := strings.ToLower(strings.TrimSpace(input.Category))
+	if category == "" {
+		return nil, errors.New("kategori tagihan wajib diisi")
+	}
+
+	entry := models.AccountEntry{
+		UserID:      member.ID,
+		Type:        input.Type,
+		Category:    category,
+		Description: input.Description,
+		Amount:      roundMoney(input.Amount),
+		PostedByID:  actor.UserID,
+		PostedAt:    time.Now(),
+	}
+	if err := s.repo.Create(&entry); err != nil {
+		return nil, errors.New("gagal menyimpan tagihan")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityAccountEntry, entry.ID, nil, entry)
+	return &entry, nil
+}
+
+// Settle mencatat pembayaran (Payment) dan mengurangi saldo terutang member sebesar nominalnya.
+// Tanpa nominal, seluruh saldo terutang dilunasi.
+func (s *AccountService) Settle(memberID uuid.UUID, input models.SettleAccountInput, actor Actor) (*models.Payment, *AccountStatement, error) {
+	balance, err := s.repo.Balance(memberID)
+	if err != nil {
+		return nil, nil, errors.New("gagal menghitung saldo tagihan")
+	}
+
+	amount := roundMoney(input.Amount)
+	if amount == 0 {
+		if balance <= 0 {
+			return nil, nil, errors.New("member tidak memiliki tagihan terutang")
+		}
+		amount = roundMoney(balance)
+	}
+
+	notes := input.Notes
+	if notes == "" {
+		notes = "Pelunasan tagihan member"
+	}
+	// Entri pelunasan disimpan bersama pembayarannya (PaymentID & PostedAt diisi recordPayment)
+	entry := models.AccountEntry{
+		UserID:      memberID,
+		Type:        models.AccountEntryPayment,
+		Category:    "payment",
+		Description: notes,
+		Amount:      amount,
+		PostedByID:  actor.UserID,
+	}
+	payment, err := s.payments.recordPayment(models.PaymentInput{
+		MemberID:  memberID,
+		Amount:    amount,
+		Method:    input.Method,
+		Reference: input.Reference,
+		Notes:     notes,
+	}, &entry, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statement, err := s.GetStatement(memberID)
+	if err != nil {
+		return payment, nil, nil
+	}
+	return payment, statement, nil
+}
+
+// reversePaymentEntries mengembalikan saldo terutang ketika pembayaran pelunasan dibatalkan,
+// di dalam transaksi pembatalan pembayaran.
+func reversePaymentEntries(repo repository.PaymentRepository, trail *auditTrail, payment *models.Payment, actor Actor) error {
+	entries, err := repo.FindAccountEntries(payment.ID)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type != models.AccountEntryPayment {
+			continue
+		}
+		paymentID := payment.ID
+		reversal := models.AccountEntry{
+			UserID:      e.UserID,
+			Type:        models.AccountEntryCharge,
+			Category:    "payment_void",
+			Description: "Pembatalan pembayaran: " + payment.VoidReason,
+			Amount:      e.Amount,
+			PaymentID:   &paymentID,
+			PostedByID:  actor.UserID,
+			PostedAt:    time.Now(),
+		}
+		if err := repo.CreateAccountEntry(&reversal); err != nil {
+			return err
+		}
+		trail.add(models.AuditActionCreate, AuditEntityAccountEntry, reversal.ID, nil, reversal)
+	}
+	return nil
+}
+
+// balanceCheckMode: BALANCE_CHECK_MODE = off | warn | block (default off)
+func balanceCheckMode() string {
+	switch mode := strings.ToLower(os.Getenv("BALANCE_CHECK_MODE")); mode {
+	case BalanceCheckWarn, BalanceCheckBlock:
+		return mode
+	default:
+		return BalanceCheckOff
+	}
+}
+
+// balanceCheckThreshold: Saldo terutang maksimum sebelum diperingatkan/ditolak (BALANCE_CHECK_THRESHOLD, default 0)
+func balanceCheckThreshold() float64 {
+	return config.GetEnvFloat("BALANCE_CHECK_THRESHOLD", 0)
+}
+
+// checkBalance memeriksa saldo terutang member saat Check-In. Pada mode warn dikembalikan
+// peringatan untuk resepsionis; pada mode block Check-In ditolak.
+func checkBalance(userID uuid.UUID) ([]string, error) {
+	mode := balanceCheckMode()
+	if mode == BalanceCheckOff {
+		return nil, nil
+	}
+
+	balance, err := accountRepo.Balance(userID)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa saldo tagihan member")
+	}
+	threshold := balanceCheckThreshold()
+	if balance <= threshold {
+		return nil, nil
+	}
+
+	if mode == BalanceCheckBlock {
+		return nil, fmt.Errorf("member memiliki tagihan belum lunas sebesar %s (batas %s), silakan lunasi di resepsionis",
+			formatRupiah(balance), formatRupiah(threshold))
+	}
+	return []string{"Member memiliki tagihan belum lunas sebesar " + formatRupiah(balance)}, nil
+}
+
+// signedAmount: Tagihan bernilai positif, potongan & pembayaran negatif
+func signedAmount(entry *models.AccountEntry) float64 {
+	if entry.Type == models.AccountEntryCharge {
+		return entry.Amount
+	}
+	return -entry.Amount
+}
+
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// formatRupiah: 150000 -> "Rp150.000"
+func formatRupiah(amount float64) string {
+	negative := amount < 0
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(amount))), 10)
+
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if negative {
+		return "-Rp" + b.String()
+	}
+	return "Rp" + b.String()
+}
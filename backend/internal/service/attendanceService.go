@@ -2,8 +2,10 @@ package service
 
 import (
 	"errors"
+	"gym_management/config"
 	"gym_management/internal/models"
 	"gym_management/internal/repository"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,74 +14,198 @@ import (
 var (
 	memberRepo     = repository.NewMemberRepository()
 	attendanceRepo = repository.NewAttendanceRepository()
+
+	checkInMemberships = NewMembershipService()
 )
 
-// CheckInMember: Hanya Staff yang bisa CheckIn
-func CheckInMember(memberEmail string) (*models.Attendance, error) {
-	member, err := memberRepo.FindByEmail(memberEmail)
+// findMemberByIdentifier mencari member berdasarkan email, kartu RFID/NFC, nomor telepon, atau nomor member.
+func findMemberByIdentifier(identifier models.MemberIdentifier) (*models.User, error) {
+	provided := 0
+	for _, value := range []string{identifier.MemberEmail, identifier.CardNumber, identifier.PhoneNumber, identifier.MemberNumber} {
+		if strings.TrimSpace(value) != "" {
+			provided++
+		}
+	}
+	if provided != 1 {
+		return nil, errors.New("isi tepat satu dari email, nomor kartu, nomor telepon, atau nomor member")
+	}
+
+	var member *models.User
+	var err error
+	switch {
+	case identifier.MemberEmail != "":
+		member, err = memberRepo.FindByEmail(identifier.MemberEmail)
+	case identifier.CardNumber != "":
+		member, err = memberRepo.FindByCardNumber(normalizeCardNumber(identifier.CardNumber))
+	case identifier.MemberNumber != "":
+		member, err = memberRepo.FindByMemberNumber(strings.TrimSpace(identifier.MemberNumber))
+	default:
+		var members []models.User
+		members, err = memberRepo.FindByPhoneNumber(normalizePhoneNumber(identifier.PhoneNumber))
+		if len(members) > 1 {
+			return nil, errors.New("nomor telepon terdaftar pada lebih dari satu member, gunakan identitas lain")
+		}
+		if len(members) == 1 {
+			member = &members[0]
+		}
+	}
+
 	if err != nil || member == nil {
 		return nil, errors.New("member tidak ditemukan")
 	}
+	return member, nil
+}
+
+// normalizePhoneNumber menyisakan digit saja dan mengubah awalan 62 menjadi 0 (08xx)
+func normalizePhoneNumber(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	if strings.HasPrefix(normalized, "62") {
+		normalized = "0" + strings.TrimPrefix(normalized, "62")
+	}
+	return normalized
+}
+
+// CheckInMember: Staff atau kiosk (actor.DeviceID diisi jika dari kiosk).
+// overrideHours mengizinkan staff memasukkan member di luar jam operasional.
+func CheckInMember(identifier models.MemberIdentifier, overrideHours bool, actor Actor) (*models.Attendance, error) {
+	member, err := findMemberByIdentifier(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return checkInMember(member, overrideHours, actor)
+}
+
+// checkInMember menjalankan seluruh aturan masuk untuk member yang sudah teridentifikasi
+// (via email, QR, dsb.) lalu menyimpan absensi.
+func checkInMember(member *models.User, overrideHours bool, actor Actor) (*models.Attendance, error) {
 	if !member.IsActive {
 		return nil, errors.New("member tidak aktif")
 	}
 
-	// Cek apakah sudah Check-In. Variabel err diabaikan (menggunakan _) karena
-	// kita hanya peduli pada nilai existingAttendance (nil atau tidak nil)
-	existingAttendance, _ := attendanceRepo.FindUncheckedOutByUserID(member.ID)
-	if existingAttendance != nil {
-		return nil, errors.New("member sudah Check-In dan belum Check-Out")
+	now := time.Now()
+
+	hoursWarnings, err := checkOpeningHours(now, overrideHours, actor)
+	if err != nil {
+		return nil, err
 	}
 
+	// Tolak masuk jika langganan sudah berakhir
+	membership, err := checkInMemberships.checkMembership(member.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreeze(member.ID, now); err != nil {
+		return nil, err
+	}
+	warnings, err := checkBalance(member.ID)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(hoursWarnings, warnings...)
+
 	attendance := models.Attendance{
-		UserID:      member.ID,
-		CheckInTime: time.Now(),
+		UserID:          member.ID,
+		CheckInTime:     now,
+		CheckInDeviceID: actor.DeviceID,
+		Warnings:        warnings,
 	}
 
-	if err := attendanceRepo.Create(&attendance); err != nil {
-		return nil, errors.New("gagal menyimpan Check-In")
+	// Pemeriksaan kapasitas dan penyimpanan dalam satu transaksi di bawah advisory lock,
+	// sehingga Check-In bersamaan di replika berbeda tidak bisa melampaui kapasitas.
+	err = attendanceRepo.Transaction(func(tx repository.AttendanceRepository) error {
+		if err := tx.LockCheckIns(); err != nil {
+			return errors.New("gagal memproses Check-In")
+		}
+
+		// Cek apakah sudah Check-In. Variabel err diabaikan (menggunakan _) karena
+		// kita hanya peduli pada nilai existingAttendance (nil atau tidak nil)
+		existingAttendance, _ := tx.FindUncheckedOutByUserID(member.ID, presentSince(now))
+		if existingAttendance != nil {
+			return errors.New("member sudah Check-In dan belum Check-Out")
+		}
+
+		// Hari/jam akses dan kuota kunjungan paket (dihitung di dalam lock yang sama)
+		if err := checkAccessRules(tx, membership, now); err != nil {
+			return err
+		}
+
+		if err := checkCapacity(tx, now); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&attendance); err != nil {
+			return errors.New("gagal menyimpan Check-In")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityAttendance, attendance.ID, nil, attendance)
+	go publishOccupancy()
 
 	// Preload User/Member untuk response
 	attendance.User = *member
 	return &attendance, nil
 }
 
-// CheckOutMember: Hanya Staff yang bisa CheckOut
-func CheckOutMember(memberEmail string) (*models.Attendance, error) {
-	member, err := memberRepo.FindByEmail(memberEmail)
-	if err != nil || member == nil {
-		return nil, errors.New("member tidak ditemukan")
+// CheckOutMember: Staff atau kiosk (actor.DeviceID diisi jika dari kiosk)
+func CheckOutMember(identifier models.MemberIdentifier, actor Actor) (*models.Attendance, error) {
+	member, err := findMemberByIdentifier(identifier)
+	if err != nil {
+		return nil, err
 	}
 
 	// Variabel err diabaikan karena kita hanya peduli pada nilai latestAttendance
-	latestAttendance, _ := attendanceRepo.FindUncheckedOutByUserID(member.ID)
+	now := time.Now()
+	latestAttendance, _ := attendanceRepo.FindUncheckedOutByUserID(member.ID, presentSince(now))
 	if latestAttendance == nil {
 		return nil, errors.New("member belum Check-In hari ini")
 	}
 
-	now := time.Now()
+	before := *latestAttendance
 	latestAttendance.CheckOutTime = &now
+	latestAttendance.CheckOutDeviceID = actor.DeviceID
 
 	if err := attendanceRepo.Update(latestAttendance); err != nil {
 		return nil, errors.New("gagal menyimpan Check-Out")
 	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityAttendance, latestAttendance.ID, before, latestAttendance)
+	go publishOccupancy()
 
 	// Preload User/Member untuk response
 	latestAttendance.User = *member
 	return latestAttendance, nil
 }
 
+// MyHistory: Histori kunjungan member beserta sisa kuota paketnya
+type MyHistory struct {
+	History         []models.Attendance `json:"history"`
+	RemainingVisits *RemainingVisits    `json:"remainingVisits"`
+}
+
 // GetMyHistory: Untuk member
-func GetMyHistory(userID uuid.UUID) ([]models.Attendance, error) {
-	return attendanceRepo.FindHistoryByUserID(userID, 50)
+func GetMyHistory(userID uuid.UUID) (*MyHistory, error) {
+	history, err := attendanceRepo.FindHistoryByUserID(userID, 50)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := GetRemainingVisits(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &MyHistory{History: history, RemainingVisits: remaining}, nil
 }
 
 // GetAllHistory: Untuk Admin/Staff, mengelola filter dan memanggil repository.
 func GetAllHistory(memberIDStr, dateFromStr, dateToStr string) ([]models.Attendance, error) {
 	var filterUserID *uuid.UUID
-	var dateFrom *time.Time
-	var dateTo *time.Time
 
 	// 1. Parsing Member ID (UUID)
 	if memberIDStr != "" {
@@ -88,31 +214,29 @@ func GetAllHistory(memberIDStr, dateFromStr, dateToStr string) ([]models.Attenda
 		}
 	}
 
-	// 2. Parsing Tanggal Mulai
-	if dateFromStr != "" {
-		// Asumsi format ISO 8601 (YYYY-MM-DDTHH:MM:SSZ)
-		if t, err := time.Parse(time.RFC3339, dateFromStr); err == nil {
-			dateFrom = &t
-		} else {
-			// Jika parsing gagal, coba format tanggal saja (YYYY-MM-DD)
-			if t, err := time.Parse("2006-01-02", dateFromStr); err == nil {
-				dateFrom = &t
-			}
-		}
-	}
-
-	// 3. Parsing Tanggal Selesai
-	if dateToStr != "" {
-		if t, err := time.Parse(time.RFC3339, dateToStr); err == nil {
-			dateTo = &t
-		} else {
-			if t, err := time.Parse("2006-01-02", dateToStr); err == nil {
-				// Untuk dateTo, set waktu ke akhir hari untuk mencakup seluruh hari
-				endOfDay := t.Add(24 * time.Hour).Add(-time.Second)
-				dateTo = &endOfDay
-			}
-		}
-	}
+	// 2. Parsing Rentang Tanggal
+	dateFrom := parseDateParam(dateFromStr, false)
+	dateTo := parseDateParam(dateToStr, true)
 
 	return attendanceRepo.FindAllHistory(filterUserID, dateFrom, dateTo)
 }
+
+// parseDateParam membaca query tanggal dalam format RFC3339 atau YYYY-MM-DD.
+// Format tanggal saja dibaca dalam zona waktu gym. Untuk batas akhir (endOfDay)
+// dibulatkan ke akhir hari agar mencakup seluruh hari.
+func parseDateParam(value string, endOfDay bool) *time.Time {
+	if value == "" {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, config.Location())
+	if err != nil {
+		return nil
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return &t
+}
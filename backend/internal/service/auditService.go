@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"reflect"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var auditRepo = repository.NewAuditRepository()
+
+// Jenis entitas yang dicatat di audit log
+const (
+	AuditEntityMember         = "member"
+	AuditEntityMembership     = "membership"
+	AuditEntityFreeze         = "membership_freeze"
+	AuditEntityAccessCard     = "access_card"
+	AuditEntityMeasurement    = "measurement"
+	AuditEntityAttendance     = "attendance"
+	AuditEntityPayment        = "payment"
+	AuditEntityAccountEntry   = "account_entry"
+	AuditEntityPackage        = "package"
+	AuditEntityStaff          = "staff"
+	AuditEntityRole           = "role"
+	AuditEntityCommissionRule = "commission_rule"
+	AuditEntityDevice         = "device"
+	AuditEntityShift          = "staff_shift"
+	AuditEntityTimeClock      = "time_clock_entry"
+	AuditEntityClassType      = "class_type"
+	AuditEntityClassSession   = "class_session"
+	AuditEntityClassSchedule  = "class_schedule"
+	AuditEntityBooking        = "booking"
+	AuditEntityPTCredit       = "pt_credit"
+	AuditEntityTrainerSlot    = "trainer_slot"
+	AuditEntityPTAppointment  = "pt_appointment"
+	AuditEntityLoginThrottle  = "login_throttle"
+	AuditEntitySession        = "session"
+)
+
+// auditIgnoredFields: Field yang selalu berubah dan tidak informatif di diff
+var auditIgnoredFields = map[string]bool{"createdAt": true, "updatedAt": true}
+
+// Actor: Pelaku sebuah operasi tulis (user atau kiosk), diisi handler dari context request
+// yang disiapkan AuthMiddleware. Dipakai untuk audit log dan pemeriksaan kepemilikan.
+type Actor struct {
+	UserID   uuid.UUID // uuid.Nil jika request berasal dari kiosk
+	Role     string
+	DeviceID *uuid.UUID
+	IP       string
+}
+
+// SystemActor: Pelaku untuk operasi tanpa request (seed data)
+var SystemActor = Actor{Role: "system"}
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{repo: repository.NewAuditRepository()}
+}
+
+// GetLogs: Audit log dengan filter opsional (Admin). Default 200 entri terbaru, maksimum 1000.
+func (s *AuditService) GetLogs(actorIDStr, action, entityType, entityID, dateFromStr, dateToStr, limitStr string) ([]models.AuditLog, error) {
+	filter := repository.AuditLogFilter{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		DateFrom:   parseDateParam(dateFromStr, false),
+		DateTo:     parseDateParam(dateToStr, true),
+		Limit:      200,
+	}
+	if actorIDStr != "" {
+		if id, err := uuid.Parse(actorIDStr); err == nil {
+			filter.ActorID = &id
+		}
+	}
+	if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+		filter.Limit = min(limit, 1000)
+	}
+	return s.repo.FindAll(filter)
+}
+
+// recordAudit mencatat operasi tulis yang sudah berhasil. before kosong (nil) untuk create,
+// after kosong untuk delete. Kegagalan mencatat hanya di-log agar operasi utama tidak ikut gagal.
+func recordAudit(actor Actor, action, entityType string, entityID any, before, after any) {
+	var trail auditTrail
+	trail.add(action, entityType, entityID, before, after)
+	trail.record(actor)
+}
+
+// auditTrail mengumpulkan perubahan yang terjadi di dalam transaksi. Diff dihitung saat add
+// (sebelum entitas diubah lagi), lalu record dipanggil setelah transaksi berhasil commit.
+type auditTrail []models.AuditLog
+
+func (t *auditTrail) add(action, entityType string, entityID any, before, after any) {
+	changes := auditChanges(before, after)
+	if action == models.AuditActionUpdate && len(changes) == 0 {
+		return
+	}
+	*t = append(*t, models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Changes:    changes,
+	})
+}
+
+func (t auditTrail) record(actor Actor) {
+	var actorID *uuid.UUID
+	if actor.UserID != uuid.Nil {
+		id := actor.UserID
+		actorID = &id
+	}
+	for i := range t {
+		entry := &t[i]
+		entry.ActorID = actorID
+		entry.ActorRole = actor.Role
+		entry.DeviceID = actor.DeviceID
+		entry.IPAddress = actor.IP
+		if err := auditRepo.Create(entry); err != nil {
+			log.Printf("Gagal mencatat audit log %s %s %s: %v", entry.Action, entry.EntityType, entry.EntityID, err)
+		}
+	}
+}
+
+// auditChanges membandingkan representasi JSON before dan after per field.
+// Objek relasi (preload) diabaikan; field sensitif sudah tersembunyi lewat tag json:"-".
+func auditChanges(before, after any) map[string]models.AuditChange {
+	beforeFields := auditSnapshot(before)
+	afterFields := auditSnapshot(after)
+
+	changes := make(map[string]models.AuditChange)
+	collect := func(fields map[string]any) {
+		for key := range fields {
+			if _, done := changes[key]; done || auditIgnoredFields[key] {
+				continue
+			}
+			oldValue, newValue := beforeFields[key], afterFields[key]
+			if isAuditObject(oldValue) || isAuditObject(newValue) || reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			changes[key] = models.AuditChange{Before: oldValue, After: newValue}
+		}
+	}
+	collect(beforeFields)
+	collect(afterFields)
+	return changes
+}
+
+// auditSnapshot mengubah entitas menjadi map field JSON-nya (nil jika entitas kosong)
+func auditSnapshot(entity any) map[string]any {
+	if entity == nil {
+		return nil
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+func isAuditObject(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
@@ -2,9 +2,14 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/jwtkeys"
 	"gym_management/internal/models"
 	"gym_management/internal/repository"
+	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,12 +18,50 @@ import (
 )
 
 type AuthClaims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Role   string    `json:"role"`
+	UserID     uuid.UUID `json:"user_id"`
+	Role       string    `json:"role"`
+	Unverified bool      `json:"unverified,omitempty"` // Email belum diverifikasi (akses dibatasi)
+	TwoFactor  bool      `json:"2fa,omitempty"`        // Login sudah melewati verifikasi TOTP
+	SessionID  uuid.UUID `json:"sid"`                  // FamilyID sesi (lihat models.Session)
+	// iss, aud, sub (= user_id), iat, exp, jti
 	jwt.RegisteredClaims
 }
 
+var (
+	accessTokenKeys     *jwtkeys.KeySet
+	accessTokenKeysErr  error
+	accessTokenKeysOnce sync.Once
+)
+
+// LoadSigningKeys memuat kunci access token sekali (lihat jwtkeys.FromEnv). Dipanggil saat startup
+// agar konfigurasi kunci yang salah langsung ketahuan.
+func LoadSigningKeys() (*jwtkeys.KeySet, error) {
+	accessTokenKeysOnce.Do(func() {
+		accessTokenKeys, accessTokenKeysErr = jwtkeys.FromEnv()
+	})
+	return accessTokenKeys, accessTokenKeysErr
+}
+
+// tokenIssuer: Klaim iss access token (JWT_ISSUER, default gym_management)
+func tokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "gym_management"
+}
+
+// tokenAudience: Klaim aud access token (JWT_AUDIENCE, default gym_management-api)
+func tokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "gym_management-api"
+}
+
 var authRepo = repository.NewAuthRepository()
+var authNotifications = NewNotificationService()
+var authMemberships = NewMembershipService()
+var loginThrottle = NewLoginThrottleService()
 
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -30,188 +73,414 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateTokens(user *models.User) (string, string, error) {
-	// access token
+// LoginResult: Hasil login. Jika 2FA aktif, hanya ChallengeToken yang terisi; access/refresh token
+// diberikan setelah kode TOTP diverifikasi (TwoFactorService.VerifyLogin).
+type LoginResult struct {
+	User           *models.User
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
+
+// GenerateAccessToken membuat access token untuk sebuah sesi, ditandatangani kunci aktif (RS256/EdDSA).
+// Status 2FA diambil dari sesi, dan FamilyID sesi dibawa sebagai "sid" agar token ikut tidak berlaku
+// saat sesinya dicabut.
+func GenerateAccessToken(user *models.User, session *models.Session) (string, error) {
+	keys, err := LoadSigningKeys()
+	if err != nil {
+		return "", err
+	}
 	jwtExpiration := 24 // Default to 24 hours if not set
 	if os.Getenv("JWT_EXPIRATION") != "" {
 		if exp, err := time.ParseDuration(os.Getenv("JWT_EXPIRATION") + "h"); err == nil {
 			jwtExpiration = int(exp.Hours())
 		}
 	}
-	expirationTime := time.Now().Add(time.Duration(jwtExpiration) * time.Hour)
+	now := time.Now()
+	expirationTime := now.Add(time.Duration(jwtExpiration) * time.Hour)
 	claims := &AuthClaims{
-		UserID: user.ID,
-		Role:   user.Role,
+		UserID:     user.ID,
+		Role:       user.Role,
+		Unverified: user.EmailVerifiedAt == nil,
+		TwoFactor:  session.TwoFactor,
+		SessionID:  session.FamilyID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    tokenIssuer(),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{tokenAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := accessToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
-	if err != nil {
-		return "", "", err
-	}
+	return keys.Sign(claims)
+}
 
-	// refresh token
+// refreshTokenTTL: Masa berlaku refresh token sejak terakhir dipakai (REFRESH_EXPIRATION jam, default 7 hari)
+func refreshTokenTTL() time.Duration {
 	refreshExpiration := 168 // Default to 168 hours (7 days) if not set
 	if os.Getenv("REFRESH_EXPIRATION") != "" {
 		if exp, err := time.ParseDuration(os.Getenv("REFRESH_EXPIRATION") + "h"); err == nil {
 			refreshExpiration = int(exp.Hours())
 		}
 	}
-	refreshExpirationTime := time.Now().Add(time.Duration(refreshExpiration) * time.Hour)
-	refreshClaims := &AuthClaims{
-		UserID: user.ID,
-		Role:   user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(refreshExpirationTime),
-		},
+	return time.Duration(refreshExpiration) * time.Hour
+}
+
+// issueTokens membuka sesi baru (keluarga refresh token baru) untuk perangkat client dan
+// mengembalikan access & refresh token-nya. Sesi lain milik user tetap berlaku.
+func issueTokens(user *models.User, twoFactor bool, client SessionClient) (string, string, error) {
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", err
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	rt, err := refreshToken.SignedString([]byte(os.Getenv("REFRESH_SECRET")))
 
-	return t, rt, err
+	now := time.Now()
+	session := models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		FamilyID:   uuid.New(),
+		TokenHash:  hashToken(refreshToken),
+		DeviceName: client.deviceName(),
+		UserAgent:  truncate(client.UserAgent, 255),
+		IPAddress:  client.IP,
+		TwoFactor:  twoFactor,
+		SignedInAt: now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL()),
+		CreatedAt:  now,
+	}
+	if err := sessionRepo.Create(&session); err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := GenerateAccessToken(user, &session)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
 }
 
-// validate jwt token
-func ValidateToken(tokenString string) (*AuthClaims, error) {
-	claims := &AuthClaims{}
+// derivedSecret: Nilai env jika diset, jika tidak JWT_SECRET + ":" + purpose. Kosong jika keduanya
+// tidak diset; signToken/parseToken menolak kunci kosong.
+func derivedSecret(env, purpose string) string {
+	if secret := os.Getenv(env); secret != "" {
+		return secret
+	}
+	if base := os.Getenv("JWT_SECRET"); base != "" {
+		return base + ":" + purpose
+	}
+	return ""
+}
+
+// CheckTokenSecrets dipanggil saat startup agar server tidak berjalan dengan kunci token internal
+// yang kosong (token verifikasi email, QR check-in & challenge 2FA bisa dipalsukan).
+func CheckTokenSecrets() error {
+	if emailVerificationSecret() == "" {
+		return errors.New("EMAIL_VERIFICATION_SECRET atau JWT_SECRET belum diatur")
+	}
+	if checkInSecret() == "" {
+		return errors.New("QR_SECRET atau JWT_SECRET belum diatur")
+	}
+	_, err := twoFactorChallengeSecret()
+	return err
+}
+
+// signToken menandatangani claims dengan HS256. Dipakai token internal yang hanya dibaca aplikasi ini
+// (verifikasi email, challenge 2FA, QR check-in); access token memakai GenerateAccessToken.
+func signToken(claims jwt.Claims, secret string) (string, error) {
+	if secret == "" {
+		return "", errors.New("kunci token belum diatur")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// parseToken memverifikasi tanda tangan HMAC dan masa berlaku, lalu mengisi claims.
+func parseToken(tokenString string, claims jwt.Claims, secret string) error {
+	if secret == "" {
+		return errors.New("kunci token belum diatur")
+	}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return []byte(secret), nil
 	})
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if !token.Valid {
-		return nil, errors.New("token is invalid")
+		return errors.New("token is invalid")
 	}
 
+	return nil
+}
+
+// ValidateToken memverifikasi access token dengan kunci sesuai kid (termasuk kunci lama yang masih
+// diterima setelah rotasi), lalu memeriksa iss, aud, iat, exp dan kecocokan sub dengan user_id.
+func ValidateToken(tokenString string) (*AuthClaims, error) {
+	keys, err := LoadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &AuthClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithAudience(tokenAudience()),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	token, err := parser.ParseWithClaims(tokenString, claims, keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.Subject != claims.UserID.String() {
+		return nil, errors.New("token is invalid")
+	}
 	return claims, nil
 }
 
-// registermembersevice handles member regis logic
-func RegisterMemberService(input models.RegisterInput) (*models.User, string, string, error) {
+// JWKS: Public key access token untuk /.well-known/jwks.json, agar layanan lain bisa memverifikasi token
+func JWKS() (jwtkeys.JWKS, error) {
+	keys, err := LoadSigningKeys()
+	if err != nil {
+		return jwtkeys.JWKS{}, err
+	}
+	return keys.JWKS(), nil
+}
+
+// registermembersevice handles member regis logic. actor hanya membawa IP; pelakunya member baru itu sendiri.
+func RegisterMemberService(input models.RegisterInput, actor Actor, client SessionClient) (*models.User, string, string, error) {
 	existingUser, _ := authRepo.FindByEmail(input.Email)
 	if existingUser != nil {
 		return nil, "", "", errors.New("email sudah terdaftar")
 	}
 
+	var pkg *models.GymPackage
+	if input.PackageID != nil {
+		found, err := authMemberships.findPackage(*input.PackageID)
+		if err != nil {
+			return nil, "", "", err
+		}
+		pkg = found
+	}
+
 	hashedPassword, err := HashPassword(input.Password)
 	if err != nil {
 		return nil, "", "", errors.New("gagal hash password")
 	}
 
+	memberNumber, err := memberRepo.NextMemberNumber()
+	if err != nil {
+		return nil, "", "", errors.New("gagal membuat nomor member")
+	}
+
 	newUser := models.User{
 		ID:           uuid.New(),
 		Name:         input.Name,
 		Email:        input.Email,
 		PasswordHash: hashedPassword,
 		Role:         "member", // Hardcoded role
+		MemberNumber: &memberNumber,
 		PhoneNumber:  input.PhoneNumber,
 		Address:      input.Address,
 		PackageID:    input.PackageID,
 		IsActive:     true,
 	}
 
-	if err := authRepo.Create(&newUser); err != nil {
+	// Langganan pertama dimulai saat registrasi, disimpan bersama akunnya
+	actor.UserID = newUser.ID
+	actor.Role = newUser.Role
+	if err := authMemberships.createMember(&newUser, pkg, time.Now(), actor); err != nil {
 		return nil, "", "", err
 	}
 
-	accessToken, refreshToken, _ := GenerateTokens(&newUser)
-	newUser.RefreshToken = refreshToken
-	authRepo.Update(&newUser)
+	if err := sendVerificationEmail(&newUser); err != nil {
+		log.Println("Gagal mengirim email verifikasi:", err)
+	}
+	// Tanpa token sampai email diverifikasi jika login akun belum terverifikasi tidak diizinkan
+	if !UnverifiedLoginAllowed() {
+		return &newUser, "", "", nil
+	}
+
+	accessToken, refreshToken, _ := issueTokens(&newUser, false, client)
 
 	return &newUser, accessToken, refreshToken, nil
 }
 
-// login service handles user login
-func LoginService(email, password string) (*models.User, string, string, error) {
+// login service handles user login. Akun dengan 2FA aktif mendapat challenge token, bukan access token.
+// Percobaan gagal dihitung per akun dan per IP (lihat LoginThrottleService); saat terblokir
+// dikembalikan *LoginLockedError.
+func LoginService(email, password string, client SessionClient) (*LoginResult, error) {
+	now := time.Now()
+	ip := client.IP
+	if err := loginThrottle.Check(email, ip, now); err != nil {
+		return nil, err
+	}
+
 	user, err := authRepo.FindByEmail(email)
 	if err != nil {
-		return nil, "", "", errors.New("kredensial tidak valid")
+		return nil, errors.New("kredensial tidak valid")
 	}
 	if user == nil || !CheckPasswordHash(password, user.PasswordHash) {
-		return nil, "", "", errors.New("kredensial tidak valid")
+		if err := loginThrottle.RecordFailure(email, ip, now); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("kredensial tidak valid")
 	}
+
 	if !user.IsActive {
-		return nil, "", "", errors.New("akun tidak aktif")
+		return nil, errors.New("akun tidak aktif")
+	}
+	if user.EmailVerifiedAt == nil && !UnverifiedLoginAllowed() {
+		return nil, errors.New("email belum diverifikasi")
 	}
 
-	accessToken, refreshToken, _ := GenerateTokens(user)
-	user.RefreshToken = refreshToken
-	authRepo.Update(user)
-
-	return user, accessToken, refreshToken, nil
-}
-
-// ValidateRefreshToken parses and validates the refresh token.
-func ValidateRefreshToken(tokenString string) (*AuthClaims, error) {
-	claims := &AuthClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+	if user.TwoFactorEnabled {
+		challenge, err := issueTwoFactorChallenge(user)
+		if err != nil {
+			return nil, errors.New("gagal membuat challenge 2FA")
 		}
-		return []byte(os.Getenv("REFRESH_SECRET")), nil
-	})
+		// Hitungan gagal baru direset setelah kode 2FA benar, agar tebakan kode tidak mendapat jatah ulang
+		return &LoginResult{User: user, ChallengeToken: challenge}, nil
+	}
 
+	loginThrottle.Reset(email)
+	accessToken, refreshToken, err := issueTokens(user, false, client)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("gagal membuat sesi login")
 	}
 
-	if !token.Valid {
-		return nil, errors.New("token is invalid")
-	}
+	return &LoginResult{User: user, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
 
-	return claims, nil
+// passwordResetTTL: Masa berlaku token reset password (default 60 menit)
+func passwordResetTTL() time.Duration {
+	return time.Duration(config.GetEnvInt("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute
 }
 
-// RefreshTokenService handles the logic for exchanging a refresh token for a new access token.
-func RefreshTokenService(refreshTokenString string) (string, string, error) {
-	claims, err := ValidateRefreshToken(refreshTokenString)
+// ForgotPasswordService menerbitkan token reset sekali pakai dan mengirimkannya lewat notifikasi.
+// Selalu berhasil untuk email yang tidak terdaftar/tidak aktif agar keberadaan akun tidak bocor.
+func ForgotPasswordService(email string) error {
+	user, err := authRepo.FindByEmail(email)
 	if err != nil {
-		return "", "", errors.New("refresh token tidak valid atau kadaluarsa")
+		return errors.New("gagal memproses permintaan reset password")
+	}
+	if user == nil || !user.IsActive {
+		return nil
 	}
 
-	// 1. Cari User di DB
-	user, err := authRepo.FindByID(claims.UserID)
+	rawToken, err := generateRandomToken(32)
 	if err != nil {
-		return "", "", errors.New("user tidak ditemukan")
+		return errors.New("gagal membuat token reset password")
+	}
+
+	now := time.Now()
+	// Hanya token terbaru yang berlaku
+	if err := authRepo.InvalidateResetTokens(user.ID, now); err != nil {
+		return errors.New("gagal memproses permintaan reset password")
+	}
+
+	resetToken := models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: now.Add(passwordResetTTL()),
+	}
+	if err := authRepo.CreateResetToken(&resetToken); err != nil {
+		return errors.New("gagal membuat token reset password")
+	}
+
+	tokenID := resetToken.ID
+	notification := models.Notification{
+		UserID:      user.ID,
+		Type:        models.NotificationTypePasswordReset,
+		ReferenceID: &tokenID,
+		Recipient:   user.Email,
+		Subject:     "Permintaan reset password",
+		Sensitive:   true,
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKami menerima permintaan reset password untuk akun Anda. Gunakan token berikut untuk membuat password baru:\n\n%s\n\nToken berlaku hingga %s dan hanya dapat dipakai sekali. Abaikan pesan ini jika Anda tidak merasa meminta reset password.\n",
+			user.Name, rawToken, resetToken.ExpiresAt.In(config.Location()).Format("2006-01-02 15:04"),
+		),
+	}
+	if _, err := authNotifications.Queue(&notification); err != nil {
+		log.Println("Gagal mengantrekan notifikasi reset password:", err)
+	}
+	return nil
+}
+
+// ResetPasswordService mengganti password memakai token reset, lalu mencabut semua sesi
+// sehingga setiap perangkat harus login ulang.
+func ResetPasswordService(rawToken, newPassword string, actor Actor) error {
+	invalid := errors.New("token reset tidak valid atau kadaluarsa")
+
+	resetToken, err := authRepo.FindResetTokenByHash(hashToken(rawToken))
+	if err != nil || resetToken == nil {
+		return invalid
+	}
+	now := time.Now()
+	if resetToken.UsedAt != nil || now.After(resetToken.ExpiresAt) {
+		return invalid
 	}
 
-	// 2. Verifikasi apakah refresh token di DB cocok dengan yang dikirim
-	if user.RefreshToken != refreshTokenString {
-		// Ini mungkin tanda adanya serangan, sebaiknya semua token di-revoke
-		user.RefreshToken = ""
-		authRepo.Update(user)
-		return "", "", errors.New("refresh token dicabut")
+	user, err := authRepo.FindByID(resetToken.UserID)
+	if err != nil || !user.IsActive {
+		return invalid
 	}
 
-	// 3. Generate token baru
-	newAccessToken, newRefreshToken, err := GenerateTokens(user)
+	hashedPassword, err := HashPassword(newPassword)
 	if err != nil {
-		return "", "", errors.New("gagal membuat token baru")
+		return errors.New("gagal hash password")
+	}
+
+	// Klaim token dulu agar dua request bersamaan tidak sama-sama berhasil
+	claimed, err := authRepo.MarkResetTokenUsed(resetToken.ID, now)
+	if err != nil || !claimed {
+		return invalid
 	}
 
-	// 4. Update refresh token di DB
-	user.RefreshToken = newRefreshToken
-	authRepo.Update(user)
+	user.PasswordHash = hashedPassword
+	if err := authRepo.Update(user); err != nil {
+		return errors.New("gagal menyimpan password baru")
+	}
+	revokeAllSessions(user.ID, models.SessionRevokedAuth)
 
-	return newAccessToken, newRefreshToken, nil
+	actor.UserID = user.ID
+	actor.Role = user.Role
+	recordAudit(actor, models.AuditActionPasswordReset, AuditEntityMember, user.ID, nil, nil)
+	return nil
 }
 
-// LogoutService clears the refresh token in the database.
-func LogoutService(userID uuid.UUID) error {
+// ChangePasswordService mengganti password user yang sedang login setelah memverifikasi password lama.
+// Semua sesi (termasuk sesi ini) dicabut.
+func ChangePasswordService(userID uuid.UUID, currentPassword, newPassword string, actor Actor) error {
 	user, err := authRepo.FindByID(userID)
 	if err != nil {
-		return nil // Jika user tidak ditemukan, anggap saja sudah logout
+		return errors.New("user tidak ditemukan")
+	}
+	if !CheckPasswordHash(currentPassword, user.PasswordHash) {
+		return errors.New("password saat ini salah")
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return errors.New("gagal hash password")
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := authRepo.Update(user); err != nil {
+		return errors.New("gagal menyimpan password baru")
+	}
+	revokeAllSessions(user.ID, models.SessionRevokedAuth)
+	if err := authRepo.InvalidateResetTokens(user.ID, time.Now()); err != nil {
+		log.Println("Gagal menghanguskan token reset password:", err)
 	}
 
-	user.RefreshToken = ""
-	return authRepo.Update(user)
+	recordAudit(actor, models.AuditActionPasswordChange, AuditEntityMember, user.ID, nil, nil)
+	return nil
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+var checkInTokenRepo = repository.NewCheckInTokenRepository()
+
+const checkInTokenPurpose = "checkin"
+
+// CheckInClaims adalah isi QR kartu member. Token berumur pendek sehingga screenshot
+// yang dibagikan ke orang lain sudah kadaluarsa saat dipakai.
+type CheckInClaims struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Purpose string    `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// MemberCard adalah payload QR yang ditampilkan aplikasi member.
+type MemberCard struct {
+	Payload   string    `json:"payload"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// checkInSecret: QR_SECRET, atau diturunkan dari JWT_SECRET jika tidak diset
+func checkInSecret() string {
+	return derivedSecret("QR_SECRET", checkInTokenPurpose)
+}
+
+// checkInTokenTTL: Umur token QR dalam detik (QR_TOKEN_TTL, default 30)
+func checkInTokenTTL() time.Duration {
+	return time.Duration(config.GetEnvInt("QR_TOKEN_TTL", 30)) * time.Second
+}
+
+// GenerateMemberCard menerbitkan payload QR baru untuk member yang sedang login.
+func GenerateMemberCard(userID uuid.UUID) (*MemberCard, error) {
+	member, err := memberRepo.FindByID(userID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(checkInTokenTTL())
+	claims := &CheckInClaims{
+		UserID:  member.ID,
+		Purpose: checkInTokenPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	payload, err := signToken(claims, checkInSecret())
+	if err != nil {
+		return nil, errors.New("gagal membuat kartu member")
+	}
+	return &MemberCard{Payload: payload, ExpiresAt: expiresAt}, nil
+}
+
+// GenerateMemberCardPNG merender payload QR sebagai gambar PNG.
+func GenerateMemberCardPNG(userID uuid.UUID, size int) ([]byte, *MemberCard, error) {
+	card, err := GenerateMemberCard(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	png, err := qrcode.Encode(card.Payload, qrcode.Medium, size)
+	if err != nil {
+		return nil, nil, errors.New("gagal membuat gambar QR")
+	}
+	return png, card, nil
+}
+
+// ValidateCheckInToken memverifikasi payload QR hasil scan.
+func ValidateCheckInToken(payload string) (*CheckInClaims, error) {
+	claims := &CheckInClaims{}
+	if err := parseToken(payload, claims, checkInSecret()); err != nil {
+		return nil, errors.New("QR tidak valid atau sudah kadaluarsa")
+	}
+	if claims.Purpose != checkInTokenPurpose {
+		return nil, errors.New("QR tidak valid atau sudah kadaluarsa")
+	}
+	return claims, nil
+}
+
+// CheckInMemberByQR: Check-In menggunakan QR kartu member (Staff atau kiosk). Setiap QR hanya
+// bisa dipakai sekali; jika Check-In ditolak, QR yang sama boleh dipindai ulang.
+func CheckInMemberByQR(payload string, overrideHours bool, actor Actor) (*models.Attendance, error) {
+	claims, err := ValidateCheckInToken(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := memberRepo.FindByID(claims.UserID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("QR tidak valid atau sudah kadaluarsa")
+	}
+	fresh, err := checkInTokenRepo.Consume(&models.UsedCheckInToken{
+		JTI:       claims.ID,
+		UserID:    member.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+	if err != nil {
+		return nil, errors.New("gagal memeriksa QR")
+	}
+	if !fresh {
+		return nil, errors.New("QR sudah dipakai, silakan tampilkan QR terbaru")
+	}
+
+	attendance, err := checkInMember(member, overrideHours, actor)
+	if err != nil {
+		if releaseErr := checkInTokenRepo.Release(claims.ID); releaseErr != nil {
+			log.Println("Gagal melepas QR Check-In:", releaseErr)
+		}
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// PurgeUsedCheckInTokens menghapus jti QR yang sudah kedaluwarsa (job harian)
+func PurgeUsedCheckInTokens(now time.Time) (int64, error) {
+	return checkInTokenRepo.DeleteExpired(now)
+}
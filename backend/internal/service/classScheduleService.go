@@ -0,0 +1,355 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Aturan Pengulangan (subset RRULE RFC 5545) ---
+
+var rruleWeekdays = map[string]int{"MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6, "SU": 7}
+
+// recurrenceRule: FREQ=WEEKLY dengan BYDAY, INTERVAL, dan COUNT atau UNTIL (opsional)
+type recurrenceRule struct {
+	Interval int
+	ByDay    []int // ISO weekday, terurut
+	Count    int
+	Until    *time.Time
+}
+
+// parseRRule membaca aturan seperti "FREQ=WEEKLY;BYDAY=MO,WE,FR;INTERVAL=1;UNTIL=20261231".
+// Jika BYDAY kosong, hari dari DTSTART yang dipakai.
+func parseRRule(value string, dtstart time.Time) (*recurrenceRule, error) {
+	rule := &recurrenceRule{Interval: 1}
+	freq := ""
+
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("bagian aturan %q tidak valid", part)
+		}
+		switch key {
+		case "FREQ":
+			freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, errors.New("INTERVAL harus bilangan bulat positif")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT harus bilangan bulat positif")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseRRuleUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			seen := map[int]bool{}
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("hari %q pada BYDAY tidak valid", day)
+				}
+				if !seen[weekday] {
+					seen[weekday] = true
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "WKST":
+			if val != "MO" {
+				return nil, errors.New("hanya WKST=MO yang didukung")
+			}
+		default:
+			return nil, fmt.Errorf("bagian aturan %s tidak didukung", key)
+		}
+	}
+
+	if freq != "WEEKLY" {
+		return nil, errors.New("hanya FREQ=WEEKLY yang didukung")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT dan UNTIL tidak boleh dipakai bersamaan")
+	}
+	if len(rule.ByDay) == 0 {
+		rule.ByDay = []int{isoWeekday(dtstart)}
+	}
+	sort.Ints(rule.ByDay)
+	return rule, nil
+}
+
+// parseRRuleUntil: YYYYMMDD (sampai akhir hari, zona waktu gym) atau YYYYMMDDTHHMMSSZ (UTC)
+func parseRRuleUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", value, config.Location())
+	if err != nil {
+		return time.Time{}, errors.New("format UNTIL tidak valid, gunakan YYYYMMDD")
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// occurrences menghasilkan jam mulai setiap kejadian pada [from, to). Jam dinding DTSTART
+// dipertahankan di zona waktu gym. COUNT dihitung sejak DTSTART.
+func (rule *recurrenceRule) occurrences(dtstart, from, to time.Time) []time.Time {
+	loc := config.Location()
+	local := dtstart.In(loc)
+	weekStart := startOfWeek(local)
+
+	var result []time.Time
+	count := 0
+	for week := 0; ; week += rule.Interval {
+		base := weekStart.AddDate(0, 0, week*7)
+		if !base.Before(to) {
+			return result
+		}
+		for _, day := range rule.ByDay {
+			date := base.AddDate(0, 0, day-1)
+			occurrence := time.Date(date.Year(), date.Month(), date.Day(), local.Hour(), local.Minute(), 0, 0, loc)
+			if occurrence.Before(local) {
+				continue
+			}
+			if rule.Until != nil && occurrence.After(*rule.Until) {
+				return result
+			}
+			if rule.Count > 0 && count >= rule.Count {
+				return result
+			}
+			count++
+			if !occurrence.Before(to) {
+				return result
+			}
+			if !occurrence.Before(from) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+}
+
+// materializeDays: Berapa hari ke depan sesi dari jadwal berulang dibuat (CLASS_MATERIALIZE_DAYS, default 28)
+func materializeDays() int {
+	return config.GetEnvInt("CLASS_MATERIALIZE_DAYS", 28)
+}
+
+// --- Jadwal Berulang ---
+
+// GetSchedules: Semua jadwal berulang (Admin/Staff)
+func (s *ClassService) GetSchedules() ([]models.ClassSchedule, error) {
+	return s.repo.FindSchedules(false)
+}
+
+// CreateSchedule membuat jadwal berulang lalu langsung membuat sesi untuk beberapa minggu ke depan.
+func (s *ClassService) CreateSchedule(input models.ClassScheduleInput, actor Actor) (*models.ClassSchedule, int, error) {
+	classType, err := s.repo.FindTypeByID(input.ClassTypeID)
+	if err != nil || classType == nil {
+		return nil, 0, errors.New("jenis kelas tidak ditemukan")
+	}
+	if !classType.IsActive {
+		return nil, 0, errors.New("jenis kelas " + classType.Name + " tidak aktif")
+	}
+	instructor, err := s.findInstructor(input.InstructorID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := parseRRule(input.RRule, input.StartsAt); err != nil {
+		return nil, 0, errors.New("aturan pengulangan tidak valid: " + err.Error())
+	}
+
+	schedule := models.ClassSchedule{
+		ClassTypeID:     classType.ID,
+		InstructorID:    instructor.ID,
+		Room:            input.Room,
+		StartsAt:        input.StartsAt,
+		DurationMinutes: classType.DurationMinutes,
+		Capacity:        classType.DefaultCapacity,
+		RRule:           strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(input.RRule)), "RRULE:"),
+		IsActive:        true,
+		CreatedByID:     actor.UserID,
+	}
+	if input.DurationMinutes > 0 {
+		schedule.DurationMinutes = input.DurationMinutes
+	}
+	if input.Capacity > 0 {
+		schedule.Capacity = input.Capacity
+	}
+
+	if err := s.repo.CreateSchedule(&schedule); err != nil {
+		return nil, 0, errors.New("gagal menyimpan jadwal berulang")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityClassSchedule, schedule.ID, nil, schedule)
+	schedule.ClassType = *classType
+	schedule.Instructor = *instructor
+
+	created, err := s.materializeSchedule(&schedule, time.Now())
+	if err != nil {
+		return nil, 0, errors.New("jadwal tersimpan, tetapi gagal membuat sesi kelas")
+	}
+	return &schedule, created, nil
+}
+
+// DeactivateSchedule menghentikan jadwal berulang dan membatalkan sesi yang belum dimulai.
+func (s *ClassService) DeactivateSchedule(id uuid.UUID, actor Actor) error {
+	schedule, err := s.repo.FindScheduleByID(id)
+	if err != nil || schedule == nil {
+		return errors.New("jadwal berulang tidak ditemukan")
+	}
+
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.ClassRepository) error {
+		before := *schedule
+		schedule.IsActive = false
+		if err := tx.UpdateSchedule(schedule); err != nil {
+			return errors.New("gagal menonaktifkan jadwal berulang")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityClassSchedule, schedule.ID, before, schedule)
+
+		upcoming, err := tx.FindSessionsByScheduleFrom(schedule.ID, time.Now())
+		if err != nil {
+			return errors.New("gagal mengambil sesi jadwal berulang")
+		}
+		for i := range upcoming {
+			session, err := tx.LockSessionByID(upcoming[i].ID)
+			if err != nil || session == nil {
+				return errors.New("sesi kelas tidak ditemukan")
+			}
+			if err := cancelSession(tx, session, "Jadwal berulang dihentikan", &trail); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	trail.record(actor)
+	return nil
+}
+
+// CancelOccurrence membatalkan satu kejadian jadwal berulang pada tanggal tertentu.
+// Kejadian yang belum dibuat sesinya tetap dicatat (status cancelled) agar job tidak membuatnya.
+func (s *ClassService) CancelOccurrence(scheduleID uuid.UUID, input models.CancelOccurrenceInput, actor Actor) (*models.ClassSession, error) {
+	schedule, err := s.repo.FindScheduleByID(scheduleID)
+	if err != nil || schedule == nil {
+		return nil, errors.New("jadwal berulang tidak ditemukan")
+	}
+	rule, err := parseRRule(schedule.RRule, schedule.StartsAt)
+	if err != nil {
+		return nil, errors.New("aturan pengulangan tidak valid: " + err.Error())
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", input.Date, config.Location())
+	if err != nil {
+		return nil, errors.New("format tanggal tidak valid, gunakan YYYY-MM-DD")
+	}
+	found := rule.occurrences(schedule.StartsAt, day, day.AddDate(0, 0, 1))
+	if len(found) == 0 {
+		return nil, errors.New("tidak ada kelas dari jadwal ini pada tanggal " + input.Date)
+	}
+	occurrenceAt := found[0]
+	if !occurrenceAt.After(time.Now()) {
+		return nil, errors.New("kelas yang sudah dimulai tidak bisa dibatalkan")
+	}
+
+	var cancelled *models.ClassSession
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.ClassRepository) error {
+		session := newOccurrenceSession(schedule, occurrenceAt)
+		if _, err := tx.CreateSessionIfAbsent(session); err != nil {
+			return errors.New("gagal menyimpan sesi kelas")
+		}
+		existing, err := tx.FindSessionByOccurrence(schedule.ID, occurrenceAt)
+		if err != nil || existing == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		locked, err := tx.LockSessionByID(existing.ID)
+		if err != nil || locked == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		if err := cancelSession(tx, locked, input.Reason, &trail); err != nil {
+			return err
+		}
+		cancelled = locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return cancelled, nil
+}
+
+// newOccurrenceSession membangun sesi untuk satu kejadian jadwal berulang
+func newOccurrenceSession(schedule *models.ClassSchedule, occurrenceAt time.Time) *models.ClassSession {
+	scheduleID := schedule.ID
+	occurrence := occurrenceAt
+	return &models.ClassSession{
+		ClassTypeID:  schedule.ClassTypeID,
+		InstructorID: schedule.InstructorID,
+		Room:         schedule.Room,
+		StartTime:    occurrenceAt,
+		EndTime:      occurrenceAt.Add(time.Duration(schedule.DurationMinutes) * time.Minute),
+		Capacity:     schedule.Capacity,
+		Status:       models.ClassSessionStatusScheduled,
+		CreatedByID:  schedule.CreatedByID,
+		ScheduleID:   &scheduleID,
+		OccurrenceAt: &occurrence,
+	}
+}
+
+// materializeSchedule membuat sesi yang belum ada untuk jendela [now, now + CLASS_MATERIALIZE_DAYS).
+func (s *ClassService) materializeSchedule(schedule *models.ClassSchedule, now time.Time) (int, error) {
+	rule, err := parseRRule(schedule.RRule, schedule.StartsAt)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, occurrenceAt := range rule.occurrences(schedule.StartsAt, now, now.AddDate(0, 0, materializeDays())) {
+		ok, err := s.repo.CreateSessionIfAbsent(newOccurrenceSession(schedule, occurrenceAt))
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// MaterializeSessions membuat sesi untuk semua jadwal berulang yang aktif.
+// Dipanggil oleh job harian; aman diulang karena setiap kejadian unik per jadwal.
+func (s *ClassService) MaterializeSessions(now time.Time) (int, error) {
+	schedules, err := s.repo.FindSchedules(true)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range schedules {
+		count, err := s.materializeSchedule(&schedules[i], now)
+		created += count
+		if err != nil {
+			return created, fmt.Errorf("jadwal %s: %w", schedules[i].ID, err)
+		}
+	}
+	return created, nil
+}
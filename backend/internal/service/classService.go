@@ -0,0 +1,672 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ClassService struct {
+	repo          repository.ClassRepository
+	userRepo      repository.AuthRepository
+	memberRepo    repository.MemberRepository
+	memberships   *MembershipService
+	notifications *NotificationService
+}
+
+func NewClassService() *ClassService {
+	return &ClassService{
+		repo:          repository.NewClassRepository(),
+		userRepo:      repository.NewAuthRepository(),
+		memberRepo:    repository.NewMemberRepository(),
+		memberships:   NewMembershipService(),
+		notifications: NewNotificationService(),
+	}
+}
+
+// --- Jenis Kelas ---
+
+func (s *ClassService) GetClassTypes() ([]models.ClassType, error) {
+	return s.repo.FindAllTypes()
+}
+
+func (s *ClassService) CreateClassType(input models.ClassTypeInput, actor Actor) (*models.ClassType, error) {
+	classType := models.ClassType{
+		Name:            input.Name,
+		Description:     input.Description,
+		DurationMinutes: input.DurationMinutes,
+		DefaultCapacity: input.DefaultCapacity,
+		IsActive:        true,
+	}
+	if input.IsActive != nil {
+		classType.IsActive = *input.IsActive
+	}
+	if err := s.repo.CreateType(&classType); err != nil {
+		return nil, errors.New("gagal membuat jenis kelas. Nama mungkin sudah ada.")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityClassType, classType.ID, nil, classType)
+	return &classType, nil
+}
+
+func (s *ClassService) UpdateClassType(id uint, input models.ClassTypeInput, actor Actor) (*models.ClassType, error) {
+	classType, err := s.repo.FindTypeByID(id)
+	if err != nil || classType == nil {
+		return nil, errors.New("jenis kelas tidak ditemukan")
+	}
+
+	before := *classType
+	classType.Name = input.Name
+	classType.Description = input.Description
+	classType.DurationMinutes = input.DurationMinutes
+	classType.DefaultCapacity = input.DefaultCapacity
+	if input.IsActive != nil {
+		classType.IsActive = *input.IsActive
+	}
+	if err := s.repo.UpdateType(classType); err != nil {
+		return nil, errors.New("gagal memperbarui jenis kelas")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityClassType, classType.ID, before, classType)
+	return classType, nil
+}
+
+// --- Jadwal Sesi ---
+
+// GetSessions: Jadwal kelas dengan filter opsional. Tanpa date_from, hanya sesi mulai hari ini.
+func (s *ClassService) GetSessions(dateFromStr, dateToStr, classTypeIDStr, instructorIDStr string) ([]models.ClassSession, error) {
+	filter := repository.ClassSessionFilter{
+		From: parseDateParam(dateFromStr, false),
+		To:   parseDateParam(dateToStr, true),
+	}
+	if filter.From == nil {
+		today := config.StartOfDay(time.Now())
+		filter.From = &today
+	}
+	if classTypeIDStr != "" {
+		if id, err := strconv.ParseUint(classTypeIDStr, 10, 32); err == nil {
+			classTypeID := uint(id)
+			filter.ClassTypeID = &classTypeID
+		}
+	}
+	if instructorIDStr != "" {
+		if id, err := uuid.Parse(instructorIDStr); err == nil {
+			filter.InstructorID = &id
+		}
+	}
+
+	sessions, err := s.repo.FindSessions(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachBookedCounts(sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// attachBookedCounts mengisi BookedCount dan WaitlistCount untuk setiap sesi
+func (s *ClassService) attachBookedCounts(sessions []models.ClassSession) error {
+	ids := make([]uuid.UUID, len(sessions))
+	for i := range sessions {
+		ids[i] = sessions[i].ID
+	}
+	booked, err := s.repo.CountBookingsBySessionIDs(ids, models.BookingSpotStatuses)
+	if err != nil {
+		return err
+	}
+	waitlisted, err := s.repo.CountBookingsBySessionIDs(ids, []string{models.BookingStatusWaitlisted})
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		sessions[i].BookedCount = booked[sessions[i].ID]
+		sessions[i].WaitlistCount = waitlisted[sessions[i].ID]
+	}
+	return nil
+}
+
+// findInstructor memastikan instruktur adalah staff/admin yang aktif
+func (s *ClassService) findInstructor(id uuid.UUID) (*models.User, error) {
+	instructor, err := s.userRepo.FindByID(id)
+	if err != nil || instructor == nil || !models.IsStaffRole(instructor.Role) {
+		return nil, errors.New("instruktur tidak ditemukan")
+	}
+	if !instructor.IsActive {
+		return nil, errors.New("instruktur tidak aktif")
+	}
+	return instructor, nil
+}
+
+// CreateSession menjadwalkan sesi kelas (Admin/Staff)
+func (s *ClassService) CreateSession(input models.ClassSessionInput, actor Actor) (*models.ClassSession, error) {
+	classType, err := s.repo.FindTypeByID(input.ClassTypeID)
+	if err != nil || classType == nil {
+		return nil, errors.New("jenis kelas tidak ditemukan")
+	}
+	if !classType.IsActive {
+		return nil, errors.New("jenis kelas " + classType.Name + " tidak aktif")
+	}
+	instructor, err := s.findInstructor(input.InstructorID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.ClassSession{
+		ClassTypeID:  classType.ID,
+		InstructorID: instructor.ID,
+		Room:         input.Room,
+		StartTime:    input.StartTime,
+		EndTime:      input.StartTime.Add(time.Duration(classType.DurationMinutes) * time.Minute),
+		Capacity:     classType.DefaultCapacity,
+		Status:       models.ClassSessionStatusScheduled,
+		CreatedByID:  actor.UserID,
+	}
+	if input.EndTime != nil {
+		session.EndTime = *input.EndTime
+	}
+	if input.Capacity > 0 {
+		session.Capacity = input.Capacity
+	}
+	if !session.EndTime.After(session.StartTime) {
+		return nil, errors.New("jam selesai kelas harus setelah jam mulai")
+	}
+	if session.StartTime.Before(time.Now()) {
+		return nil, errors.New("jadwal kelas tidak boleh di masa lalu")
+	}
+
+	if err := s.repo.CreateSession(&session); err != nil {
+		return nil, errors.New("gagal menyimpan jadwal kelas")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityClassSession, session.ID, nil, session)
+	session.ClassType = *classType
+	session.Instructor = *instructor
+	return &session, nil
+}
+
+// UpdateSession mengubah instruktur, ruangan, jam, atau kapasitas sesi yang belum dimulai.
+func (s *ClassService) UpdateSession(id uuid.UUID, input models.UpdateClassSessionInput, actor Actor) (*models.ClassSession, error) {
+	if input.InstructorID != nil {
+		if _, err := s.findInstructor(*input.InstructorID); err != nil {
+			return nil, err
+		}
+	}
+
+	var promoted []models.Booking
+	var trail auditTrail
+	err := s.repo.Transaction(func(tx repository.ClassRepository) error {
+		session, err := tx.LockSessionByID(id)
+		if err != nil || session == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		if session.Status == models.ClassSessionStatusCancelled {
+			return errors.New("sesi kelas sudah dibatalkan")
+		}
+		if !session.StartTime.After(time.Now()) {
+			return errors.New("sesi kelas yang sudah dimulai tidak bisa diubah")
+		}
+
+		before := *session
+		if input.InstructorID != nil {
+			session.InstructorID = *input.InstructorID
+		}
+		if input.Room != nil {
+			session.Room = *input.Room
+		}
+		if input.StartTime != nil {
+			// Geser jam selesai mengikuti jam mulai jika tidak diisi
+			duration := session.EndTime.Sub(session.StartTime)
+			session.StartTime = *input.StartTime
+			session.EndTime = session.StartTime.Add(duration)
+		}
+		if input.EndTime != nil {
+			session.EndTime = *input.EndTime
+		}
+		if !session.EndTime.After(session.StartTime) {
+			return errors.New("jam selesai kelas harus setelah jam mulai")
+		}
+		if session.StartTime.Before(time.Now()) {
+			return errors.New("jadwal kelas tidak boleh di masa lalu")
+		}
+
+		if input.Capacity != nil {
+			booked, err := tx.CountBooked(session.ID)
+			if err != nil {
+				return errors.New("gagal memeriksa jumlah peserta")
+			}
+			if int64(*input.Capacity) < booked {
+				return fmt.Errorf("kapasitas tidak boleh kurang dari jumlah peserta terdaftar (%d orang)", booked)
+			}
+			session.Capacity = *input.Capacity
+		}
+
+		if err := tx.UpdateSession(session); err != nil {
+			return errors.New("gagal memperbarui jadwal kelas")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityClassSession, session.ID, before, session)
+
+		// Kapasitas bertambah: naikkan antrean daftar tunggu
+		promoted, err = promoteWaitlist(tx, session, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+
+	session, err := s.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyPromoted(promoted, session)
+	return session, nil
+}
+
+// SetDeliveredBy mencatat instruktur pengganti yang benar-benar mengajar sesi (Admin/Staff).
+// Bisa dilakukan setelah kelas berjalan, untuk keperluan laporan komisi.
+func (s *ClassService) SetDeliveredBy(id, staffID uuid.UUID, actor Actor) (*models.ClassSession, error) {
+	staff, err := s.findInstructor(staffID)
+	if err != nil {
+		return nil, err
+	}
+
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.ClassRepository) error {
+		session, err := tx.LockSessionByID(id)
+		if err != nil || session == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		if session.Status == models.ClassSessionStatusCancelled {
+			return errors.New("sesi kelas sudah dibatalkan")
+		}
+		before := *session
+		session.DeliveredByID = &staff.ID
+		if err := tx.UpdateSession(session); err != nil {
+			return errors.New("gagal menyimpan instruktur pengganti")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityClassSession, session.ID, before, session)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.GetSession(id)
+}
+
+// GetSession: Detail sesi beserta jumlah peserta
+func (s *ClassService) GetSession(id uuid.UUID) (*models.ClassSession, error) {
+	session, err := s.repo.FindSessionByID(id)
+	if err != nil || session == nil {
+		return nil, errors.New("sesi kelas tidak ditemukan")
+	}
+	sessions := []models.ClassSession{*session}
+	if err := s.attachBookedCounts(sessions); err != nil {
+		return nil, errors.New("gagal memeriksa jumlah peserta")
+	}
+	return &sessions[0], nil
+}
+
+// CancelSession membatalkan sesi beserta semua booking dan daftar tunggunya (Admin/Staff)
+func (s *ClassService) CancelSession(id uuid.UUID, reason string, actor Actor) error {
+	var trail auditTrail
+	err := s.repo.Transaction(func(tx repository.ClassRepository) error {
+		session, err := tx.LockSessionByID(id)
+		if err != nil || session == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		return cancelSession(tx, session, reason, &trail)
+	})
+	if err != nil {
+		return err
+	}
+	trail.record(actor)
+	return nil
+}
+
+// cancelSession dipanggil di dalam transaksi dengan baris sesi yang sudah dikunci
+func cancelSession(tx repository.ClassRepository, session *models.ClassSession, reason string, trail *auditTrail) error {
+	if session.Status == models.ClassSessionStatusCancelled {
+		return nil
+	}
+	if session.AttendanceMarkedAt != nil {
+		return errors.New("sesi kelas yang sudah berlangsung tidak bisa dibatalkan")
+	}
+
+	before := *session
+	session.Status = models.ClassSessionStatusCancelled
+	session.CancelReason = reason
+	if err := tx.UpdateSession(session); err != nil {
+		return errors.New("gagal membatalkan sesi kelas")
+	}
+	trail.add(models.AuditActionUpdate, AuditEntityClassSession, session.ID, before, session)
+	if err := tx.CancelBookingsBySessionID(session.ID, time.Now()); err != nil {
+		return errors.New("gagal membatalkan booking peserta")
+	}
+	return nil
+}
+
+// GetSessionBookings: Daftar peserta sesi (Admin/Staff)
+func (s *ClassService) GetSessionBookings(sessionID uuid.UUID) ([]models.Booking, error) {
+	session, err := s.repo.FindSessionByID(sessionID)
+	if err != nil || session == nil {
+		return nil, errors.New("sesi kelas tidak ditemukan")
+	}
+	return s.repo.FindBookingsBySessionID(sessionID)
+}
+
+// --- Booking (Member) ---
+
+// BookSession mendaftarkan member ke sesi kelas. Baris sesi dikunci (SELECT ... FOR UPDATE)
+// selama pemeriksaan kuota sehingga dua member tidak bisa mengambil kursi terakhir bersamaan.
+// Jika kelas penuh, member masuk daftar tunggu sesuai urutan waktu mendaftar.
+func (s *ClassService) BookSession(sessionID, userID uuid.UUID, actor Actor) (*models.Booking, error) {
+	member, err := s.memberRepo.FindByID(userID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+	if !member.IsActive {
+		return nil, errors.New("member tidak aktif")
+	}
+
+	session, err := s.repo.FindSessionByID(sessionID)
+	if err != nil || session == nil {
+		return nil, errors.New("sesi kelas tidak ditemukan")
+	}
+	now := time.Now()
+	if !session.StartTime.After(now) {
+		return nil, errors.New("sesi kelas sudah dimulai")
+	}
+	// Langganan harus berlaku pada saat kelas dimulai
+	if _, err := s.memberships.checkMembership(member.ID, session.StartTime); err != nil {
+		return nil, err
+	}
+
+	var booking *models.Booking
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.ClassRepository) error {
+		locked, err := tx.LockSessionByID(sessionID)
+		if err != nil || locked == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		if locked.Status == models.ClassSessionStatusCancelled {
+			return errors.New("sesi kelas sudah dibatalkan")
+		}
+
+		existing, err := tx.FindBooking(sessionID, member.ID)
+		if err != nil {
+			return errors.New("gagal memeriksa booking")
+		}
+		if existing != nil && existing.Status == models.BookingStatusBooked {
+			return errors.New("member sudah terdaftar di kelas ini")
+		}
+		if existing != nil && existing.Status == models.BookingStatusWaitlisted {
+			return errors.New("member sudah berada di daftar tunggu kelas ini")
+		}
+
+		booked, err := tx.CountBooked(sessionID)
+		if err != nil {
+			return errors.New("gagal memeriksa jumlah peserta")
+		}
+
+		// Booking ulang setelah dibatalkan memakai baris yang sama
+		action, before := models.AuditActionUpdate, any(nil)
+		if existing == nil {
+			action = models.AuditActionCreate
+			existing = &models.Booking{SessionID: sessionID, UserID: member.ID}
+		} else {
+			before = *existing
+		}
+		existing.Status = models.BookingStatusBooked
+		if booked >= int64(locked.Capacity) {
+			existing.Status = models.BookingStatusWaitlisted
+		}
+		existing.BookedAt = now
+		existing.PromotedAt = nil
+		existing.CancelledAt = nil
+		if err := tx.SaveBooking(existing); err != nil {
+			return errors.New("gagal menyimpan booking")
+		}
+		trail.add(action, AuditEntityBooking, existing.ID, before, existing)
+
+		if existing.Status == models.BookingStatusWaitlisted {
+			ahead, err := tx.CountWaitlistedBefore(sessionID, existing.BookedAt)
+			if err != nil {
+				return errors.New("gagal menghitung urutan daftar tunggu")
+			}
+			existing.WaitlistPosition = int(ahead) + 1
+		}
+		booking = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+
+	booking.Session = session
+	return booking, nil
+}
+
+// CancelBooking: Member membatalkan booking (atau keluar dari daftar tunggu) sebelum kelas
+// dimulai. Kursi yang kosong langsung diberikan ke antrean terdepan daftar tunggu.
+func (s *ClassService) CancelBooking(bookingID, userID uuid.UUID, actor Actor) (*models.Booking, error) {
+	booking, err := s.repo.FindBookingByID(bookingID)
+	if err != nil || booking == nil || booking.UserID != userID {
+		return nil, errors.New("booking tidak ditemukan")
+	}
+
+	var session *models.ClassSession
+	var promoted []models.Booking
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.ClassRepository) error {
+		locked, err := tx.LockSessionByID(booking.SessionID)
+		if err != nil || locked == nil {
+			return errors.New("sesi kelas tidak ditemukan")
+		}
+		session = locked
+		if !session.StartTime.After(time.Now()) {
+			return errors.New("booking tidak bisa dibatalkan setelah kelas dimulai")
+		}
+
+		// Baca ulang di dalam transaksi agar status terbaru yang dipakai
+		current, err := tx.FindBookingByID(bookingID)
+		if err != nil || current == nil {
+			return errors.New("booking tidak ditemukan")
+		}
+		if current.Status != models.BookingStatusBooked && current.Status != models.BookingStatusWaitlisted {
+			return errors.New("booking sudah dibatalkan")
+		}
+
+		now := time.Now()
+		before := *current
+		freedSpot := current.Status == models.BookingStatusBooked
+		current.Status = models.BookingStatusCancelled
+		current.CancelledAt = &now
+		if err := tx.SaveBooking(current); err != nil {
+			return errors.New("gagal membatalkan booking")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityBooking, current.ID, before, current)
+		booking = current
+
+		if freedSpot {
+			promoted, err = promoteWaitlist(tx, session, now)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+
+	s.notifyPromoted(promoted, session)
+	return booking, nil
+}
+
+// promoteWaitlist mengisi kursi kosong dari daftar tunggu (urut waktu mendaftar).
+// Dipanggil di dalam transaksi dengan baris sesi yang sudah dikunci.
+func promoteWaitlist(tx repository.ClassRepository, session *models.ClassSession, now time.Time) ([]models.Booking, error) {
+	if session.Status != models.ClassSessionStatusScheduled || !session.StartTime.After(now) {
+		return nil, nil
+	}
+
+	var promoted []models.Booking
+	for {
+		booked, err := tx.CountBooked(session.ID)
+		if err != nil {
+			return nil, errors.New("gagal memeriksa jumlah peserta")
+		}
+		if booked >= int64(session.Capacity) {
+			return promoted, nil
+		}
+
+		next, err := tx.FindNextWaitlisted(session.ID)
+		if err != nil {
+			return nil, errors.New("gagal memeriksa daftar tunggu")
+		}
+		if next == nil {
+			return promoted, nil
+		}
+
+		next.Status = models.BookingStatusBooked
+		next.PromotedAt = &now
+		if err := tx.SaveBooking(next); err != nil {
+			return nil, errors.New("gagal memproses daftar tunggu")
+		}
+		promoted = append(promoted, *next)
+	}
+}
+
+// notifyPromoted mengantrekan email untuk member yang naik dari daftar tunggu.
+// Kegagalan hanya dicatat karena booking sudah tersimpan.
+func (s *ClassService) notifyPromoted(promoted []models.Booking, session *models.ClassSession) {
+	if len(promoted) == 0 {
+		return
+	}
+
+	className := "kelas"
+	if classType, err := s.repo.FindTypeByID(session.ClassTypeID); err == nil && classType != nil {
+		className = "kelas " + classType.Name
+	}
+	startAt := session.StartTime.In(config.Location()).Format("02 Jan 2006 15:04")
+
+	for _, booking := range promoted {
+		member, err := s.memberRepo.FindByID(booking.UserID)
+		if err != nil || member == nil {
+			continue
+		}
+		bookingID := booking.ID
+		notification := models.Notification{
+			UserID:      member.ID,
+			Type:        models.NotificationTypeWaitlistPromoted,
+			ReferenceID: &bookingID,
+			Recipient:   member.Email,
+			Subject:     "Anda mendapat tempat di " + className,
+			Body: fmt.Sprintf(
+				"Halo %s,\n\nAda peserta yang membatalkan booking sehingga Anda sekarang terdaftar di %s pada %s. Jika berhalangan, mohon batalkan booking agar tempat dapat diberikan ke member lain.\n",
+				member.Name, className, startAt,
+			),
+		}
+		if _, err := s.notifications.Queue(&notification); err != nil {
+			log.Println("Gagal mengantrekan notifikasi daftar tunggu:", err)
+		}
+	}
+}
+
+// GetMyBookings: Booking milik member yang sedang login, beserta urutan daftar tunggu
+func (s *ClassService) GetMyBookings(userID uuid.UUID) ([]models.Booking, error) {
+	bookings, err := s.repo.FindBookingsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookings {
+		if bookings[i].Status != models.BookingStatusWaitlisted {
+			continue
+		}
+		ahead, err := s.repo.CountWaitlistedBefore(bookings[i].SessionID, bookings[i].BookedAt)
+		if err != nil {
+			return nil, err
+		}
+		bookings[i].WaitlistPosition = int(ahead) + 1
+	}
+	return bookings, nil
+}
+
+// classCheckInWindow: Check-In gym paling awal yang dihitung sebagai kehadiran kelas
+// (CLASS_CHECKIN_WINDOW_MINUTES sebelum kelas dimulai, default 120)
+func classCheckInWindow() time.Duration {
+	return time.Duration(config.GetEnvInt("CLASS_CHECKIN_WINDOW_MINUTES", 120)) * time.Minute
+}
+
+// MarkClassAttendance menandai booking sesi yang sudah dimulai: attended jika member
+// Check-In (via CheckInMember) sebelum kelas dimulai, no_show jika tidak. Antrean daftar
+// tunggu yang tersisa ditandai expired. Dipanggil oleh job berkala; aman diulang.
+func (s *ClassService) MarkClassAttendance(now time.Time) (int, error) {
+	sessions, err := s.repo.FindSessionsToMark(now)
+	if err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for i := range sessions {
+		err := s.repo.Transaction(func(tx repository.ClassRepository) error {
+			session, err := tx.LockSessionByID(sessions[i].ID)
+			if err != nil || session == nil {
+				return err
+			}
+			// Bisa saja sudah diproses replika lain atau dipindah jadwalnya
+			if session.AttendanceMarkedAt != nil || session.Status != models.ClassSessionStatusScheduled || session.StartTime.After(now) {
+				return nil
+			}
+
+			bookings, err := tx.FindBookingsBySessionID(session.ID)
+			if err != nil {
+				return err
+			}
+			for j := range bookings {
+				booking := &bookings[j]
+				switch booking.Status {
+				case models.BookingStatusBooked:
+					attendance, err := attendanceRepo.FindFirstCheckInBetween(booking.UserID, session.StartTime.Add(-classCheckInWindow()), session.StartTime)
+					if err != nil {
+						return err
+					}
+					if attendance != nil {
+						booking.Status = models.BookingStatusAttended
+						booking.AttendanceID = &attendance.ID
+					} else {
+						booking.Status = models.BookingStatusNoShow
+					}
+				case models.BookingStatusWaitlisted:
+					booking.Status = models.BookingStatusExpired
+				default:
+					continue
+				}
+				if err := tx.SaveBooking(booking); err != nil {
+					return err
+				}
+			}
+
+			session.AttendanceMarkedAt = &now
+			if session.DeliveredByID == nil {
+				instructorID := session.InstructorID
+				session.DeliveredByID = &instructorID
+			}
+			if err := tx.UpdateSession(session); err != nil {
+				return err
+			}
+			marked++
+			return nil
+		})
+		if err != nil {
+			return marked, err
+		}
+	}
+	return marked, nil
+}
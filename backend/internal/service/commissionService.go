@@ -0,0 +1,320 @@
+package service
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CommissionService struct {
+	repo     repository.CommissionRepository
+	userRepo repository.AuthRepository
+}
+
+func NewCommissionService() *CommissionService {
+	return &CommissionService{
+		repo:     repository.NewCommissionRepository(),
+		userRepo: repository.NewAuthRepository(),
+	}
+}
+
+// CommissionLine: Satu sesi yang diberikan staff beserta komisinya
+type CommissionLine struct {
+	SessionType string    `json:"sessionType"`
+	SessionID   uuid.UUID `json:"sessionId"`
+	StartTime   time.Time `json:"startTime"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Attendees   int       `json:"attendees"`  // Peserta hadir (kelas) atau 1 (PT)
+	MemberPaid  float64   `json:"memberPaid"` // Harga per sesi yang dibayar member (PT)
+	RuleType    string    `json:"ruleType"`
+	RuleValue   float64   `json:"ruleValue"`
+	Commission  float64   `json:"commission"`
+}
+
+// StaffCommission: Rekap sesi dan komisi satu staff dalam periode
+type StaffCommission struct {
+	StaffID         uuid.UUID        `json:"staffId"`
+	StaffName       string           `json:"staffName"`
+	StaffEmail      string           `json:"staffEmail"`
+	PTSessions      int              `json:"ptSessions"`
+	ClassSessions   int              `json:"classSessions"`
+	PTRevenue       float64          `json:"ptRevenue"`
+	PTCommission    float64          `json:"ptCommission"`
+	ClassCommission float64          `json:"classCommission"`
+	TotalCommission float64          `json:"totalCommission"`
+	Lines           []CommissionLine `json:"lines"`
+}
+
+// CommissionReport: Laporan komisi per staff untuk periode [From, To]
+type CommissionReport struct {
+	From            time.Time         `json:"from"`
+	To              time.Time         `json:"to"`
+	TotalCommission float64           `json:"totalCommission"`
+	Staff           []StaffCommission `json:"staff"`
+}
+
+// --- Aturan Komisi ---
+
+func (s *CommissionService) GetRules() ([]models.CommissionRule, error) {
+	return s.repo.FindRules()
+}
+
+// validateRule memastikan staff valid, persentase masuk akal, dan hanya ada satu aturan aktif
+// per staff (atau default) per jenis sesi
+func (s *CommissionService) validateRule(rule *models.CommissionRule) error {
+	if rule.StaffID != nil {
+		staff, err := s.userRepo.FindByID(*rule.StaffID)
+		if err != nil || staff == nil || !models.IsStaffRole(staff.Role) {
+			return errors.New("staff tidak ditemukan")
+		}
+	}
+	if rule.Type == models.CommissionTypePercentage {
+		// Member tidak membayar per sesi kelas (sudah termasuk langganan)
+		if rule.SessionType != models.CommissionSessionPT {
+			return errors.New("komisi persentase hanya berlaku untuk sesi PT")
+		}
+		if rule.Value > 100 {
+			return errors.New("persentase komisi tidak boleh lebih dari 100")
+		}
+	}
+	if !rule.IsActive {
+		return nil
+	}
+
+	existing, err := s.repo.FindActiveRule(rule.StaffID, rule.SessionType, rule.ID)
+	if err != nil {
+		return errors.New("gagal memeriksa aturan komisi")
+	}
+	if existing != nil {
+		return errors.New("sudah ada aturan komisi aktif untuk staff dan jenis sesi ini")
+	}
+	return nil
+}
+
+func (s *CommissionService) CreateRule(input models.CommissionRuleInput, actor Actor) (*models.CommissionRule, error) {
+	rule := models.CommissionRule{
+		StaffID:     input.StaffID,
+		SessionType: input.SessionType,
+		Type:        input.Type,
+		Value:       roundMoney(input.Value),
+		IsActive:    true,
+	}
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+	if err := s.validateRule(&rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(&rule); err != nil {
+		return nil, errors.New("gagal menyimpan aturan komisi")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityCommissionRule, rule.ID, nil, rule)
+	return &rule, nil
+}
+
+func (s *CommissionService) UpdateRule(id uint, input models.CommissionRuleInput, actor Actor) (*models.CommissionRule, error) {
+	rule, err := s.repo.FindRuleByID(id)
+	if err != nil || rule == nil {
+		return nil, errors.New("aturan komisi tidak ditemukan")
+	}
+
+	before := *rule
+	rule.StaffID = input.StaffID
+	rule.SessionType = input.SessionType
+	rule.Type = input.Type
+	rule.Value = roundMoney(input.Value)
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+	if err := s.validateRule(rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRule(rule); err != nil {
+		return nil, errors.New("gagal memperbarui aturan komisi")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityCommissionRule, rule.ID, before, rule)
+	return rule, nil
+}
+
+func (s *CommissionService) DeleteRule(id uint, actor Actor) error {
+	rule, err := s.repo.FindRuleByID(id)
+	if err != nil || rule == nil {
+		return errors.New("aturan komisi tidak ditemukan")
+	}
+	if err := s.repo.DeleteRule(id); err != nil {
+		return err
+	}
+	recordAudit(actor, models.AuditActionDelete, AuditEntityCommissionRule, id, rule, nil)
+	return nil
+}
+
+// --- Laporan ---
+
+// ruleBook: Aturan aktif; aturan khusus staff mengalahkan aturan default
+type ruleBook struct {
+	byStaff  map[string]*models.CommissionRule
+	defaults map[string]*models.CommissionRule
+}
+
+func newRuleBook(rules []models.CommissionRule) *ruleBook {
+	book := &ruleBook{
+		byStaff:  map[string]*models.CommissionRule{},
+		defaults: map[string]*models.CommissionRule{},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive {
+			continue
+		}
+		if rule.StaffID == nil {
+			book.defaults[rule.SessionType] = rule
+		} else {
+			book.byStaff[rule.StaffID.String()+"|"+rule.SessionType] = rule
+		}
+	}
+	return book
+}
+
+func (b *ruleBook) find(staffID uuid.UUID, sessionType string) *models.CommissionRule {
+	if rule, ok := b.byStaff[staffID.String()+"|"+sessionType]; ok {
+		return rule
+	}
+	return b.defaults[sessionType]
+}
+
+// commissionFor: flat = nominal per sesi, percentage = persen dari harga per sesi yang dibayar member
+func commissionFor(rule *models.CommissionRule, memberPaid float64) float64 {
+	if rule == nil {
+		return 0
+	}
+	if rule.Type == models.CommissionTypePercentage {
+		return roundMoney(memberPaid * rule.Value / 100)
+	}
+	return rule.Value
+}
+
+// GetReport: Sesi PT & kelas yang diberikan per staff beserta komisinya.
+// Tanpa date_from dihitung sejak awal bulan berjalan; tanpa date_to sampai sekarang.
+func (s *CommissionService) GetReport(dateFromStr, dateToStr string) (*CommissionReport, error) {
+	now := time.Now().In(config.Location())
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, config.Location())
+	to := now
+	if parsed := parseDateParam(dateFromStr, false); parsed != nil {
+		from = *parsed
+	}
+	if parsed := parseDateParam(dateToStr, true); parsed != nil {
+		to = *parsed
+	}
+	if to.Before(from) {
+		return nil, errors.New("tanggal akhir harus setelah tanggal awal")
+	}
+
+	rules, err := s.repo.FindRules()
+	if err != nil {
+		return nil, err
+	}
+	book := newRuleBook(rules)
+
+	appointments, err := s.repo.FindDeliveredPTAppointments(from, to)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.repo.FindDeliveredClassSessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	sessionIDs := make([]uuid.UUID, len(sessions))
+	for i := range sessions {
+		sessionIDs[i] = sessions[i].ID
+	}
+	attended, err := s.repo.CountAttendedBySessionIDs(sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byStaff := map[uuid.UUID]*StaffCommission{}
+	staffEntry := func(staffID uuid.UUID) *StaffCommission {
+		if entry, ok := byStaff[staffID]; ok {
+			return entry
+		}
+		entry := &StaffCommission{StaffID: staffID, Lines: []CommissionLine{}}
+		if staff, err := s.userRepo.FindByID(staffID); err == nil && staff != nil {
+			entry.StaffName = staff.Name
+			entry.StaffEmail = staff.Email
+		}
+		byStaff[staffID] = entry
+		return entry
+	}
+
+	for i := range appointments {
+		a := &appointments[i]
+		memberPaid := 0.0
+		if a.Credit != nil && a.Credit.TotalSessions > 0 {
+			memberPaid = roundMoney(a.Credit.Price / float64(a.Credit.TotalSessions))
+		}
+		description := "Personal training"
+		if a.User != nil {
+			description += " - " + a.User.Name
+		}
+
+		entry := staffEntry(*a.DeliveredByID)
+		rule := book.find(entry.StaffID, models.CommissionSessionPT)
+		line := CommissionLine{
+			SessionType: models.CommissionSessionPT,
+			SessionID:   a.ID,
+			StartTime:   a.StartTime,
+			Description: description,
+			Status:      a.Status,
+			Attendees:   1,
+			MemberPaid:  memberPaid,
+			Commission:  commissionFor(rule, memberPaid),
+		}
+		if a.Status == models.PTAppointmentStatusNoShow {
+			line.Attendees = 0
+		}
+		if rule != nil {
+			line.RuleType, line.RuleValue = rule.Type, rule.Value
+		}
+		entry.PTSessions++
+		entry.PTRevenue = roundMoney(entry.PTRevenue + memberPaid)
+		entry.PTCommission = roundMoney(entry.PTCommission + line.Commission)
+		entry.Lines = append(entry.Lines, line)
+	}
+
+	for i := range sessions {
+		session := &sessions[i]
+		entry := staffEntry(*session.DeliveredByID)
+		rule := book.find(entry.StaffID, models.CommissionSessionClass)
+		line := CommissionLine{
+			SessionType: models.CommissionSessionClass,
+			SessionID:   session.ID,
+			StartTime:   session.StartTime,
+			Description: "Kelas " + session.ClassType.Name,
+			Status:      session.Status,
+			Attendees:   attended[session.ID],
+			Commission:  commissionFor(rule, 0),
+		}
+		if rule != nil {
+			line.RuleType, line.RuleValue = rule.Type, rule.Value
+		}
+		entry.ClassSessions++
+		entry.ClassCommission = roundMoney(entry.ClassCommission + line.Commission)
+		entry.Lines = append(entry.Lines, line)
+	}
+
+	report := &CommissionReport{From: from, To: to, Staff: []StaffCommission{}}
+	for _, entry := range byStaff {
+		sort.Slice(entry.Lines, func(i, j int) bool { return entry.Lines[i].StartTime.Before(entry.Lines[j].StartTime) })
+		entry.TotalCommission = roundMoney(entry.PTCommission + entry.ClassCommission)
+		report.TotalCommission = roundMoney(report.TotalCommission + entry.TotalCommission)
+		report.Staff = append(report.Staff, *entry)
+	}
+	sort.Slice(report.Staff, func(i, j int) bool { return report.Staff[i].StaffName < report.Staff[j].StaffName })
+	return report, nil
+}
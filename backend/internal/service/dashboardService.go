@@ -3,6 +3,7 @@ package service
 import (
 	"gym_management/config"
 	"gym_management/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -20,6 +21,19 @@ type DashboardStats struct {
 	ActiveMembers           int64          `json:"activeMembers"`
 	MembersByPackage        []PackageCount `json:"membersByPackage"`
 	ProjectedMonthlyRevenue float64        `json:"projectedMonthlyRevenue"`
+
+	// Revenue nyata dari tabel payments (hanya status 'paid')
+	RevenueToday     float64         `json:"revenueToday"`
+	RevenueThisMonth float64         `json:"revenueThisMonth"`
+	DailyRevenue     []RevenuePeriod `json:"dailyRevenue"`
+	MonthlyRevenue   []RevenuePeriod `json:"monthlyRevenue"`
+}
+
+// RevenuePeriod: Total pembayaran per hari (YYYY-MM-DD) atau per bulan (YYYY-MM)
+type RevenuePeriod struct {
+	Period string  `json:"period"`
+	Total  float64 `json:"total"`
+	Count  int64   `json:"count"`
 }
 
 type PackageCount struct {
@@ -67,5 +81,45 @@ func (s *DashboardService) GetStats() (*DashboardStats, error) {
 
 	stats.ProjectedMonthlyRevenue = revenue
 
+	// 4. Revenue Nyata (dari pembayaran yang tidak dibatalkan)
+	// Batas hari/bulan dan pengelompokan mengikuti zona waktu gym (APP_TIMEZONE), bukan TimeZone koneksi
+	loc := config.Location()
+	today := config.StartOfDay(time.Now())
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+
+	if err := s.db.Raw(`
+		SELECT COALESCE(SUM(amount), 0) FROM payments
+		WHERE status = 'paid' AND paid_at >= ?
+	`, today).Scan(&stats.RevenueToday).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Raw(`
+		SELECT COALESCE(SUM(amount), 0) FROM payments
+		WHERE status = 'paid' AND paid_at >= ?
+	`, thisMonth).Scan(&stats.RevenueThisMonth).Error; err != nil {
+		return nil, err
+	}
+
+	// Per hari untuk 30 hari terakhir
+	if err := s.db.Raw(`
+		SELECT to_char(paid_at AT TIME ZONE ?, 'YYYY-MM-DD') AS period, SUM(amount) AS total, COUNT(*) AS count
+		FROM payments
+		WHERE status = 'paid' AND paid_at >= ?
+		GROUP BY 1 ORDER BY 1
+	`, loc.String(), today.AddDate(0, 0, -29)).Scan(&stats.DailyRevenue).Error; err != nil {
+		return nil, err
+	}
+
+	// Per bulan untuk 12 bulan terakhir
+	if err := s.db.Raw(`
+		SELECT to_char(paid_at AT TIME ZONE ?, 'YYYY-MM') AS period, SUM(amount) AS total, COUNT(*) AS count
+		FROM payments
+		WHERE status = 'paid' AND paid_at >= ?
+		GROUP BY 1 ORDER BY 1
+	`, loc.String(), thisMonth.AddDate(0, -11, 0)).Scan(&stats.MonthlyRevenue).Error; err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
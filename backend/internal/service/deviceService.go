@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deviceKeyPrefix = "gymk_"
+
+type DeviceService struct {
+	repo repository.DeviceRepository
+}
+
+func NewDeviceService() *DeviceService {
+	return &DeviceService{repo: repository.NewDeviceRepository()}
+}
+
+// hashToken: SHA-256 hex dari token acak (API key, token sekali pakai, dsb.)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRandomToken membuat string acak URL-safe dari n byte.
+func generateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GetDevices: Daftar kiosk (Admin)
+func (s *DeviceService) GetDevices() ([]models.Device, error) {
+	return s.repo.FindAll()
+}
+
+// CreateDevice mendaftarkan kiosk baru dan mengembalikan API key mentah (hanya sekali).
+func (s *DeviceService) CreateDevice(input models.DeviceInput, actor Actor) (*models.Device, string, error) {
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return nil, "", errors.New("gagal membuat API key")
+	}
+	apiKey := deviceKeyPrefix + secret
+
+	device := models.Device{
+		Name:        input.Name,
+		KeyPrefix:   apiKey[:len(deviceKeyPrefix)+6],
+		KeyHash:     hashToken(apiKey),
+		IsActive:    true,
+		CreatedByID: actor.UserID,
+	}
+	if err := s.repo.Create(&device); err != nil {
+		return nil, "", errors.New("gagal menyimpan device")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityDevice, device.ID, nil, device)
+	return &device, apiKey, nil
+}
+
+// RevokeDevice menonaktifkan kiosk; API key-nya langsung tidak bisa dipakai.
+func (s *DeviceService) RevokeDevice(id uuid.UUID, actor Actor) error {
+	device, err := s.repo.FindByID(id)
+	if err != nil || device == nil {
+		return errors.New("device tidak ditemukan")
+	}
+	if !device.IsActive {
+		return nil
+	}
+
+	before := *device
+	now := time.Now()
+	device.IsActive = false
+	device.RevokedAt = &now
+	if err := s.repo.Update(device); err != nil {
+		return errors.New("gagal mencabut device")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityDevice, device.ID, before, device)
+	return nil
+}
+
+// AuthenticateDevice memverifikasi API key kiosk dari header request.
+func (s *DeviceService) AuthenticateDevice(apiKey string) (*models.Device, error) {
+	device, err := s.repo.FindByKeyHash(hashToken(apiKey))
+	if err != nil || device == nil || !device.IsActive {
+		return nil, errors.New("API key device tidak valid")
+	}
+
+	// Batasi penulisan last_used_at agar tidak terjadi di setiap request
+	now := time.Now()
+	if device.LastUsedAt == nil || now.Sub(*device.LastUsedAt) > time.Minute {
+		s.repo.TouchLastUsed(device.ID, now)
+	}
+	return device, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// emailVerificationPurpose membedakan token verifikasi dari token lain yang ditandatangani secret sama
+const emailVerificationPurpose = "email_verification"
+
+// defaultUnverifiedPermissions: Akun yang belum verifikasi email hanya boleh melihat-lihat
+// (portal diri, paket, jadwal kelas & PT), belum boleh booking.
+var defaultUnverifiedPermissions = []string{
+	models.PermSelfPortal, models.PermPackagesRead, models.PermClassesRead, models.PermPTRead,
+}
+
+var (
+	unverifiedPermissions     map[string]bool
+	unverifiedPermissionsOnce sync.Once
+)
+
+type emailVerificationClaims struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Email   string    `json:"email"`
+	Purpose string    `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// UnverifiedLoginAllowed: Apakah akun yang belum verifikasi email boleh login (UNVERIFIED_LOGIN_ALLOWED, default true)
+func UnverifiedLoginAllowed() bool {
+	return config.GetEnvBool("UNVERIFIED_LOGIN_ALLOWED", true)
+}
+
+// UnverifiedAllowed: Apakah permission tetap boleh dipakai akun yang belum verifikasi email.
+// Daftar diatur lewat UNVERIFIED_ALLOWED_PERMISSIONS (dipisah koma), default defaultUnverifiedPermissions.
+func UnverifiedAllowed(permission string) bool {
+	unverifiedPermissionsOnce.Do(func() {
+		codes := defaultUnverifiedPermissions
+		if value, ok := os.LookupEnv("UNVERIFIED_ALLOWED_PERMISSIONS"); ok {
+			codes = strings.Split(value, ",")
+		}
+		unverifiedPermissions = make(map[string]bool)
+		for _, code := range codes {
+			if code = strings.TrimSpace(code); code != "" {
+				unverifiedPermissions[code] = true
+			}
+		}
+	})
+	return unverifiedPermissions[permission]
+}
+
+// emailVerificationSecret: EMAIL_VERIFICATION_SECRET, atau diturunkan dari JWT_SECRET jika tidak diset
+func emailVerificationSecret() string {
+	return derivedSecret("EMAIL_VERIFICATION_SECRET", emailVerificationPurpose)
+}
+
+// sendVerificationEmail menerbitkan token verifikasi bertanda tangan dan mengantrekan email berisi tautannya.
+// Email user ikut ditandatangani sehingga token lama tidak berlaku setelah email diganti.
+func sendVerificationEmail(user *models.User) error {
+	now := time.Now()
+	ttl := time.Duration(config.GetEnvInt("EMAIL_VERIFICATION_TTL_HOURS", 48)) * time.Hour
+	tokenID := uuid.New()
+	claims := &emailVerificationClaims{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Purpose: emailVerificationPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	token, err := signToken(claims, emailVerificationSecret())
+	if err != nil {
+		return errors.New("gagal membuat token verifikasi")
+	}
+
+	baseURL := os.Getenv("APP_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	link := strings.TrimRight(baseURL, "/") + "/api/auth/verify-email?token=" + url.QueryEscape(token)
+
+	notification := models.Notification{
+		UserID:      user.ID,
+		Type:        models.NotificationTypeEmailVerify,
+		ReferenceID: &tokenID,
+		Recipient:   user.Email,
+		Subject:     "Verifikasi email akun gym Anda",
+		Sensitive:   true,
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nTerima kasih telah mendaftar. Silakan verifikasi email Anda dengan membuka tautan berikut:\n\n%s\n\nTautan berlaku hingga %s. Abaikan pesan ini jika Anda tidak merasa mendaftar.\n",
+			user.Name, link, now.Add(ttl).In(config.Location()).Format("2006-01-02 15:04"),
+		),
+	}
+	if _, err := authNotifications.Queue(&notification); err != nil {
+		return errors.New("gagal mengantrekan email verifikasi")
+	}
+
+	user.VerificationSentAt = &now
+	return authRepo.Update(user)
+}
+
+// VerifyEmailService menandai email user terverifikasi berdasarkan token dari email verifikasi.
+// Token yang sudah pernah dipakai tetap dianggap berhasil (idempoten).
+func VerifyEmailService(tokenString string, actor Actor) error {
+	invalid := errors.New("token verifikasi tidak valid atau kadaluarsa")
+
+	claims := &emailVerificationClaims{}
+	if err := parseToken(tokenString, claims, emailVerificationSecret()); err != nil || claims.Purpose != emailVerificationPurpose {
+		return invalid
+	}
+
+	user, err := authRepo.FindByID(claims.UserID)
+	if err != nil || !strings.EqualFold(user.Email, claims.Email) {
+		return invalid
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	before := *user
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := authRepo.Update(user); err != nil {
+		return errors.New("gagal menyimpan status verifikasi")
+	}
+
+	actor.UserID = user.ID
+	actor.Role = user.Role
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityMember, user.ID, before, *user)
+	return nil
+}
+
+// ResendVerificationService mengirim ulang email verifikasi. Email yang tidak terdaftar, sudah
+// terverifikasi, atau masih dalam jeda EMAIL_VERIFICATION_RESEND_SECONDS (default 60) diabaikan
+// tanpa error agar keberadaan akun tidak bocor.
+func ResendVerificationService(email string) error {
+	user, err := authRepo.FindByEmail(email)
+	if err != nil {
+		return errors.New("gagal memproses permintaan verifikasi")
+	}
+	if user == nil || !user.IsActive || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	cooldown := time.Duration(config.GetEnvInt("EMAIL_VERIFICATION_RESEND_SECONDS", 60)) * time.Second
+	if user.VerificationSentAt != nil && time.Since(*user.VerificationSentAt) < cooldown {
+		return nil
+	}
+
+	if err := sendVerificationEmail(user); err != nil {
+		log.Println("Gagal mengirim ulang email verifikasi:", err)
+		return err
+	}
+	return nil
+}
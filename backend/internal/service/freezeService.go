@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var freezeRepo = repository.NewFreezeRepository()
+
+// FreezeMembership membekukan langganan member yang sedang berjalan. EndDate langganan
+// (dan perpanjangan yang sudah dibeli setelahnya) langsung digeser sebanyak hari cuti.
+func (s *MembershipService) FreezeMembership(memberID uuid.UUID, input models.FreezeInput, actor Actor) (*models.MembershipFreeze, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	now := time.Now()
+	start := now
+	if input.StartDate != nil {
+		if input.StartDate.Before(now.Add(-time.Minute)) {
+			return nil, errors.New("tanggal mulai cuti tidak boleh di masa lalu")
+		}
+		start = *input.StartDate
+	}
+
+	membership, err := s.repo.FindCurrentByUserID(member.ID, start)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa langganan member")
+	}
+	if membership == nil {
+		return nil, errors.New("member tidak memiliki langganan aktif pada tanggal mulai cuti")
+	}
+
+	pkg := membership.Package
+	if pkg.MaxFreezes == 0 {
+		return nil, errors.New("paket " + pkg.Name + " tidak dapat dibekukan")
+	}
+	if pkg.MaxFreezeDays == 0 && input.Days < pkg.MinFreezeDays {
+		return nil, fmt.Errorf("lama cuti minimal %d hari", pkg.MinFreezeDays)
+	}
+	if pkg.MaxFreezeDays > 0 && (input.Days < pkg.MinFreezeDays || input.Days > pkg.MaxFreezeDays) {
+		return nil, fmt.Errorf("lama cuti harus antara %d dan %d hari", pkg.MinFreezeDays, pkg.MaxFreezeDays)
+	}
+
+	freeze := models.MembershipFreeze{
+		MembershipID: membership.ID,
+		UserID:       member.ID,
+		StartDate:    start,
+		EndDate:      start.AddDate(0, 0, input.Days),
+		Days:         input.Days,
+		Reason:       input.Reason,
+		CreatedByID:  actor.UserID,
+	}
+
+	// Batas cuti & cuti terbuka diperiksa setelah member dan langganannya dikunci, agar dua
+	// permintaan bersamaan tidak sama-sama lolos
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.MembershipRepository) error {
+		if err := tx.LockMember(member.ID); err != nil {
+			return errors.New("gagal memeriksa riwayat cuti")
+		}
+		locked, err := tx.LockByID(membership.ID)
+		if err != nil || locked == nil {
+			return errors.New("gagal memeriksa langganan member")
+		}
+		if locked.Status != models.MembershipStatusActive || locked.StartDate.After(start) || !locked.EndDate.After(start) {
+			return errors.New("member tidak memiliki langganan aktif pada tanggal mulai cuti")
+		}
+		locked.Package = pkg
+
+		used, err := tx.CountFreezes(locked.ID)
+		if err != nil {
+			return errors.New("gagal memeriksa riwayat cuti")
+		}
+		if used >= int64(pkg.MaxFreezes) {
+			return fmt.Errorf("batas cuti paket %s sudah tercapai (%d kali)", pkg.Name, pkg.MaxFreezes)
+		}
+		open, err := tx.FindOpenFreeze(member.ID, now)
+		if err != nil {
+			return errors.New("gagal memeriksa riwayat cuti")
+		}
+		if open != nil {
+			return errors.New("member masih memiliki cuti yang berjalan atau terjadwal")
+		}
+
+		if err := shiftMemberships(tx, &trail, locked, input.Days); err != nil {
+			return err
+		}
+		if err := tx.CreateFreeze(&freeze); err != nil {
+			return errors.New("gagal menyimpan data cuti")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityFreeze, freeze.ID, nil, freeze)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return &freeze, nil
+}
+
+// UnfreezeMembership menghentikan cuti lebih awal (atau membatalkan cuti yang belum dimulai).
+// Hari yang tidak terpakai dikembalikan, dengan minimal MinFreezeDays paket tetap terhitung.
+func (s *MembershipService) UnfreezeMembership(memberID uuid.UUID, actor Actor) (*models.MembershipFreeze, error) {
+	now := time.Now()
+	var freeze *models.MembershipFreeze
+	var trail auditTrail
+	err := s.repo.Transaction(func(tx repository.MembershipRepository) error {
+		if err := tx.LockMember(memberID); err != nil {
+			return errors.New("gagal memeriksa riwayat cuti")
+		}
+		open, err := tx.FindOpenFreeze(memberID, now)
+		if err != nil {
+			return errors.New("gagal memeriksa riwayat cuti")
+		}
+		if open == nil {
+			return errors.New("member tidak sedang cuti")
+		}
+		freeze = open
+
+		membership, err := tx.FindByID(freeze.MembershipID)
+		if err != nil || membership == nil {
+			return errors.New("langganan tidak ditemukan")
+		}
+
+		credited := 0
+		if freeze.StartDate.Before(now) {
+			elapsed := int(math.Ceil(now.Sub(freeze.StartDate).Hours() / 24))
+			credited = max(elapsed, membership.Package.MinFreezeDays)
+			credited = min(credited, freeze.Days)
+		}
+
+		if unused := freeze.Days - credited; unused > 0 {
+			if err := shiftMemberships(tx, &trail, membership, -unused); err != nil {
+				return err
+			}
+		}
+
+		before := *freeze
+		freeze.EndedAt = &now
+		freeze.Days = credited
+		if err := tx.UpdateFreeze(freeze); err != nil {
+			return errors.New("gagal memperbarui data cuti")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityFreeze, freeze.ID, before, freeze)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return freeze, nil
+}
+
+// GetFreezes: Riwayat cuti member (Admin/Staff)
+func (s *MembershipService) GetFreezes(memberID uuid.UUID) ([]models.MembershipFreeze, error) {
+	return freezeRepo.FindByUserID(memberID)
+}
+
+// shiftMemberships menggeser EndDate langganan dan seluruh perpanjangan sesudahnya sebanyak `days`.
+// Dipanggil di dalam transaksi bersama penyimpanan data cuti.
+func shiftMemberships(repo repository.MembershipRepository, trail *auditTrail, membership *models.Membership, days int) error {
+	following, err := repo.FindStartingFrom(membership.UserID, membership.EndDate)
+	if err != nil {
+		return errors.New("gagal memeriksa langganan member")
+	}
+
+	before := *membership
+	membership.EndDate = membership.EndDate.AddDate(0, 0, days)
+	if err := repo.Update(membership); err != nil {
+		return errors.New("gagal memperbarui masa aktif langganan")
+	}
+	trail.add(models.AuditActionUpdate, AuditEntityMembership, membership.ID, before, membership)
+
+	for i := range following {
+		next := &following[i]
+		if next.ID == membership.ID {
+			continue
+		}
+		nextBefore := *next
+		next.StartDate = next.StartDate.AddDate(0, 0, days)
+		next.EndDate = next.EndDate.AddDate(0, 0, days)
+		if err := repo.Update(next); err != nil {
+			return errors.New("gagal memperbarui masa aktif langganan")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityMembership, next.ID, nextBefore, next)
+	}
+	return nil
+}
+
+// checkFreeze menolak Check-In selama member sedang cuti
+func checkFreeze(userID uuid.UUID, at time.Time) error {
+	freeze, err := freezeRepo.FindActiveByUserID(userID, at)
+	if err != nil {
+		return errors.New("gagal memeriksa status cuti member")
+	}
+	if freeze != nil {
+		return errors.New("membership sedang dibekukan hingga " + formatDate(freeze.EndDate))
+	}
+	return nil
+}
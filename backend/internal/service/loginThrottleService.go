@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// LoginLockedError: Login ditolak sementara karena terlalu banyak percobaan gagal (HTTP 429)
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("terlalu banyak percobaan login gagal, coba lagi dalam %d detik", e.RetrySeconds())
+}
+
+// RetrySeconds: Nilai header Retry-After (dibulatkan ke atas, minimal 1)
+func (e *LoginLockedError) RetrySeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// throttleScope: Ambang batas untuk satu jenis kunci (akun atau IP)
+type throttleScope struct {
+	FreeAttempts     int // Kegagalan tanpa jeda
+	LockoutThreshold int // Kegagalan yang memicu blokir penuh
+}
+
+// loginThrottlePolicy: Kebijakan dari environment. Setelah FreeAttempts, setiap kegagalan memberi
+// jeda 1, 2, 4, ... detik (maksimal LOGIN_BACKOFF_MAX_SECONDS); pada LockoutThreshold kunci
+// diblokir selama LOGIN_LOCKOUT_MINUTES. Hitungan dimulai ulang setelah LOGIN_FAILURE_WINDOW_MINUTES
+// tanpa kegagalan.
+type loginThrottlePolicy struct {
+	Account    throttleScope
+	IP         throttleScope
+	MaxBackoff time.Duration
+	Lockout    time.Duration
+	Window     time.Duration
+}
+
+func currentLoginThrottlePolicy() loginThrottlePolicy {
+	return loginThrottlePolicy{
+		Account: throttleScope{
+			FreeAttempts:     config.GetEnvInt("LOGIN_ACCOUNT_FREE_ATTEMPTS", 3),
+			LockoutThreshold: config.GetEnvInt("LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", 10),
+		},
+		IP: throttleScope{
+			FreeAttempts:     config.GetEnvInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+			LockoutThreshold: config.GetEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+		},
+		MaxBackoff: time.Duration(config.GetEnvInt("LOGIN_BACKOFF_MAX_SECONDS", 300)) * time.Second,
+		Lockout:    time.Duration(config.GetEnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		Window:     time.Duration(config.GetEnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 60)) * time.Minute,
+	}
+}
+
+// LoginLockout: Kunci yang sedang diblokir (untuk admin)
+type LoginLockout struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+	BlockedUntil  time.Time `json:"blockedUntil"`
+	Locked        bool      `json:"locked"` // true = blokir penuh, false = masih jeda backoff
+}
+
+type LoginThrottleService struct {
+	repo repository.LoginThrottleRepository
+}
+
+func NewLoginThrottleService() *LoginThrottleService {
+	return NewLoginThrottleServiceWithStore(repository.NewLoginThrottleRepository())
+}
+
+// NewLoginThrottleServiceWithStore memakai store kustom (mis. in-memory untuk pengujian)
+func NewLoginThrottleServiceWithStore(store repository.LoginThrottleRepository) *LoginThrottleService {
+	return &LoginThrottleService{repo: store}
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check menolak percobaan login jika akun atau IP masih dalam jeda/blokir.
+// Kegagalan store tidak memblokir login (fail-open) agar gangguan DB tidak mengunci semua user.
+func (s *LoginThrottleService) Check(email, ip string, now time.Time) error {
+	rows, err := s.repo.Find(s.keys(email, ip)...)
+	if err != nil {
+		log.Println("Gagal membaca status throttle login:", err)
+		return nil
+	}
+	policy := currentLoginThrottlePolicy()
+	var retryAfter time.Duration
+	for _, row := range rows {
+		if until, _ := policy.blockedUntil(row, now); until.After(now) {
+			retryAfter = max(retryAfter, until.Sub(now))
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure mencatat login gagal untuk akun dan IP. Mengembalikan LoginLockedError jika
+// kegagalan ini membuat akun/IP terkena jeda atau blokir.
+func (s *LoginThrottleService) RecordFailure(email, ip string, now time.Time) error {
+	policy := currentLoginThrottlePolicy()
+	var retryAfter time.Duration
+	for _, key := range s.keys(email, ip) {
+		row, err := s.repo.RecordFailure(key, now, now.Add(-policy.Window))
+		if err != nil {
+			log.Println("Gagal mencatat login gagal:", err)
+			continue
+		}
+		until, locked := policy.blockedUntil(*row, now)
+		if locked && row.Failures == policy.scope(row.Key).LockoutThreshold {
+			log.Printf("Login diblokir untuk %s hingga %s setelah %d percobaan gagal", row.Key, until.Format(time.RFC3339), row.Failures)
+		}
+		if until.After(now) {
+			retryAfter = max(retryAfter, until.Sub(now))
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Reset menghapus hitungan gagal akun setelah login berhasil. Hitungan IP sengaja tidak direset
+// agar login ke akun sendiri tidak bisa dipakai untuk menghapus jejak percobaan ke akun lain.
+func (s *LoginThrottleService) Reset(email string) {
+	if _, err := s.repo.Delete(accountThrottleKey(email)); err != nil {
+		log.Println("Gagal mereset throttle login:", err)
+	}
+}
+
+// GetLockouts: Akun & IP yang saat ini sedang dalam jeda atau blokir (Admin)
+func (s *LoginThrottleService) GetLockouts(now time.Time) ([]LoginLockout, error) {
+	policy := currentLoginThrottlePolicy()
+	rows, err := s.repo.FindSince(now.Add(-max(policy.Lockout, policy.MaxBackoff)))
+	if err != nil {
+		return nil, errors.New("gagal mengambil data blokir login")
+	}
+	lockouts := []LoginLockout{}
+	for _, row := range rows {
+		until, locked := policy.blockedUntil(row, now)
+		if !until.After(now) {
+			continue
+		}
+		lockouts = append(lockouts, LoginLockout{
+			Key:           row.Key,
+			Failures:      row.Failures,
+			LastFailureAt: row.LastFailureAt,
+			BlockedUntil:  until,
+			Locked:        locked,
+		})
+	}
+	return lockouts, nil
+}
+
+// Unlock membuka blokir akun (email) dan/atau IP (Admin)
+func (s *LoginThrottleService) Unlock(email, ip string, actor Actor) (int64, error) {
+	if email == "" && ip == "" {
+		return 0, errors.New("email atau IP wajib diisi")
+	}
+	keys := s.keys(email, ip)
+	before, _ := s.repo.Find(keys...)
+	count, err := s.repo.Delete(keys...)
+	if err != nil {
+		return 0, errors.New("gagal membuka blokir login")
+	}
+	for _, row := range before {
+		recordAudit(actor, models.AuditActionDelete, AuditEntityLoginThrottle, row.Key, row, nil)
+	}
+	return count, nil
+}
+
+// PurgeStale menghapus hitungan yang sudah lewat jendela kegagalan dan masa blokir (job harian)
+func (s *LoginThrottleService) PurgeStale(now time.Time) (int64, error) {
+	policy := currentLoginThrottlePolicy()
+	return s.repo.DeleteBefore(now.Add(-max(policy.Window, policy.Lockout)))
+}
+
+func (s *LoginThrottleService) keys(email, ip string) []string {
+	var keys []string
+	if email != "" {
+		keys = append(keys, accountThrottleKey(email))
+	}
+	if ip != "" {
+		keys = append(keys, ipThrottleKey(ip))
+	}
+	return keys
+}
+
+func (p loginThrottlePolicy) scope(key string) throttleScope {
+	if strings.HasPrefix(key, "ip:") {
+		return p.IP
+	}
+	return p.Account
+}
+
+// blockedUntil menghitung akhir jeda/blokir dari hitungan gagal; locked true jika blokir penuh.
+// Hitungan yang sudah lewat jendela kegagalan tidak lagi berpengaruh.
+func (p loginThrottlePolicy) blockedUntil(row models.LoginThrottle, now time.Time) (time.Time, bool) {
+	if row.LastFailureAt.Before(now.Add(-p.Window)) {
+		return time.Time{}, false
+	}
+	scope := p.scope(row.Key)
+	if row.Failures >= scope.LockoutThreshold {
+		return row.LastFailureAt.Add(p.Lockout), true
+	}
+	if row.Failures <= scope.FreeAttempts {
+		return time.Time{}, false
+	}
+	backoff := time.Second << min(row.Failures-scope.FreeAttempts-1, 30)
+	return row.LastFailureAt.Add(min(backoff, p.MaxBackoff)), false
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"gym_management/internal/models"
+	"testing"
+	"time"
+)
+
+// memThrottleStore: LoginThrottleRepository in-memory dengan semantik yang sama seperti versi Postgres.
+type memThrottleStore struct {
+	rows map[string]models.LoginThrottle
+}
+
+func newMemThrottleStore() *memThrottleStore {
+	return &memThrottleStore{rows: map[string]models.LoginThrottle{}}
+}
+
+func (s *memThrottleStore) Find(keys ...string) ([]models.LoginThrottle, error) {
+	var rows []models.LoginThrottle
+	for _, key := range keys {
+		if row, ok := s.rows[key]; ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *memThrottleStore) RecordFailure(key string, at, windowStart time.Time) (*models.LoginThrottle, error) {
+	row, ok := s.rows[key]
+	if !ok || row.LastFailureAt.Before(windowStart) {
+		row = models.LoginThrottle{Key: key}
+	}
+	row.Failures++
+	row.LastFailureAt = at
+	row.UpdatedAt = at
+	s.rows[key] = row
+	return &row, nil
+}
+
+func (s *memThrottleStore) Delete(keys ...string) (int64, error) {
+	var count int64
+	for _, key := range keys {
+		if _, ok := s.rows[key]; ok {
+			delete(s.rows, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memThrottleStore) FindSince(since time.Time) ([]models.LoginThrottle, error) {
+	var rows []models.LoginThrottle
+	for _, row := range s.rows {
+		if !row.LastFailureAt.Before(since) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *memThrottleStore) DeleteBefore(before time.Time) (int64, error) {
+	var count int64
+	for key, row := range s.rows {
+		if row.LastFailureAt.Before(before) {
+			delete(s.rows, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// setThrottlePolicy memasang kebijakan default secara eksplisit agar tes tidak bergantung pada environment.
+func setThrottlePolicy(t *testing.T) {
+	t.Helper()
+	t.Setenv("LOGIN_ACCOUNT_FREE_ATTEMPTS", "3")
+	t.Setenv("LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", "10")
+	t.Setenv("LOGIN_IP_FREE_ATTEMPTS", "20")
+	t.Setenv("LOGIN_IP_LOCKOUT_THRESHOLD", "100")
+	t.Setenv("LOGIN_BACKOFF_MAX_SECONDS", "300")
+	t.Setenv("LOGIN_LOCKOUT_MINUTES", "15")
+	t.Setenv("LOGIN_FAILURE_WINDOW_MINUTES", "60")
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var locked *LoginLockedError
+	if err == nil {
+		return 0
+	}
+	if !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want *LoginLockedError", err)
+	}
+	return locked.RetryAfter
+}
+
+var throttleNow = time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+func TestLoginThrottleBackoffGrowth(t *testing.T) {
+	setThrottlePolicy(t)
+	s := NewLoginThrottleServiceWithStore(newMemThrottleStore())
+
+	want := []time.Duration{0, 0, 0, 1, 2, 4, 8, 16, 32}
+	for i, w := range want {
+		got := retryAfter(t, s.RecordFailure("budi@example.com", "10.0.0.1", throttleNow))
+		if got != w*time.Second {
+			t.Fatalf("failure %d: retry after %s, want %s", i+1, got, w*time.Second)
+		}
+	}
+}
+
+func TestLoginThrottleBackoffCapped(t *testing.T) {
+	setThrottlePolicy(t)
+	t.Setenv("LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", "50")
+	t.Setenv("LOGIN_BACKOFF_MAX_SECONDS", "20")
+	s := NewLoginThrottleServiceWithStore(newMemThrottleStore())
+
+	var got time.Duration
+	for range 12 {
+		got = retryAfter(t, s.RecordFailure("budi@example.com", "", throttleNow))
+	}
+	if got != 20*time.Second {
+		t.Fatalf("retry after %s, want capped at 20s", got)
+	}
+}
+
+func TestLoginThrottleLockoutThreshold(t *testing.T) {
+	setThrottlePolicy(t)
+	s := NewLoginThrottleServiceWithStore(newMemThrottleStore())
+
+	var err error
+	for range 10 {
+		err = s.RecordFailure("budi@example.com", "10.0.0.1", throttleNow)
+	}
+	if got := retryAfter(t, err); got != 15*time.Minute {
+		t.Fatalf("retry after %s at threshold, want 15m", got)
+	}
+
+	// Check menolak login selama blokir dan mengizinkan lagi setelah masa blokir lewat
+	if got := retryAfter(t, s.Check("budi@example.com", "10.0.0.1", throttleNow.Add(10*time.Minute))); got != 5*time.Minute {
+		t.Fatalf("check retry after %s, want 5m", got)
+	}
+	if err := s.Check("budi@example.com", "10.0.0.1", throttleNow.Add(15*time.Minute)); err != nil {
+		t.Fatalf("check after lockout = %v, want nil", err)
+	}
+}
+
+func TestLoginThrottleWindowRestartsCount(t *testing.T) {
+	setThrottlePolicy(t)
+	s := NewLoginThrottleServiceWithStore(newMemThrottleStore())
+
+	for range 9 {
+		s.RecordFailure("budi@example.com", "", throttleNow)
+	}
+	later := throttleNow.Add(61 * time.Minute)
+	if err := s.RecordFailure("budi@example.com", "", later); err != nil {
+		t.Fatalf("failure after window = %v, want nil (count restarted)", err)
+	}
+}
+
+func TestLoginLockedErrorRetrySeconds(t *testing.T) {
+	cases := []struct {
+		retryAfter time.Duration
+		want       int
+	}{
+		{0, 1},
+		{300 * time.Millisecond, 1},
+		{2 * time.Second, 2},
+		{2*time.Second + time.Millisecond, 3},
+		{15 * time.Minute, 900},
+	}
+	for _, tc := range cases {
+		err := &LoginLockedError{RetryAfter: tc.retryAfter}
+		if got := err.RetrySeconds(); got != tc.want {
+			t.Errorf("RetrySeconds(%s) = %d, want %d", tc.retryAfter, got, tc.want)
+		}
+	}
+}
+
+func TestLoginThrottleResetOnSuccess(t *testing.T) {
+	setThrottlePolicy(t)
+	t.Setenv("LOGIN_IP_FREE_ATTEMPTS", "3")
+	store := newMemThrottleStore()
+	s := NewLoginThrottleServiceWithStore(store)
+
+	for range 5 {
+		s.RecordFailure("Budi@Example.com", "10.0.0.1", throttleNow)
+	}
+	s.Reset(" budi@example.com ")
+
+	if _, ok := store.rows[accountThrottleKey("budi@example.com")]; ok {
+		t.Fatal("account count not reset after successful login")
+	}
+	// Hitungan IP tetap ada agar login sukses tidak menghapus jejak percobaan ke akun lain
+	if row, ok := store.rows[ipThrottleKey("10.0.0.1")]; !ok || row.Failures != 5 {
+		t.Fatalf("ip row = %+v, want 5 failures kept", row)
+	}
+	if got := retryAfter(t, s.Check("budi@example.com", "10.0.0.1", throttleNow)); got != 2*time.Second {
+		t.Fatalf("check retry after %s, want ip backoff 2s", got)
+	}
+}
+
+func TestLoginThrottleAdminUnlock(t *testing.T) {
+	setThrottlePolicy(t)
+	s := NewLoginThrottleServiceWithStore(newMemThrottleStore())
+
+	for range 10 {
+		s.RecordFailure("budi@example.com", "10.0.0.1", throttleNow)
+	}
+	lockouts, err := s.GetLockouts(throttleNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lockouts) != 1 || lockouts[0].Key != accountThrottleKey("budi@example.com") || !lockouts[0].Locked {
+		t.Fatalf("lockouts = %+v, want locked account only", lockouts)
+	}
+
+	if _, err := s.Unlock("", "", Actor{Role: models.RoleAdmin}); err == nil {
+		t.Fatal("unlock without email or ip succeeded")
+	}
+	count, err := s.Unlock("budi@example.com", "", Actor{Role: models.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("unlocked %d keys, want 1", count)
+	}
+	if err := s.Check("budi@example.com", "", throttleNow); err != nil {
+		t.Fatalf("check after unlock = %v, want nil", err)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MeasurementService struct {
+	repo       repository.MeasurementRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewMeasurementService() *MeasurementService {
+	return &MeasurementService{
+		repo:       repository.NewMeasurementRepository(),
+		memberRepo: repository.NewMemberRepository(),
+	}
+}
+
+// MeasurementHistory: Deret tren asesmen member (terlama dulu) dan asesmen terakhir
+type MeasurementHistory struct {
+	Measurements []models.Measurement `json:"measurements"`
+	Latest       *models.Measurement  `json:"latest"`
+}
+
+// RecordMeasurement menyimpan hasil asesmen (Admin/Staff)
+func (s *MeasurementService) RecordMeasurement(memberID uuid.UUID, input models.MeasurementInput, actor Actor) (*models.Measurement, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	if input.HeightCm == nil && input.WeightKg == nil && input.BodyFatPercent == nil &&
+		input.ChestCm == nil && input.WaistCm == nil && input.HipCm == nil && input.ArmCm == nil && input.ThighCm == nil &&
+		input.SystolicBP == nil && input.DiastolicBP == nil {
+		return nil, errors.New("isi minimal satu hasil pengukuran")
+	}
+	if (input.SystolicBP == nil) != (input.DiastolicBP == nil) {
+		return nil, errors.New("tekanan darah harus diisi sistolik dan diastolik")
+	}
+	if input.SystolicBP != nil && *input.SystolicBP <= *input.DiastolicBP {
+		return nil, errors.New("tekanan sistolik harus lebih tinggi dari diastolik")
+	}
+
+	measuredAt := time.Now()
+	if input.MeasuredAt != nil {
+		if input.MeasuredAt.After(measuredAt) {
+			return nil, errors.New("tanggal pengukuran tidak boleh di masa depan")
+		}
+		measuredAt = *input.MeasuredAt
+	}
+
+	measurement := models.Measurement{
+		UserID:         member.ID,
+		RecordedByID:   actor.UserID,
+		MeasuredAt:     measuredAt,
+		HeightCm:       input.HeightCm,
+		WeightKg:       input.WeightKg,
+		BodyFatPercent: input.BodyFatPercent,
+		ChestCm:        input.ChestCm,
+		WaistCm:        input.WaistCm,
+		HipCm:          input.HipCm,
+		ArmCm:          input.ArmCm,
+		ThighCm:        input.ThighCm,
+		SystolicBP:     input.SystolicBP,
+		DiastolicBP:    input.DiastolicBP,
+		Notes:          input.Notes,
+	}
+	if err := s.repo.Create(&measurement); err != nil {
+		return nil, errors.New("gagal menyimpan hasil pengukuran")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityMeasurement, measurement.ID, nil, measurement)
+
+	// Kembalikan dengan nilai turunan (BMI, perubahan) yang sudah dihitung
+	history, err := s.GetHistory(member.ID)
+	if err != nil {
+		return &measurement, nil
+	}
+	for i := range history.Measurements {
+		if history.Measurements[i].ID == measurement.ID {
+			return &history.Measurements[i], nil
+		}
+	}
+	return &measurement, nil
+}
+
+// DeleteMeasurement menghapus hasil asesmen yang salah input (Admin/Staff)
+func (s *MeasurementService) DeleteMeasurement(memberID, measurementID uuid.UUID, actor Actor) error {
+	measurement, err := s.repo.FindByID(measurementID)
+	if err != nil || measurement == nil || measurement.UserID != memberID {
+		return errors.New("hasil pengukuran tidak ditemukan")
+	}
+	if err := s.repo.Delete(measurement.ID); err != nil {
+		return errors.New("gagal menghapus hasil pengukuran")
+	}
+	recordAudit(actor, models.AuditActionDelete, AuditEntityMeasurement, measurement.ID, measurement, nil)
+	return nil
+}
+
+// GetHistory: Deret tren asesmen member beserta BMI dan perubahan sejak asesmen sebelumnya
+func (s *MeasurementService) GetHistory(memberID uuid.UUID) (*MeasurementHistory, error) {
+	measurements, err := s.repo.FindByUserID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	deriveMeasurements(measurements)
+
+	history := &MeasurementHistory{Measurements: measurements}
+	if len(measurements) > 0 {
+		history.Latest = &measurements[len(measurements)-1]
+	}
+	return history, nil
+}
+
+// deriveMeasurements menghitung BMI (memakai tinggi terakhir yang diketahui) dan perubahan
+// setiap nilai terhadap nilai terakhir yang tercatat sebelumnya. `measurements` harus urut kronologis.
+func deriveMeasurements(measurements []models.Measurement) {
+	var height *float64
+	var last models.Measurement
+	var lastAt *time.Time
+
+	for i := range measurements {
+		m := &measurements[i]
+		if m.HeightCm != nil {
+			height = m.HeightCm
+		}
+		if m.WeightKg != nil && height != nil {
+			bmi := round1(*m.WeightKg / math.Pow(*height/100, 2))
+			m.BMI = &bmi
+			m.BMICategory = bmiCategory(bmi)
+		}
+
+		if lastAt != nil {
+			m.Change = &models.MeasurementChange{
+				WeightKg:       delta(m.WeightKg, last.WeightKg),
+				BodyFatPercent: delta(m.BodyFatPercent, last.BodyFatPercent),
+				BMI:            delta(m.BMI, last.BMI),
+				ChestCm:        delta(m.ChestCm, last.ChestCm),
+				WaistCm:        delta(m.WaistCm, last.WaistCm),
+				HipCm:          delta(m.HipCm, last.HipCm),
+				ArmCm:          delta(m.ArmCm, last.ArmCm),
+				ThighCm:        delta(m.ThighCm, last.ThighCm),
+				DaysSinceLast:  int(m.MeasuredAt.Sub(*lastAt).Hours() / 24),
+			}
+		}
+
+		// Simpan nilai terakhir yang diketahui per field
+		last.WeightKg = coalesce(m.WeightKg, last.WeightKg)
+		last.BodyFatPercent = coalesce(m.BodyFatPercent, last.BodyFatPercent)
+		last.BMI = coalesce(m.BMI, last.BMI)
+		last.ChestCm = coalesce(m.ChestCm, last.ChestCm)
+		last.WaistCm = coalesce(m.WaistCm, last.WaistCm)
+		last.HipCm = coalesce(m.HipCm, last.HipCm)
+		last.ArmCm = coalesce(m.ArmCm, last.ArmCm)
+		last.ThighCm = coalesce(m.ThighCm, last.ThighCm)
+		measuredAt := m.MeasuredAt
+		lastAt = &measuredAt
+	}
+}
+
+// bmiCategory: Klasifikasi BMI WHO
+func bmiCategory(bmi float64) string {
+	switch {
+	case bmi < 18.5:
+		return "Kurus"
+	case bmi < 25:
+		return "Normal"
+	case bmi < 30:
+		return "Berat badan berlebih"
+	default:
+		return "Obesitas"
+	}
+}
+
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// delta: current - previous, nil jika salah satunya tidak tercatat
+func delta(current, previous *float64) *float64 {
+	if current == nil || previous == nil {
+		return nil
+	}
+	d := round1(*current - *previous)
+	return &d
+}
+
+func coalesce(value, fallback *float64) *float64 {
+	if value != nil {
+		return value
+	}
+	return fallback
+}
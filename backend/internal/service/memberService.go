@@ -4,20 +4,22 @@ import (
 	"errors"
 	"gym_management/internal/models"
 	"gym_management/internal/repository"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type MemberService struct {
-	repo repository.MemberRepository
+	repo        repository.MemberRepository
+	memberships *MembershipService
 }
 
 func NewMemberService() *MemberService {
-	return &MemberService{repo: repository.NewMemberRepository()}
+	return &MemberService{repo: repository.NewMemberRepository(), memberships: NewMembershipService()}
 }
 
 // createMember (untuk Admin/Staff)
-func (s *MemberService) CreateMember(input models.RegisterInput) (*models.User, error) {
+func (s *MemberService) CreateMember(input models.RegisterInput, actor Actor) (*models.User, error) {
 	if input.Email == "" || input.Password == "" {
 		return nil, errors.New("email dan password wajib diisi")
 	}
@@ -27,22 +29,40 @@ func (s *MemberService) CreateMember(input models.RegisterInput) (*models.User,
 		return nil, errors.New("email sudah terdaftar")
 	}
 
+	var pkg *models.GymPackage
+	if input.PackageID != nil {
+		found, err := s.memberships.findPackage(*input.PackageID)
+		if err != nil {
+			return nil, err
+		}
+		pkg = found
+	}
+
 	hashedPassword, _ := HashPassword(input.Password)
 
+	memberNumber, err := s.repo.NextMemberNumber()
+	if err != nil {
+		return nil, errors.New("gagal membuat nomor member")
+	}
+
+	now := time.Now()
 	member := models.User{
 		ID:           uuid.New(),
 		Name:         input.Name,
 		Email:        input.Email,
 		PasswordHash: hashedPassword,
 		Role:         "member",
+		MemberNumber: &memberNumber,
 		IsActive:     true,
 		PhoneNumber:  input.PhoneNumber,
 		Address:      input.Address,
 		PackageID:    input.PackageID,
+		// Didaftarkan langsung oleh staff sehingga email dianggap sudah diverifikasi
+		EmailVerifiedAt: &now,
 	}
 
-	if err := s.repo.Create(&member); err != nil {
-		return nil, errors.New("gagal menyimpan member ke database")
+	if err := s.memberships.createMember(&member, pkg, now, actor); err != nil {
+		return nil, err
 	}
 	return &member, nil
 }
@@ -53,11 +73,27 @@ func (s *MemberService) GetMembers(search string, isActive *bool) ([]models.User
 }
 
 // UpdateMember
-func (s *MemberService) UpdateMember(id uuid.UUID, input models.RegisterInput) (*models.User, error) {
+func (s *MemberService) UpdateMember(id uuid.UUID, input models.RegisterInput, actor Actor) (*models.User, error) {
 	member, err := s.repo.FindByID(id)
 	if err != nil || member == nil {
 		return nil, errors.New("member tidak ditemukan")
 	}
+	before := *member
+
+	// Ganti paket = langganan lama dibatalkan, langganan baru dimulai hari ini.
+	// packageId null = member berhenti berlangganan (langganan berjalan ikut diakhiri).
+	packageChanged := (member.PackageID == nil) != (input.PackageID == nil) ||
+		(input.PackageID != nil && *member.PackageID != *input.PackageID)
+	var pkg *models.GymPackage
+	if packageChanged && input.PackageID != nil {
+		pkg, err = s.memberships.findPackage(*input.PackageID)
+		if err != nil {
+			return nil, err
+		}
+		member.Package = *pkg
+	} else if input.PackageID == nil {
+		member.Package = models.GymPackage{}
+	}
 
 	// Update fields
 	member.Name = input.Name
@@ -66,13 +102,24 @@ func (s *MemberService) UpdateMember(id uuid.UUID, input models.RegisterInput) (
 	member.PackageID = input.PackageID
 	// isActive harus ditangani oleh input berbeda jika ada, atau tambahkan di struct input
 
-	if err := s.repo.Update(member); err != nil {
-		return nil, errors.New("gagal memperbarui member")
+	if err := s.memberships.updateMember(member, before, packageChanged, pkg, actor); err != nil {
+		return nil, err
 	}
 	return member, nil
 }
 
 // DeleteMember
-func (s *MemberService) DeleteMember(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *MemberService) DeleteMember(id uuid.UUID, actor Actor) error {
+	member, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	revokeAllSessions(id, models.SessionRevokedAdmin)
+	if member != nil {
+		recordAudit(actor, models.AuditActionDelete, AuditEntityMember, id, member, nil)
+	}
+	return nil
 }
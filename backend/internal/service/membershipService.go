@@ -0,0 +1,305 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MembershipService struct {
+	repo          repository.MembershipRepository
+	memberRepo    repository.MemberRepository
+	packages      repository.PackageRepository
+	notifications *NotificationService
+}
+
+func NewMembershipService() *MembershipService {
+	return &MembershipService{
+		repo:          repository.NewMembershipRepository(),
+		memberRepo:    repository.NewMemberRepository(),
+		packages:      repository.NewPackageRepository(),
+		notifications: NewNotificationService(),
+	}
+}
+
+const dateLayout = "02 Jan 2006"
+
+// formatDate menampilkan tanggal dalam zona waktu gym untuk pesan ke pengguna
+func formatDate(t time.Time) string {
+	return t.In(config.Location()).Format(dateLayout)
+}
+
+// membershipEndDate menghitung akhir periode berdasarkan DurationDays paket
+func membershipEndDate(start time.Time, pkg *models.GymPackage) time.Time {
+	return start.AddDate(0, 0, pkg.DurationDays)
+}
+
+// findPackage memastikan paket yang dipilih benar-benar ada
+func (s *MembershipService) findPackage(packageID uint) (*models.GymPackage, error) {
+	pkg, err := s.packages.FindByID(packageID)
+	if err != nil || pkg == nil {
+		return nil, errors.New("paket tidak ditemukan")
+	}
+	return pkg, nil
+}
+
+// createMembership membuat periode langganan baru untuk member mulai dari `start`.
+// repo boleh terikat transaksi; perubahan dicatat ke trail.
+func createMembership(repo repository.MembershipRepository, trail *auditTrail, userID uuid.UUID, pkg *models.GymPackage, start time.Time) (*models.Membership, error) {
+	membership := models.Membership{
+		UserID:    userID,
+		PackageID: pkg.ID,
+		StartDate: start,
+		EndDate:   membershipEndDate(start, pkg),
+		Status:    models.MembershipStatusActive,
+	}
+	if err := repo.Create(&membership); err != nil {
+		return nil, errors.New("gagal menyimpan langganan member")
+	}
+	trail.add(models.AuditActionCreate, AuditEntityMembership, membership.ID, nil, membership)
+	membership.Package = *pkg
+	return &membership, nil
+}
+
+// cancelActiveMemberships membatalkan langganan yang masih berjalan atau terjadwal;
+// periode yang sedang berjalan diakhiri pada `now`.
+func cancelActiveMemberships(repo repository.MembershipRepository, trail *auditTrail, memberID uuid.UUID, now time.Time) error {
+	current, err := repo.FindByUserID(memberID)
+	if err != nil {
+		return errors.New("gagal memeriksa langganan member")
+	}
+	for i := range current {
+		m := &current[i]
+		if m.Status != models.MembershipStatusActive || !m.EndDate.After(now) {
+			continue
+		}
+		before := *m
+		m.Status = models.MembershipStatusCancelled
+		if m.StartDate.Before(now) {
+			m.EndDate = now
+		}
+		if err := repo.Update(m); err != nil {
+			return errors.New("gagal membatalkan langganan lama")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityMembership, m.ID, before, m)
+	}
+	return nil
+}
+
+// createMember menyimpan member baru beserta langganan pertamanya (pkg opsional) dalam satu transaksi
+// (registrasi dan pembuatan member oleh staff), agar tidak tersisa member tanpa langganan.
+func (s *MembershipService) createMember(member *models.User, pkg *models.GymPackage, start time.Time, actor Actor) error {
+	var trail auditTrail
+	err := s.repo.Transaction(func(tx repository.MembershipRepository) error {
+		if err := tx.CreateMember(member); err != nil {
+			return errors.New("gagal menyimpan member ke database")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityMember, member.ID, nil, *member)
+		if pkg == nil {
+			return nil
+		}
+		_, err := createMembership(tx, &trail, member.ID, pkg, start)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	trail.record(actor)
+	return nil
+}
+
+// GetMemberships: Riwayat langganan seorang member (Admin/Staff)
+func (s *MembershipService) GetMemberships(memberID uuid.UUID) ([]models.Membership, error) {
+	return s.repo.FindByUserID(memberID)
+}
+
+// Subscribe membuat langganan baru (perpanjangan atau pembelian paket).
+// Jika StartDate kosong, periode dimulai saat langganan aktif terakhir berakhir,
+// atau sekarang bila member tidak punya langganan yang masih berjalan.
+func (s *MembershipService) Subscribe(memberID uuid.UUID, input models.MembershipInput, actor Actor) (*models.Membership, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	pkg, err := s.findPackage(input.PackageID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if input.StartDate != nil {
+		start = *input.StartDate
+	} else {
+		latest, err := s.repo.FindLatestByUserID(member.ID)
+		if err != nil {
+			return nil, errors.New("gagal memeriksa langganan member")
+		}
+		if latest != nil && latest.EndDate.After(start) {
+			start = latest.EndDate
+		}
+	}
+
+	var membership *models.Membership
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.MembershipRepository) error {
+		created, err := createMembership(tx, &trail, member.ID, pkg, start)
+		if err != nil {
+			return err
+		}
+		membership = created
+
+		before := *member
+		member.PackageID = &pkg.ID
+		member.IsActive = true
+		if err := tx.UpdateMember(member); err != nil {
+			return errors.New("gagal memperbarui member")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityMember, member.ID, before, member)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return membership, nil
+}
+
+// updateMember menyimpan perubahan data member dalam satu transaksi dengan perubahan paketnya.
+// Jika packageChanged, langganan yang masih berjalan/terjadwal dibatalkan lalu periode baru
+// dengan pkg dimulai hari ini; pkg nil berarti member berhenti berlangganan.
+func (s *MembershipService) updateMember(member *models.User, before models.User, packageChanged bool, pkg *models.GymPackage, actor Actor) error {
+	var trail auditTrail
+	err := s.repo.Transaction(func(tx repository.MembershipRepository) error {
+		if packageChanged {
+			now := time.Now()
+			if err := cancelActiveMemberships(tx, &trail, member.ID, now); err != nil {
+				return err
+			}
+			if pkg != nil {
+				if _, err := createMembership(tx, &trail, member.ID, pkg, now); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.UpdateMember(member); err != nil {
+			return errors.New("gagal memperbarui member")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityMember, member.ID, before, member)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	trail.record(actor)
+	return nil
+}
+
+// checkMembership memastikan member memiliki langganan yang berlaku pada waktu `at`
+func (s *MembershipService) checkMembership(userID uuid.UUID, at time.Time) (*models.Membership, error) {
+	current, err := s.repo.FindCurrentByUserID(userID, at)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa langganan member")
+	}
+	if current != nil {
+		return current, nil
+	}
+
+	latest, err := s.repo.FindLatestByUserID(userID)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa langganan member")
+	}
+	if latest == nil {
+		return nil, errors.New("member tidak memiliki paket aktif")
+	}
+	if latest.StartDate.After(at) {
+		return nil, errors.New("langganan member baru berlaku mulai " + formatDate(latest.StartDate))
+	}
+	return nil, errors.New("masa aktif paket member berakhir pada " + formatDate(latest.EndDate))
+}
+
+// ExpireMemberships menandai langganan yang periodenya sudah lewat sebagai expired dan
+// menonaktifkan member yang tidak lagi memiliki langganan berjalan atau yang akan datang.
+// Dipanggil oleh job malam; aman dijalankan berulang kali.
+func (s *MembershipService) ExpireMemberships(now time.Time) (int, error) {
+	ended, err := s.repo.FindEnded(now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range ended {
+		m := &ended[i]
+		m.Status = models.MembershipStatusExpired
+		if err := s.repo.Update(m); err != nil {
+			return expired, err
+		}
+		expired++
+
+		latest, err := s.repo.FindLatestByUserID(m.UserID)
+		if err != nil {
+			return expired, err
+		}
+		if latest != nil && latest.Status == models.MembershipStatusActive && latest.EndDate.After(now) {
+			continue // Sudah diperpanjang
+		}
+
+		member, err := s.memberRepo.FindByID(m.UserID)
+		if err != nil {
+			return expired, err
+		}
+		if member == nil || !member.IsActive {
+			continue
+		}
+		member.IsActive = false
+		if err := s.memberRepo.Update(member); err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// QueueRenewalReminders mengantrekan pengingat perpanjangan untuk langganan yang akan
+// berakhir dalam `daysBefore` hari dan belum diperpanjang. Satu pengingat per langganan.
+func (s *MembershipService) QueueRenewalReminders(now time.Time, daysBefore int) (int, error) {
+	ending, err := s.repo.FindEndingBetween(now, now.AddDate(0, 0, daysBefore))
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for i := range ending {
+		m := &ending[i]
+		if m.User == nil || m.User.Role != "member" {
+			continue
+		}
+
+		membershipID := m.ID
+		notification := models.Notification{
+			UserID:      m.UserID,
+			Type:        models.NotificationTypeRenewalReminder,
+			ReferenceID: &membershipID,
+			Recipient:   m.User.Email,
+			Subject:     "Paket " + m.Package.Name + " Anda akan segera berakhir",
+			Body: fmt.Sprintf(
+				"Halo %s,\n\nPaket %s Anda akan berakhir pada %s. Silakan lakukan perpanjangan di meja resepsionis agar tetap dapat berlatih tanpa jeda.\n",
+				m.User.Name, m.Package.Name, formatDate(m.EndDate),
+			),
+			ScheduledAt: now,
+		}
+
+		ok, err := s.notifications.Queue(&notification)
+		if err != nil {
+			return queued, err
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
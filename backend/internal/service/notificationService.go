@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/mailer"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"time"
+)
+
+type NotificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{repo: repository.NewNotificationRepository()}
+}
+
+// Queue memasukkan notifikasi ke antrean (outbox). Mengembalikan false jika
+// notifikasi dengan Type + ReferenceID yang sama sudah pernah diantrekan.
+func (s *NotificationService) Queue(notification *models.Notification) (bool, error) {
+	if notification.Channel == "" {
+		notification.Channel = models.NotificationChannelEmail
+	}
+	if notification.ScheduledAt.IsZero() {
+		notification.ScheduledAt = time.Now()
+	}
+	notification.Status = models.NotificationStatusPending
+	return s.repo.CreateIfAbsent(notification)
+}
+
+// DispatchPending mengirim notifikasi yang sudah jatuh tempo lewat sender. Kegagalan dicoba lagi
+// dengan jeda bertambah (1, 2, 4, ... menit) hingga NOTIFICATION_MAX_ATTEMPTS, lalu ditandai failed.
+// Mengembalikan jumlah notifikasi yang berhasil dikirim.
+func (s *NotificationService) DispatchPending(now time.Time, sender mailer.Sender) (int, error) {
+	batchSize := config.GetEnvInt("NOTIFICATION_BATCH_SIZE", 100)
+	maxAttempts := config.GetEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5)
+
+	due, err := s.repo.FindDue(now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, n := range due {
+		var sendErr error
+		if n.Channel == models.NotificationChannelEmail {
+			sendErr = sender.Send(mailer.Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+		} else {
+			sendErr = fmt.Errorf("channel %q tidak didukung", n.Channel)
+		}
+
+		if sendErr == nil {
+			if err := s.repo.MarkSent(n.ID, now); err != nil {
+				log.Printf("Gagal menandai notifikasi %s terkirim: %v", n.ID, err)
+			}
+			sent++
+			continue
+		}
+
+		var retryAt *time.Time
+		if n.Attempts+1 < maxAttempts {
+			next := now.Add(time.Minute << min(n.Attempts, 10))
+			retryAt = &next
+		}
+		if err := s.repo.MarkFailed(n.ID, sendErr.Error(), retryAt); err != nil {
+			log.Printf("Gagal mencatat kegagalan notifikasi %s: %v", n.ID, err)
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/repository"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Occupant: Member yang sedang berada di gym
+type Occupant struct {
+	UserID       uuid.UUID `json:"userId"`
+	Name         string    `json:"name"`
+	MemberNumber string    `json:"memberNumber"`
+	CheckInTime  time.Time `json:"checkInTime"`
+}
+
+// Occupancy: Ringkasan isi gym saat ini. Capacity 0 berarti tanpa batas.
+type Occupancy struct {
+	Count     int        `json:"count"`
+	Capacity  int        `json:"capacity"`
+	Available *int       `json:"available"`
+	Occupants []Occupant `json:"occupants"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// maxCapacity: Kapasitas maksimum gym (GYM_MAX_CAPACITY, 0 = tanpa batas)
+func maxCapacity() int {
+	return config.GetEnvInt("GYM_MAX_CAPACITY", 0)
+}
+
+// presentSince: Absensi tanpa Check-Out yang dimulai setelah batas ini dianggap masih di dalam gym.
+// Tidak dipotong pada tengah malam; sesi yang lebih lama akan ditutup job auto Check-Out.
+func presentSince(now time.Time) time.Time {
+	return now.Add(-maxSessionDuration())
+}
+
+// GetOccupancy menghitung siapa saja yang sedang berada di gym (Check-In tanpa Check-Out).
+func GetOccupancy() (*Occupancy, error) {
+	now := time.Now()
+	open, err := attendanceRepo.FindOpen(presentSince(now))
+	if err != nil {
+		return nil, err
+	}
+
+	occupancy := &Occupancy{
+		Count:     len(open),
+		Capacity:  maxCapacity(),
+		Occupants: make([]Occupant, 0, len(open)),
+		UpdatedAt: now,
+	}
+	if occupancy.Capacity > 0 {
+		available := max(occupancy.Capacity-occupancy.Count, 0)
+		occupancy.Available = &available
+	}
+	for _, a := range open {
+		occupant := Occupant{UserID: a.UserID, Name: a.User.Name, CheckInTime: a.CheckInTime}
+		if a.User.MemberNumber != nil {
+			occupant.MemberNumber = *a.User.MemberNumber
+		}
+		occupancy.Occupants = append(occupancy.Occupants, occupant)
+	}
+	return occupancy, nil
+}
+
+// checkCapacity menolak Check-In jika gym sudah penuh. repo harus terikat transaksi yang
+// memegang LockCheckIns agar hitungan tidak berubah sebelum Check-In disimpan.
+func checkCapacity(repo repository.AttendanceRepository, at time.Time) error {
+	capacity := maxCapacity()
+	if capacity <= 0 {
+		return nil
+	}
+	count, err := repo.CountOpen(presentSince(at))
+	if err != nil {
+		return errors.New("gagal memeriksa kapasitas gym")
+	}
+	if count >= int64(capacity) {
+		return fmt.Errorf("gym sedang penuh (kapasitas %d orang)", capacity)
+	}
+	return nil
+}
+
+// --- Live update (Server-Sent Events) ---
+
+// occupancyHub menyebarkan snapshot okupansi ke semua layar yang sedang berlangganan.
+type occupancyHub struct {
+	mu          sync.Mutex
+	subscribers map[chan *Occupancy]struct{}
+}
+
+var occupancyEvents = &occupancyHub{subscribers: make(map[chan *Occupancy]struct{})}
+
+// SubscribeOccupancy mendaftarkan listener baru. Panggil fungsi yang dikembalikan untuk berhenti.
+func SubscribeOccupancy() (<-chan *Occupancy, func()) {
+	ch := make(chan *Occupancy, 1)
+
+	occupancyEvents.mu.Lock()
+	occupancyEvents.subscribers[ch] = struct{}{}
+	occupancyEvents.mu.Unlock()
+
+	return ch, func() {
+		occupancyEvents.mu.Lock()
+		delete(occupancyEvents.subscribers, ch)
+		occupancyEvents.mu.Unlock()
+	}
+}
+
+// publishOccupancy menghitung ulang okupansi dan mengirimkannya ke semua listener.
+// Listener yang lambat hanya menerima snapshot terbaru (snapshot lama dibuang).
+func publishOccupancy() {
+	occupancyEvents.mu.Lock()
+	listeners := len(occupancyEvents.subscribers)
+	occupancyEvents.mu.Unlock()
+	if listeners == 0 {
+		return
+	}
+
+	snapshot, err := GetOccupancy()
+	if err != nil {
+		log.Println("Gagal menghitung okupansi:", err)
+		return
+	}
+
+	occupancyEvents.mu.Lock()
+	defer occupancyEvents.mu.Unlock()
+	for ch := range occupancyEvents.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"gym_management/config"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OpeningHours: Jam operasional harian gym dalam zona waktu gym.
+// Open == Close berarti buka 24 jam.
+type OpeningHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// GetOpeningHours membaca GYM_OPEN_TIME dan GYM_CLOSE_TIME (format HH:MM, default 06:00-22:00).
+// Jam tutup lewat tengah malam tidak didukung dan diperlakukan sebagai buka 24 jam.
+func GetOpeningHours() OpeningHours {
+	hours := OpeningHours{
+		Open:  config.GetEnvClock("GYM_OPEN_TIME", "06:00"),
+		Close: config.GetEnvClock("GYM_CLOSE_TIME", "22:00"),
+	}
+	if hours.Close < hours.Open {
+		log.Println("GYM_CLOSE_TIME lebih awal dari GYM_OPEN_TIME, gym dianggap buka 24 jam")
+		hours.Close = hours.Open
+	}
+	return hours
+}
+
+// Is24Hours: true jika gym tidak pernah tutup
+func (h OpeningHours) Is24Hours() bool {
+	return h.Open == h.Close
+}
+
+// IsOpen: apakah gym buka pada waktu `t`
+func (h OpeningHours) IsOpen(t time.Time) bool {
+	if h.Is24Hours() {
+		return true
+	}
+	sinceMidnight := t.Sub(config.StartOfDay(t))
+	return sinceMidnight >= h.Open && sinceMidnight < h.Close
+}
+
+// ClosingTime: jam tutup pada hari yang memuat `t`
+func (h OpeningHours) ClosingTime(t time.Time) time.Time {
+	return config.StartOfDay(t).Add(h.Close)
+}
+
+// maxSessionDuration: Batas lama satu sesi latihan sebelum dianggap lupa Check-Out
+// (ATTENDANCE_MAX_HOURS, default 12 jam). Berlaku juga untuk gym 24 jam.
+func maxSessionDuration() time.Duration {
+	return time.Duration(config.GetEnvInt("ATTENDANCE_MAX_HOURS", 12)) * time.Hour
+}
+
+// enforceOpeningHours: Tolak Check-In di luar jam operasional (ENFORCE_OPENING_HOURS, default false)
+func enforceOpeningHours() bool {
+	return config.GetEnvBool("ENFORCE_OPENING_HOURS", false)
+}
+
+// checkOpeningHours menolak Check-In di luar jam operasional jika ENFORCE_OPENING_HOURS aktif.
+// Staff (bukan kiosk) boleh melewatinya dengan override; Check-In tetap dicatat dengan peringatan.
+func checkOpeningHours(at time.Time, override bool, actor Actor) ([]string, error) {
+	hours := GetOpeningHours()
+	if !enforceOpeningHours() || hours.IsOpen(at) {
+		return nil, nil
+	}
+	open := config.StartOfDay(at).Add(hours.Open).In(config.Location())
+	closing := hours.ClosingTime(at).In(config.Location())
+	schedule := open.Format("15:04") + " - " + closing.Format("15:04")
+	if !override {
+		return nil, errors.New("gym sedang tutup (jam operasional " + schedule + ")")
+	}
+	if actor.DeviceID != nil || actor.UserID == uuid.Nil {
+		return nil, errors.New("override jam operasional hanya dapat dilakukan oleh staff")
+	}
+	return []string{"Check-In di luar jam operasional (" + schedule + ") atas izin staff"}, nil
+}
+
+// autoCheckOutAt menentukan kapan sebuah sesi yang lupa Check-Out dianggap selesai:
+// jam tutup pada hari Check-In, atau batas lama sesi, mana yang lebih dulu.
+func autoCheckOutAt(checkIn time.Time, hours OpeningHours) time.Time {
+	deadline := checkIn.Add(maxSessionDuration())
+	if !hours.Is24Hours() {
+		closing := hours.ClosingTime(checkIn)
+		if closing.Before(checkIn) {
+			closing = checkIn // Check-In setelah jam tutup (mis. override staff)
+		}
+		if closing.Before(deadline) {
+			deadline = closing
+		}
+	}
+	return deadline
+}
+
+// AutoCheckOut menutup semua absensi yang belum Check-Out setelah jam tutup atau
+// setelah melewati batas lama sesi. Waktu Check-Out diisi dengan batas tersebut dan
+// baris ditandai AutoClosed. Dipanggil oleh job terjadwal; aman dijalankan berulang kali.
+func AutoCheckOut(now time.Time) (int, error) {
+	open, err := attendanceRepo.FindAllOpen()
+	if err != nil {
+		return 0, err
+	}
+
+	hours := GetOpeningHours()
+	closed := 0
+	for i := range open {
+		a := &open[i]
+		checkOutAt := autoCheckOutAt(a.CheckInTime, hours)
+		if checkOutAt.After(now) {
+			continue
+		}
+		a.CheckOutTime = &checkOutAt
+		a.AutoClosed = true
+		if err := attendanceRepo.Update(a); err != nil {
+			return closed, err
+		}
+		closed++
+	}
+
+	if closed > 0 {
+		go publishOccupancy()
+	}
+	return closed, nil
+}
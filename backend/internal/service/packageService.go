@@ -18,25 +18,42 @@ func (s *PackageService) GetPackages() ([]models.GymPackage, error) {
 	return s.repo.FindAll()
 }
 
-func (s *PackageService) CreatePackage(input models.CreatePackageInput) (*models.GymPackage, error) {
+func (s *PackageService) CreatePackage(input models.CreatePackageInput, actor Actor) (*models.GymPackage, error) {
 	pkg := models.GymPackage{
 		Name:         input.Name,
 		Price:        input.Price,
 		DurationDays: input.DurationDays,
 		Benefits:     input.Benefits,
+
+		MinFreezeDays: input.MinFreezeDays,
+		MaxFreezeDays: input.MaxFreezeDays,
+		MaxFreezes:    input.MaxFreezes,
+
+		AllowedDays:      input.AllowedDays,
+		AccessWindows:    input.AccessWindows,
+		MaxVisits:        input.MaxVisits,
+		MaxVisitsPerWeek: input.MaxVisitsPerWeek,
+	}
+	if err := validateFreezeRules(&pkg); err != nil {
+		return nil, err
+	}
+	if err := validateAccessRules(&pkg); err != nil {
+		return nil, err
 	}
 	if err := s.repo.Create(&pkg); err != nil {
 		return nil, errors.New("gagal membuat paket. Nama mungkin sudah ada.")
 	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityPackage, pkg.ID, nil, pkg)
 	return &pkg, nil
 }
 
-func (s *PackageService) UpdatePackage(id uint, input models.UpdatePackageInput) (*models.GymPackage, error) {
+func (s *PackageService) UpdatePackage(id uint, input models.UpdatePackageInput, actor Actor) (*models.GymPackage, error) {
 	// 1. Cari Paket
 	pkg, err := s.repo.FindByID(id)
 	if err != nil || pkg == nil {
 		return nil, errors.New("paket tidak ditemukan")
 	}
+	before := *pkg
 
 	// 2. Update Fields (Cek apakah field di-supply, jika ya, update)
 	if input.Name != "" {
@@ -51,21 +68,67 @@ func (s *PackageService) UpdatePackage(id uint, input models.UpdatePackageInput)
 	// Benefits dapat berupa string kosong jika ingin menghapus benefit
 	pkg.Benefits = input.Benefits
 
+	if input.MinFreezeDays != nil {
+		pkg.MinFreezeDays = *input.MinFreezeDays
+	}
+	if input.MaxFreezeDays != nil {
+		pkg.MaxFreezeDays = *input.MaxFreezeDays
+	}
+	if input.MaxFreezes != nil {
+		pkg.MaxFreezes = *input.MaxFreezes
+	}
+	if err := validateFreezeRules(pkg); err != nil {
+		return nil, err
+	}
+
+	if input.AllowedDays != nil {
+		pkg.AllowedDays = *input.AllowedDays
+	}
+	if input.AccessWindows != nil {
+		pkg.AccessWindows = *input.AccessWindows
+	}
+	if input.MaxVisits != nil {
+		pkg.MaxVisits = *input.MaxVisits
+	}
+	if input.MaxVisitsPerWeek != nil {
+		pkg.MaxVisitsPerWeek = *input.MaxVisitsPerWeek
+	}
+	if err := validateAccessRules(pkg); err != nil {
+		return nil, err
+	}
+
 	// 3. Simpan ke Database
 	if err := s.repo.Update(pkg); err != nil {
 		return nil, errors.New("gagal memperbarui paket")
 	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityPackage, pkg.ID, before, pkg)
 	return pkg, nil
 }
 
 // DeletePackage handles business logic for deleting a gym package.
-func (s *PackageService) DeletePackage(id uint) error {
-	// 1. Cek keberadaan paket (opsional, repo.Delete akan menangani not found)
+func (s *PackageService) DeletePackage(id uint, actor Actor) error {
+	// 1. Cek keberadaan paket (snapshot untuk audit log; repo.Delete akan menangani not found)
+	pkg, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
 	// 2. Lakukan penghapusan
 	if err := s.repo.Delete(id); err != nil {
 		// Logika pengecekan Foreign Key Constraint Error dapat ditambahkan di sini
 		// Jika Gorm gagal karena ada member yang menggunakan paket ini
 		return errors.New("gagal menghapus paket. Mungkin masih ada member yang terikat dengan paket ini")
 	}
+	if pkg != nil {
+		recordAudit(actor, models.AuditActionDelete, AuditEntityPackage, id, pkg, nil)
+	}
+	return nil
+}
+
+// validateFreezeRules memastikan batas minimum cuti tidak melebihi batas maksimum.
+// Nilai 0 pada paket baru akan diisi default database.
+func validateFreezeRules(pkg *models.GymPackage) error {
+	if pkg.MinFreezeDays > 0 && pkg.MaxFreezeDays > 0 && pkg.MinFreezeDays > pkg.MaxFreezeDays {
+		return errors.New("minimal hari cuti tidak boleh melebihi maksimal hari cuti")
+	}
 	return nil
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentService struct {
+	repo           repository.PaymentRepository
+	memberRepo     repository.MemberRepository
+	membershipRepo repository.MembershipRepository
+}
+
+func NewPaymentService() *PaymentService {
+	return &PaymentService{
+		repo:           repository.NewPaymentRepository(),
+		memberRepo:     repository.NewMemberRepository(),
+		membershipRepo: repository.NewMembershipRepository(),
+	}
+}
+
+// RecordPayment mencatat uang yang diterima dari member (Admin/Staff)
+func (s *PaymentService) RecordPayment(input models.PaymentInput, actor Actor) (*models.Payment, error) {
+	return s.recordPayment(input, nil, actor)
+}
+
+// recordPayment menyimpan pembayaran. entry (opsional) adalah entri pelunasan buku tagihan yang
+// disimpan dalam transaksi yang sama sehingga kas dan saldo tagihan tidak pernah tercatat sebagian.
+func (s *PaymentService) recordPayment(input models.PaymentInput, entry *models.AccountEntry, actor Actor) (*models.Payment, error) {
+	member, err := s.memberRepo.FindByID(input.MemberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+
+	// Pembayaran boleh dikaitkan ke langganan, asalkan milik member yang sama
+	if input.MembershipID != nil {
+		membership, err := s.membershipRepo.FindByID(*input.MembershipID)
+		if err != nil || membership == nil || membership.UserID != member.ID {
+			return nil, errors.New("langganan tidak ditemukan untuk member ini")
+		}
+	}
+
+	paidAt := time.Now()
+	if input.PaidAt != nil {
+		paidAt = *input.PaidAt
+	}
+
+	payment := models.Payment{
+		UserID:       member.ID,
+		MembershipID: input.MembershipID,
+		Amount:       input.Amount,
+		Method:       input.Method,
+		Reference:    strings.TrimSpace(input.Reference),
+		Notes:        input.Notes,
+		Status:       models.PaymentStatusPaid,
+		PaidAt:       paidAt,
+		RecordedByID: actor.UserID,
+	}
+
+	var trail auditTrail
+	err = s.repo.Transaction(func(repo repository.PaymentRepository) error {
+		if err := repo.Create(&payment); err != nil {
+			return errors.New("gagal menyimpan pembayaran")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityPayment, payment.ID, nil, payment)
+		if entry == nil {
+			return nil
+		}
+		entry.PaymentID = &payment.ID
+		entry.PostedAt = payment.PaidAt
+		if err := repo.CreateAccountEntry(entry); err != nil {
+			return errors.New("gagal menyimpan pembayaran")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityAccountEntry, entry.ID, nil, *entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.repo.FindByID(payment.ID)
+}
+
+// VoidPayment membatalkan pembayaran. Baris tidak dihapus agar jejak kas tetap utuh.
+// Pembatalan dan pengembalian saldo tagihan disimpan dalam satu transaksi.
+func (s *PaymentService) VoidPayment(id uuid.UUID, reason string, actor Actor) (*models.Payment, error) {
+	var trail auditTrail
+	err := s.repo.Transaction(func(repo repository.PaymentRepository) error {
+		payment, err := repo.LockByID(id)
+		if err != nil || payment == nil {
+			return errors.New("pembayaran tidak ditemukan")
+		}
+		if payment.Status == models.PaymentStatusVoid {
+			return errors.New("pembayaran sudah dibatalkan")
+		}
+
+		before := *payment
+		now := time.Now()
+		payment.Status = models.PaymentStatusVoid
+		payment.VoidedAt = &now
+		payment.VoidedByID = &actor.UserID
+		payment.VoidReason = reason
+
+		if err := repo.Update(payment); err != nil {
+			return errors.New("gagal membatalkan pembayaran")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityPayment, payment.ID, before, *payment)
+
+		// Pembayaran pelunasan tagihan: saldo terutang member dikembalikan
+		if err := reversePaymentEntries(repo, &trail, payment, actor); err != nil {
+			return errors.New("gagal membatalkan pembayaran")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.repo.FindByID(id)
+}
+
+// GetPayments: Daftar pembayaran dengan filter member, metode, status dan rentang tanggal
+func (s *PaymentService) GetPayments(memberIDStr, method, status, dateFromStr, dateToStr string) ([]models.Payment, error) {
+	filter := repository.PaymentFilter{
+		Method:   method,
+		Status:   status,
+		DateFrom: parseDateParam(dateFromStr, false),
+		DateTo:   parseDateParam(dateToStr, true),
+	}
+	if memberIDStr != "" {
+		if id, err := uuid.Parse(memberIDStr); err == nil {
+			filter.UserID = &id
+		}
+	}
+	return s.repo.FindAll(filter)
+}
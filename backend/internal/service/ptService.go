@@ -0,0 +1,525 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PTService struct {
+	repo          repository.PTRepository
+	userRepo      repository.AuthRepository
+	memberRepo    repository.MemberRepository
+	notifications *NotificationService
+}
+
+func NewPTService() *PTService {
+	return &PTService{
+		repo:          repository.NewPTRepository(),
+		userRepo:      repository.NewAuthRepository(),
+		memberRepo:    repository.NewMemberRepository(),
+		notifications: NewNotificationService(),
+	}
+}
+
+// findTrainer memastikan trainer adalah staff/admin aktif yang ditandai sebagai trainer
+func (s *PTService) findTrainer(id uuid.UUID) (*models.User, error) {
+	trainer, err := s.userRepo.FindByID(id)
+	if err != nil || trainer == nil || !models.IsStaffRole(trainer.Role) || !trainer.IsTrainer {
+		return nil, errors.New("trainer tidak ditemukan")
+	}
+	if !trainer.IsActive {
+		return nil, errors.New("trainer tidak aktif")
+	}
+	return trainer, nil
+}
+
+// --- Kredit Sesi ---
+
+// GetCredits: Paket sesi PT milik member beserta jumlah sesi yang sedang dibooking
+func (s *PTService) GetCredits(memberID uuid.UUID) ([]models.PTCredit, error) {
+	credits, err := s.repo.FindCreditsByUserID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(credits))
+	for i := range credits {
+		ids[i] = credits[i].ID
+	}
+	reserved, err := s.repo.CountReservedByCreditIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range credits {
+		credits[i].ReservedSessions = reserved[credits[i].ID]
+	}
+	return credits, nil
+}
+
+// AddCredit menambahkan paket sesi PT yang dibeli member (Admin/Staff)
+func (s *PTService) AddCredit(memberID uuid.UUID, input models.PTCreditInput, actor Actor) (*models.PTCredit, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+	if input.TrainerID != nil {
+		if _, err := s.findTrainer(*input.TrainerID); err != nil {
+			return nil, err
+		}
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("tanggal kedaluwarsa harus di masa depan")
+	}
+
+	credit := models.PTCredit{
+		UserID:        member.ID,
+		TrainerID:     input.TrainerID,
+		Name:          input.Name,
+		TotalSessions: input.TotalSessions,
+		Price:         roundMoney(input.Price),
+		PaymentID:     input.PaymentID,
+		ExpiresAt:     input.ExpiresAt,
+		CreatedByID:   actor.UserID,
+	}
+	if err := s.repo.CreateCredit(&credit); err != nil {
+		return nil, errors.New("gagal menyimpan paket sesi PT")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityPTCredit, credit.ID, nil, credit)
+	return &credit, nil
+}
+
+// creditUsable: Kredit bisa dipakai untuk sesi dengan trainer & waktu mulai tersebut
+func creditUsable(credit *models.PTCredit, reserved int, trainerID uuid.UUID, startTime time.Time) bool {
+	if credit.TrainerID != nil && *credit.TrainerID != trainerID {
+		return false
+	}
+	if credit.ExpiresAt != nil && !credit.ExpiresAt.After(startTime) {
+		return false
+	}
+	return credit.TotalSessions-credit.UsedSessions-reserved > 0
+}
+
+// --- Slot Trainer ---
+
+// CreateSlot membuka slot ketersediaan. Tanpa pt.manage_all hanya untuk dirinya sendiri.
+func (s *PTService) CreateSlot(input models.TrainerSlotInput, actor Actor) (*models.TrainerSlot, error) {
+	trainerID := actor.UserID
+	if input.TrainerID != nil && *input.TrainerID != actor.UserID {
+		if !canManageAllTrainers(actor) {
+			return nil, errors.New("staff hanya bisa membuka slot untuk dirinya sendiri")
+		}
+		trainerID = *input.TrainerID
+	}
+	trainer, err := s.findTrainer(trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !input.EndTime.After(input.StartTime) {
+		return nil, errors.New("waktu selesai harus setelah waktu mulai")
+	}
+	if !input.StartTime.After(time.Now()) {
+		return nil, errors.New("slot harus di masa depan")
+	}
+	overlap, err := s.repo.HasOverlappingSlot(trainer.ID, input.StartTime, input.EndTime)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa jadwal trainer")
+	}
+	if overlap {
+		return nil, errors.New("slot bentrok dengan slot trainer yang sudah ada")
+	}
+
+	slot := models.TrainerSlot{
+		TrainerID: trainer.ID,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Status:    models.TrainerSlotStatusOpen,
+	}
+	if err := s.repo.CreateSlot(&slot); err != nil {
+		return nil, errors.New("gagal menyimpan slot")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityTrainerSlot, slot.ID, nil, slot)
+	slot.Trainer = *trainer
+	return &slot, nil
+}
+
+// GetSlots: Slot trainer dengan filter opsional. Tanpa date_from, hanya slot mulai hari ini.
+// openOnly dipakai untuk member yang mencari jadwal kosong.
+func (s *PTService) GetSlots(trainerIDStr, dateFromStr, dateToStr string, openOnly bool) ([]models.TrainerSlot, error) {
+	from := parseDateParam(dateFromStr, false)
+	if from == nil {
+		today := config.StartOfDay(time.Now())
+		from = &today
+	}
+	var trainerID *uuid.UUID
+	if trainerIDStr != "" {
+		if id, err := uuid.Parse(trainerIDStr); err == nil {
+			trainerID = &id
+		}
+	}
+
+	status := ""
+	if openOnly {
+		status = models.TrainerSlotStatusOpen
+		now := time.Now()
+		if from.Before(now) {
+			from = &now
+		}
+	}
+	return s.repo.FindSlots(trainerID, from, parseDateParam(dateToStr, true), status)
+}
+
+// CancelSlot menutup slot. Jika slot sudah dibooking, janji member ikut dibatalkan dan member diberi tahu.
+func (s *PTService) CancelSlot(slotID uuid.UUID, actor Actor) error {
+	slot, err := s.repo.FindSlotByID(slotID)
+	if err != nil || slot == nil {
+		return errors.New("slot tidak ditemukan")
+	}
+	if slot.TrainerID != actor.UserID && !canManageAllTrainers(actor) {
+		return errors.New("slot tidak ditemukan")
+	}
+
+	var cancelled *models.PTAppointment
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.PTRepository) error {
+		locked, err := tx.LockSlotByID(slotID)
+		if err != nil || locked == nil {
+			return errors.New("slot tidak ditemukan")
+		}
+		if locked.Status == models.TrainerSlotStatusCancelled {
+			return errors.New("slot sudah ditutup")
+		}
+		if !locked.StartTime.After(time.Now()) {
+			return errors.New("slot yang sudah dimulai tidak bisa ditutup")
+		}
+
+		if locked.Status == models.TrainerSlotStatusBooked {
+			appointment, err := tx.FindBookedAppointmentBySlotID(locked.ID)
+			if err != nil {
+				return errors.New("gagal memeriksa janji pada slot ini")
+			}
+			if appointment != nil {
+				if err := cancelAppointment(tx, appointment, actor.UserID, "Slot ditutup oleh trainer", &trail); err != nil {
+					return err
+				}
+				cancelled = appointment
+			}
+		}
+
+		before := *locked
+		locked.Status = models.TrainerSlotStatusCancelled
+		if err := tx.UpdateSlot(locked); err != nil {
+			return errors.New("gagal menutup slot")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityTrainerSlot, locked.ID, before, locked)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	trail.record(actor)
+
+	if cancelled != nil {
+		s.notifyCancelled(cancelled, cancelled.UserID)
+	}
+	return nil
+}
+
+// --- Janji Personal Training ---
+
+// BookSlot membooking slot trainer untuk member memakai kredit yang paling cepat kedaluwarsa.
+// actor adalah member sendiri atau staff yang membookingkan.
+func (s *PTService) BookSlot(slotID, memberID uuid.UUID, notes string, actor Actor) (*models.PTAppointment, error) {
+	member, err := s.memberRepo.FindByID(memberID)
+	if err != nil || member == nil {
+		return nil, errors.New("member tidak ditemukan")
+	}
+	if !member.IsActive {
+		return nil, errors.New("member tidak aktif")
+	}
+
+	var appointment *models.PTAppointment
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.PTRepository) error {
+		slot, err := tx.LockSlotByID(slotID)
+		if err != nil || slot == nil {
+			return errors.New("slot tidak ditemukan")
+		}
+		if slot.Status != models.TrainerSlotStatusOpen {
+			return errors.New("slot sudah tidak tersedia")
+		}
+		if !slot.StartTime.After(time.Now()) {
+			return errors.New("slot sudah dimulai")
+		}
+
+		credits, err := tx.LockCreditsByUserID(member.ID)
+		if err != nil {
+			return errors.New("gagal memeriksa kredit sesi PT")
+		}
+		ids := make([]uuid.UUID, len(credits))
+		for i := range credits {
+			ids[i] = credits[i].ID
+		}
+		reserved, err := tx.CountReservedByCreditIDs(ids)
+		if err != nil {
+			return errors.New("gagal memeriksa kredit sesi PT")
+		}
+
+		// Urutan kredit: kedaluwarsa paling dekat lebih dulu
+		var credit *models.PTCredit
+		for i := range credits {
+			if creditUsable(&credits[i], reserved[credits[i].ID], slot.TrainerID, slot.StartTime) {
+				credit = &credits[i]
+				break
+			}
+		}
+		if credit == nil {
+			return errors.New("member tidak memiliki sisa kredit sesi PT yang berlaku untuk trainer ini")
+		}
+
+		appointment = &models.PTAppointment{
+			SlotID:     slot.ID,
+			TrainerID:  slot.TrainerID,
+			UserID:     member.ID,
+			CreditID:   credit.ID,
+			StartTime:  slot.StartTime,
+			EndTime:    slot.EndTime,
+			Status:     models.PTAppointmentStatusBooked,
+			BookedByID: actor.UserID,
+			Notes:      notes,
+		}
+		if err := tx.CreateAppointment(appointment); err != nil {
+			return errors.New("gagal menyimpan janji sesi PT")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityPTAppointment, appointment.ID, nil, appointment)
+
+		before := *slot
+		slot.Status = models.TrainerSlotStatusBooked
+		if err := tx.UpdateSlot(slot); err != nil {
+			return errors.New("gagal memperbarui slot")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityTrainerSlot, slot.ID, before, slot)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.repo.FindAppointmentByID(appointment.ID)
+}
+
+// canManageAllTrainers: Actor boleh mengelola slot & janji PT trainer lain (pt.manage_all)
+func canManageAllTrainers(actor Actor) bool {
+	ok, err := HasPermission(actor.Role, models.PermPTManageAll)
+	return err == nil && ok
+}
+
+// canManageAppointment: Trainer janji tersebut, atau pemegang pt.manage_all
+func canManageAppointment(appointment *models.PTAppointment, actor Actor) bool {
+	return appointment.TrainerID == actor.UserID || canManageAllTrainers(actor)
+}
+
+// canAccessAppointment: Member pemilik janji, ditambah siapa pun yang boleh mengelolanya
+func canAccessAppointment(appointment *models.PTAppointment, actor Actor) bool {
+	return appointment.UserID == actor.UserID || canManageAppointment(appointment, actor)
+}
+
+// CancelAppointment membatalkan janji sebelum sesi dimulai, dari sisi member maupun trainer.
+// Kredit tidak terpotong dan slot dibuka kembali; pihak lain diberi tahu.
+func (s *PTService) CancelAppointment(appointmentID uuid.UUID, reason string, actor Actor) (*models.PTAppointment, error) {
+	appointment, err := s.repo.FindAppointmentByID(appointmentID)
+	if err != nil || appointment == nil || !canAccessAppointment(appointment, actor) {
+		return nil, errors.New("janji sesi PT tidak ditemukan")
+	}
+
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.PTRepository) error {
+		// Kunci slot lebih dulu (urutan sama dengan BookSlot) agar tidak deadlock
+		slot, err := tx.LockSlotByID(appointment.SlotID)
+		if err != nil || slot == nil {
+			return errors.New("slot tidak ditemukan")
+		}
+		current, err := tx.LockAppointmentByID(appointmentID)
+		if err != nil || current == nil {
+			return errors.New("janji sesi PT tidak ditemukan")
+		}
+		if current.Status != models.PTAppointmentStatusBooked {
+			return errors.New("janji sesi PT sudah tidak aktif")
+		}
+		if !current.StartTime.After(time.Now()) {
+			return errors.New("janji tidak bisa dibatalkan setelah sesi dimulai")
+		}
+
+		if err := cancelAppointment(tx, current, actor.UserID, reason, &trail); err != nil {
+			return err
+		}
+		if slot.Status == models.TrainerSlotStatusBooked {
+			before := *slot
+			slot.Status = models.TrainerSlotStatusOpen
+			if err := tx.UpdateSlot(slot); err != nil {
+				return errors.New("gagal membuka kembali slot")
+			}
+			trail.add(models.AuditActionUpdate, AuditEntityTrainerSlot, slot.ID, before, slot)
+		}
+		appointment.Status = current.Status
+		appointment.CancelledAt = current.CancelledAt
+		appointment.CancelledByID = current.CancelledByID
+		appointment.CancelReason = current.CancelReason
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+
+	// Beri tahu pihak yang tidak membatalkan
+	recipient := appointment.UserID
+	if actor.UserID == appointment.UserID {
+		recipient = appointment.TrainerID
+	}
+	s.notifyCancelled(appointment, recipient)
+	return appointment, nil
+}
+
+// cancelAppointment menandai janji batal. Dipanggil di dalam transaksi.
+func cancelAppointment(tx repository.PTRepository, appointment *models.PTAppointment, cancelledBy uuid.UUID, reason string, trail *auditTrail) error {
+	before := *appointment
+	now := time.Now()
+	appointment.Status = models.PTAppointmentStatusCancelled
+	appointment.CancelledAt = &now
+	appointment.CancelledByID = &cancelledBy
+	appointment.CancelReason = reason
+	if err := tx.UpdateAppointment(appointment); err != nil {
+		return errors.New("gagal membatalkan janji sesi PT")
+	}
+	trail.add(models.AuditActionUpdate, AuditEntityPTAppointment, appointment.ID, before, appointment)
+	return nil
+}
+
+// notifyCancelled mengantrekan email pembatalan janji ke member atau trainer
+func (s *PTService) notifyCancelled(appointment *models.PTAppointment, recipientID uuid.UUID) {
+	recipient, err := s.userRepo.FindByID(recipientID)
+	if err != nil || recipient == nil {
+		return
+	}
+	startAt := appointment.StartTime.In(config.Location()).Format("02 Jan 2006 15:04")
+	reason := appointment.CancelReason
+	if reason == "" {
+		reason = "-"
+	}
+
+	appointmentID := appointment.ID
+	notification := models.Notification{
+		UserID:      recipient.ID,
+		Type:        models.NotificationTypePTCancelled,
+		ReferenceID: &appointmentID,
+		Recipient:   recipient.Email,
+		Subject:     "Sesi personal training " + startAt + " dibatalkan",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nSesi personal training pada %s telah dibatalkan.\nAlasan: %s\n",
+			recipient.Name, startAt, reason,
+		),
+	}
+	if _, err := s.notifications.Queue(&notification); err != nil {
+		log.Println("Gagal mengantrekan notifikasi pembatalan sesi PT:", err)
+	}
+}
+
+// CompleteAppointment: Trainer (atau pemegang pt.manage_all) menandai sesi selesai/tidak hadir; satu kredit dipotong.
+// Staff yang memberikan sesi dicatat untuk perhitungan komisi.
+func (s *PTService) CompleteAppointment(appointmentID uuid.UUID, input models.CompletePTAppointmentInput, actor Actor) (*models.PTAppointment, error) {
+	appointment, err := s.repo.FindAppointmentByID(appointmentID)
+	if err != nil || appointment == nil || !canManageAppointment(appointment, actor) {
+		return nil, errors.New("janji sesi PT tidak ditemukan")
+	}
+	deliveredBy := appointment.TrainerID
+	if input.DeliveredByID != nil && *input.DeliveredByID != deliveredBy {
+		substitute, err := s.findTrainer(*input.DeliveredByID)
+		if err != nil {
+			return nil, err
+		}
+		deliveredBy = substitute.ID
+	}
+
+	var trail auditTrail
+	err = s.repo.Transaction(func(tx repository.PTRepository) error {
+		current, err := tx.LockAppointmentByID(appointmentID)
+		if err != nil || current == nil {
+			return errors.New("janji sesi PT tidak ditemukan")
+		}
+		if current.Status != models.PTAppointmentStatusBooked {
+			return errors.New("janji sesi PT sudah tidak aktif")
+		}
+		now := time.Now()
+		if current.StartTime.After(now) {
+			return errors.New("sesi belum dimulai")
+		}
+
+		credit, err := tx.LockCreditByID(current.CreditID)
+		if err != nil || credit == nil {
+			return errors.New("kredit sesi PT tidak ditemukan")
+		}
+		if credit.UsedSessions >= credit.TotalSessions {
+			return errors.New("kredit sesi PT member sudah habis")
+		}
+		creditBefore := *credit
+		credit.UsedSessions++
+		if err := tx.UpdateCredit(credit); err != nil {
+			return errors.New("gagal memotong kredit sesi PT")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityPTCredit, credit.ID, creditBefore, credit)
+
+		before := *current
+		current.Status = models.PTAppointmentStatusCompleted
+		if input.NoShow {
+			current.Status = models.PTAppointmentStatusNoShow
+		}
+		current.CompletedAt = &now
+		current.DeliveredByID = &deliveredBy
+		if input.Notes != "" {
+			current.Notes = input.Notes
+		}
+		if err := tx.UpdateAppointment(current); err != nil {
+			return errors.New("gagal menyimpan status sesi PT")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityPTAppointment, current.ID, before, current)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.repo.FindAppointmentByID(appointmentID)
+}
+
+// GetAppointments: Daftar janji (Admin/Staff)
+// Query: date_from, date_to, trainer_id, member_id, status
+func (s *PTService) GetAppointments(dateFromStr, dateToStr, trainerIDStr, memberIDStr, status string) ([]models.PTAppointment, error) {
+	filter := repository.PTAppointmentFilter{
+		From:   parseDateParam(dateFromStr, false),
+		To:     parseDateParam(dateToStr, true),
+		Status: status,
+	}
+	if trainerIDStr != "" {
+		if id, err := uuid.Parse(trainerIDStr); err == nil {
+			filter.TrainerID = &id
+		}
+	}
+	if memberIDStr != "" {
+		if id, err := uuid.Parse(memberIDStr); err == nil {
+			filter.UserID = &id
+		}
+	}
+	return s.repo.FindAppointments(filter)
+}
+
+// GetMyAppointments: Janji PT milik member yang sedang login
+func (s *PTService) GetMyAppointments(userID uuid.UUID) ([]models.PTAppointment, error) {
+	return s.repo.FindAppointments(repository.PTAppointmentFilter{UserID: &userID})
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// permissionCatalog: Seluruh permission yang dikenal aplikasi. Disinkronkan ke tabel permissions saat start.
+var permissionCatalog = []models.Permission{
+	{Code: models.PermMembersRead, Description: "Melihat data member, langganan, freeze & kartu"},
+	{Code: models.PermMembersCreate, Description: "Mendaftarkan member baru"},
+	{Code: models.PermMembersUpdate, Description: "Mengubah data member"},
+	{Code: models.PermMembersDelete, Description: "Menghapus member"},
+	{Code: models.PermMembershipsManage, Description: "Membuat langganan, freeze & unfreeze"},
+	{Code: models.PermCardsManage, Description: "Menerbitkan & memblokir kartu akses"},
+	{Code: models.PermMeasurementsRead, Description: "Melihat hasil asesmen fisik member"},
+	{Code: models.PermMeasurementsManage, Description: "Mencatat & menghapus hasil asesmen fisik"},
+	{Code: models.PermAttendanceRead, Description: "Melihat riwayat absensi & okupansi"},
+	{Code: models.PermAttendanceCheckIn, Description: "Check-In & Check-Out member"},
+	{Code: models.PermPaymentsRead, Description: "Melihat transaksi pembayaran"},
+	{Code: models.PermPaymentsCreate, Description: "Mencatat pembayaran"},
+	{Code: models.PermPaymentsVoid, Description: "Membatalkan pembayaran"},
+	{Code: models.PermAccountsRead, Description: "Melihat tagihan member"},
+	{Code: models.PermAccountsManage, Description: "Memposting tagihan & pelunasan"},
+	{Code: models.PermPackagesRead, Description: "Melihat daftar paket"},
+	{Code: models.PermPackagesManage, Description: "Membuat, mengubah & menghapus paket"},
+	{Code: models.PermClassesRead, Description: "Melihat jenis & jadwal kelas"},
+	{Code: models.PermClassTypesManage, Description: "Mengelola jenis kelas"},
+	{Code: models.PermClassSessionsManage, Description: "Mengelola jadwal kelas, jadwal berulang & peserta"},
+	{Code: models.PermClassesBook, Description: "Membooking kelas untuk diri sendiri"},
+	{Code: models.PermPTRead, Description: "Melihat trainer & slot personal training"},
+	{Code: models.PermPTCreditsManage, Description: "Mengelola kredit sesi PT member"},
+	{Code: models.PermPTSlotsManage, Description: "Membuka & menutup slot trainer"},
+	{Code: models.PermPTAppointmentsManage, Description: "Mengelola & menyelesaikan janji sesi PT"},
+	{Code: models.PermPTAppointmentsCancel, Description: "Membatalkan janji sesi PT"},
+	{Code: models.PermPTBook, Description: "Membooking sesi PT untuk diri sendiri"},
+	{Code: models.PermPTManageAll, Description: "Mengelola slot & janji PT trainer lain (tanpa ini hanya milik sendiri)"},
+	{Code: models.PermSelfPortal, Description: "Portal member (riwayat, kartu, tagihan sendiri)"},
+	{Code: models.PermStaffRead, Description: "Melihat daftar staff"},
+	{Code: models.PermStaffManage, Description: "Mengelola akun staff & role-nya"},
+	{Code: models.PermRolesManage, Description: "Mengelola role & permission"},
+	{Code: models.PermDashboardRead, Description: "Melihat statistik dashboard"},
+	{Code: models.PermCommissionsManage, Description: "Mengelola aturan komisi & laporan komisi"},
+	{Code: models.PermDevicesManage, Description: "Mengelola perangkat kiosk"},
+	{Code: models.PermShiftsRead, Description: "Melihat roster shift"},
+	{Code: models.PermShiftsManage, Description: "Menyusun shift, koreksi clock-in & laporan jam kerja"},
+	{Code: models.PermTimeClockUse, Description: "Clock-in & clock-out diri sendiri"},
+	{Code: models.PermAuditRead, Description: "Melihat audit log perubahan data"},
+	{Code: models.PermLoginLockoutsManage, Description: "Melihat & membuka blokir login"},
+	{Code: models.PermSessionsManage, Description: "Mengeluarkan member dari semua perangkat"},
+}
+
+// defaultRolePermissions: Pemetaan awal role lama (sebelum RBAC) ke permission.
+// Admin tidak tercantum karena selalu mendapat seluruh katalog.
+var defaultRolePermissions = map[string][]string{
+	models.RoleStaff: {
+		models.PermMembersRead, models.PermMembersCreate, models.PermMembersUpdate,
+		models.PermMembershipsManage, models.PermCardsManage,
+		models.PermMeasurementsRead, models.PermMeasurementsManage,
+		models.PermAttendanceRead, models.PermAttendanceCheckIn,
+		models.PermPaymentsRead, models.PermPaymentsCreate, models.PermPaymentsVoid,
+		models.PermAccountsRead, models.PermAccountsManage,
+		models.PermPackagesRead, models.PermClassesRead, models.PermClassSessionsManage,
+		models.PermPTRead, models.PermPTCreditsManage, models.PermPTSlotsManage,
+		models.PermPTAppointmentsManage, models.PermPTAppointmentsCancel,
+		models.PermStaffRead, models.PermShiftsRead, models.PermTimeClockUse,
+	},
+	models.RoleMember: {
+		models.PermPackagesRead, models.PermClassesRead, models.PermClassesBook,
+		models.PermPTRead, models.PermPTBook, models.PermPTAppointmentsCancel,
+		models.PermSelfPortal,
+	},
+}
+
+var systemRoleDescriptions = map[string]string{
+	models.RoleAdmin:  "Administrator (seluruh akses)",
+	models.RoleStaff:  "Staff / resepsionis",
+	models.RoleMember: "Member gym",
+}
+
+// devicePermissions: Kiosk bukan user sehingga tidak punya baris role; aksesnya tetap
+var devicePermissions = []string{models.PermAttendanceCheckIn}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// permissionCacheTTL: Perubahan role di replika lain paling lambat terlihat setelah selang ini
+const permissionCacheTTL = 30 * time.Second
+
+var (
+	permissionCacheMu       sync.RWMutex
+	permissionCache         map[string]map[string]bool
+	twoFactorRoles          map[string]bool // Role yang mewajibkan 2FA, dimuat bersama permissionCache
+	permissionCacheLoadedAt time.Time
+)
+
+type RoleService struct {
+	repo repository.RoleRepository
+}
+
+func NewRoleService() *RoleService {
+	return &RoleService{repo: repository.NewRoleRepository()}
+}
+
+// SeedDefaults menyinkronkan katalog permission, membuat role sistem yang belum ada dengan
+// permission default, dan memastikan admin memiliki seluruh permission.
+func (s *RoleService) SeedDefaults() error {
+	if err := s.repo.UpsertPermissions(permissionCatalog); err != nil {
+		return err
+	}
+
+	for _, name := range []string{models.RoleAdmin, models.RoleStaff, models.RoleMember} {
+		role, err := s.repo.FindByName(name)
+		if err != nil {
+			return err
+		}
+
+		codes := defaultRolePermissions[name]
+		if name == models.RoleAdmin {
+			codes = nil
+			for _, p := range permissionCatalog {
+				codes = append(codes, p.Code)
+			}
+		}
+
+		if role == nil {
+			role = &models.Role{Name: name, Description: systemRoleDescriptions[name], IsSystem: true, Permissions: toPermissions(codes)}
+			if err := s.repo.Create(role); err != nil {
+				return err
+			}
+			log.Printf("Role '%s' dibuat dengan %d permission.", name, len(codes))
+			continue
+		}
+		// Role yang sudah ada tidak ditimpa (bisa sudah diubah admin), kecuali admin
+		if name == models.RoleAdmin {
+			role.Permissions = toPermissions(codes)
+			if err := s.repo.Update(role); err != nil {
+				return err
+			}
+		}
+	}
+
+	invalidatePermissionCache()
+	return nil
+}
+
+func toPermissions(codes []string) []models.Permission {
+	permissions := make([]models.Permission, len(codes))
+	for i, code := range codes {
+		permissions[i] = models.Permission{Code: code}
+	}
+	return permissions
+}
+
+// validatePermissions memastikan seluruh kode ada di katalog (tanpa duplikat)
+func validatePermissions(codes []string) ([]models.Permission, error) {
+	known := map[string]bool{}
+	for _, p := range permissionCatalog {
+		known[p.Code] = true
+	}
+
+	seen := map[string]bool{}
+	var unique []string
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if !known[code] {
+			return nil, fmt.Errorf("permission '%s' tidak dikenal", code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			unique = append(unique, code)
+		}
+	}
+	return toPermissions(unique), nil
+}
+
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	return s.repo.FindAll()
+}
+
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.repo.FindAllPermissions()
+}
+
+// CreateRole membuat role karyawan baru (mis. trainer, finance)
+func (s *RoleService) CreateRole(input models.RoleInput, actor Actor) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("nama role hanya boleh huruf kecil, angka, '-' atau '_' (2-50 karakter)")
+	}
+	if name == models.RoleDevice {
+		return nil, errors.New("nama role sudah dipakai sistem")
+	}
+	existing, err := s.repo.FindByName(name)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa role")
+	}
+	if existing != nil {
+		return nil, errors.New("role sudah ada")
+	}
+
+	permissions, err := validatePermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(actor, permissions); err != nil {
+		return nil, err
+	}
+	role := models.Role{Name: name, Description: input.Description, Permissions: permissions}
+	if err := s.repo.Create(&role); err != nil {
+		return nil, errors.New("gagal menyimpan role")
+	}
+	invalidatePermissionCache()
+	recordAudit(actor, models.AuditActionCreate, AuditEntityRole, role.Name, nil, role)
+	return s.repo.FindByName(name)
+}
+
+// UpdateRole mengganti deskripsi & permission role. Role admin tidak bisa diubah.
+func (s *RoleService) UpdateRole(name string, input models.RoleInput, actor Actor) (*models.Role, error) {
+	role, err := s.repo.FindByName(name)
+	if err != nil || role == nil {
+		return nil, errors.New("role tidak ditemukan")
+	}
+	if role.Name == models.RoleAdmin {
+		return nil, errors.New("role admin selalu memiliki seluruh permission dan tidak bisa diubah")
+	}
+
+	permissions, err := validatePermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(actor, permissions); err != nil {
+		return nil, err
+	}
+	before := *role
+	if input.Description != "" {
+		role.Description = input.Description
+	}
+	role.Permissions = permissions
+	if err := s.repo.Update(role); err != nil {
+		return nil, errors.New("gagal memperbarui role")
+	}
+	invalidatePermissionCache()
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityRole, role.Name, before, role)
+	return s.repo.FindByName(name)
+}
+
+// SetTwoFactorRequired mewajibkan (atau tidak) 2FA bagi user dengan role ini. Hanya untuk role
+// karyawan; termasuk admin, yang permission-nya sendiri tidak bisa diubah.
+func (s *RoleService) SetTwoFactorRequired(name string, required bool, actor Actor) (*models.Role, error) {
+	role, err := s.repo.FindByName(name)
+	if err != nil || role == nil {
+		return nil, errors.New("role tidak ditemukan")
+	}
+	if !models.IsStaffRole(role.Name) {
+		return nil, errors.New("kewajiban 2FA hanya bisa diatur untuk role karyawan")
+	}
+
+	before := *role
+	if err := s.repo.SetRequireTwoFactor(role.Name, required); err != nil {
+		return nil, errors.New("gagal memperbarui role")
+	}
+	role.RequireTwoFactor = required
+	invalidatePermissionCache()
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityRole, role.Name, before, role)
+	return role, nil
+}
+
+// DeleteRole menghapus role buatan admin yang tidak lagi dipakai user mana pun
+func (s *RoleService) DeleteRole(name string, actor Actor) error {
+	role, err := s.repo.FindByName(name)
+	if err != nil || role == nil {
+		return errors.New("role tidak ditemukan")
+	}
+	if role.IsSystem {
+		return errors.New("role sistem tidak bisa dihapus")
+	}
+	users, err := s.repo.CountUsers(name)
+	if err != nil {
+		return errors.New("gagal memeriksa pengguna role")
+	}
+	if users > 0 {
+		return fmt.Errorf("role masih dipakai %d user", users)
+	}
+	if err := s.repo.Delete(name); err != nil {
+		return errors.New("gagal menghapus role")
+	}
+	invalidatePermissionCache()
+	recordAudit(actor, models.AuditActionDelete, AuditEntityRole, role.Name, role, nil)
+	return nil
+}
+
+// --- Pemeriksaan Permission ---
+
+func invalidatePermissionCache() {
+	permissionCacheMu.Lock()
+	permissionCache = nil
+	permissionCacheMu.Unlock()
+}
+
+// loadPermissionCache membaca ulang peta role -> permission jika cache kosong atau kedaluwarsa
+func loadPermissionCache() (map[string]map[string]bool, error) {
+	permissionCacheMu.RLock()
+	cache, loadedAt := permissionCache, permissionCacheLoadedAt
+	permissionCacheMu.RUnlock()
+	if cache != nil && time.Since(loadedAt) < permissionCacheTTL {
+		return cache, nil
+	}
+
+	repo := repository.NewRoleRepository()
+	codes, err := repo.PermissionCodesByRole()
+	if err != nil {
+		return nil, err
+	}
+	requiring, err := repo.RolesRequiringTwoFactor()
+	if err != nil {
+		return nil, err
+	}
+	required := make(map[string]bool, len(requiring))
+	for _, role := range requiring {
+		required[role] = true
+	}
+	cache = make(map[string]map[string]bool, len(codes)+1)
+	for role, list := range codes {
+		cache[role] = map[string]bool{}
+		for _, code := range list {
+			cache[role][code] = true
+		}
+	}
+	cache[models.RoleDevice] = map[string]bool{}
+	for _, code := range devicePermissions {
+		cache[models.RoleDevice][code] = true
+	}
+
+	permissionCacheMu.Lock()
+	permissionCache, twoFactorRoles, permissionCacheLoadedAt = cache, required, time.Now()
+	permissionCacheMu.Unlock()
+	return cache, nil
+}
+
+// HasPermission: Apakah role memiliki permission tersebut
+func HasPermission(role, permission string) (bool, error) {
+	cache, err := loadPermissionCache()
+	if err != nil {
+		return false, err
+	}
+	return cache[role][permission], nil
+}
+
+// RoleRequiresTwoFactor: Apakah user dengan role ini wajib login dengan 2FA
+func RoleRequiresTwoFactor(role string) (bool, error) {
+	if _, err := loadPermissionCache(); err != nil {
+		return false, err
+	}
+	permissionCacheMu.RLock()
+	defer permissionCacheMu.RUnlock()
+	return twoFactorRoles[role], nil
+}
+
+// checkGrantable menolak pemberian permission yang tidak dimiliki actor sendiri, agar pemegang
+// roles.manage tidak bisa menaikkan hak aksesnya lewat role lain (mis. memberi dirinya role admin).
+func checkGrantable(actor Actor, permissions []models.Permission) error {
+	if actor.Role == models.RoleAdmin {
+		return nil
+	}
+	for _, p := range permissions {
+		ok, err := HasPermission(actor.Role, p.Code)
+		if err != nil {
+			return errors.New("gagal memeriksa permission")
+		}
+		if !ok {
+			return fmt.Errorf("tidak bisa memberikan permission '%s' yang tidak Anda miliki", p.Code)
+		}
+	}
+	return nil
+}
+
+// AssignableRole memastikan role ada dan bisa diberikan ke akun staff (bukan member)
+func (s *RoleService) AssignableRole(name string) (*models.Role, error) {
+	role, err := s.repo.FindByName(name)
+	if err != nil || role == nil {
+		return nil, errors.New("role tidak ditemukan")
+	}
+	if !models.IsStaffRole(role.Name) {
+		return nil, errors.New("role member tidak bisa diberikan ke akun staff")
+	}
+	return role, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var sessionRepo = repository.NewSessionRepository()
+
+// SessionClient: Informasi perangkat yang membuka/memakai sesi, diisi handler dari request
+type SessionClient struct {
+	DeviceName string // Nama perangkat dari client (opsional)
+	UserAgent  string
+	IP         string
+}
+
+// deviceName: Nama perangkat dari client, atau User-Agent jika kosong
+func (c SessionClient) deviceName() string {
+	if c.DeviceName != "" {
+		return truncate(c.DeviceName, 100)
+	}
+	return truncate(c.UserAgent, 100)
+}
+
+// truncate memotong string ke maksimal n karakter (aman untuk UTF-8)
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// RefreshTokenService menukar refresh token dengan pasangan token baru (rotasi). Refresh token yang
+// sudah pernah dirotasi lalu dipakai lagi berarti bocor: seluruh keluarga sesinya dicabut.
+func RefreshTokenService(refreshToken string, client SessionClient) (string, string, error) {
+	invalid := errors.New("refresh token tidak valid atau kadaluarsa")
+
+	session, err := sessionRepo.FindByTokenHash(hashToken(refreshToken))
+	if err != nil || session == nil {
+		return "", "", invalid
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil {
+		if session.RevokedReason == models.SessionRevokedRotated {
+			revokeReusedFamily(session, now)
+		}
+		return "", "", errors.New("refresh token dicabut")
+	}
+	if now.After(session.ExpiresAt) {
+		return "", "", invalid
+	}
+
+	user, err := authRepo.FindByID(session.UserID)
+	if err != nil {
+		return "", "", errors.New("user tidak ditemukan")
+	}
+	if !user.IsActive {
+		return "", "", errors.New("akun tidak aktif")
+	}
+	if user.EmailVerifiedAt == nil && !UnverifiedLoginAllowed() {
+		return "", "", errors.New("email belum diverifikasi")
+	}
+
+	newRefreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", errors.New("gagal membuat token baru")
+	}
+	next := *session
+	next.ID = uuid.New()
+	next.TokenHash = hashToken(newRefreshToken)
+	next.LastUsedAt = now
+	next.ExpiresAt = now.Add(refreshTokenTTL())
+	next.CreatedAt = now
+	if client.UserAgent != "" {
+		next.UserAgent = truncate(client.UserAgent, 255)
+	}
+	if client.IP != "" {
+		next.IPAddress = client.IP
+	}
+
+	rotated, err := sessionRepo.Rotate(session, &next)
+	if err != nil {
+		return "", "", errors.New("gagal membuat token baru")
+	}
+	if !rotated {
+		// Token yang sama baru saja dirotasi oleh request lain
+		revokeReusedFamily(session, now)
+		return "", "", errors.New("refresh token dicabut")
+	}
+
+	accessToken, err := GenerateAccessToken(user, &next)
+	if err != nil {
+		return "", "", errors.New("gagal membuat token baru")
+	}
+	return accessToken, newRefreshToken, nil
+}
+
+func revokeReusedFamily(session *models.Session, now time.Time) {
+	log.Printf("Refresh token lama dipakai ulang untuk user %s; keluarga sesi %s dicabut", session.UserID, session.FamilyID)
+	if _, err := sessionRepo.RevokeFamily(session.UserID, session.FamilyID, models.SessionRevokedReuse, now); err != nil {
+		log.Println("Gagal mencabut keluarga sesi:", err)
+	}
+}
+
+// LogoutService mencabut sesi yang sedang dipakai. Token lama tanpa sesi (sid kosong) mencabut semua sesi user.
+func LogoutService(userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		_, err := sessionRepo.RevokeAllByUser(userID, models.SessionRevokedLogout, time.Now())
+		return err
+	}
+	_, err := sessionRepo.RevokeFamily(userID, sessionID, models.SessionRevokedLogout, time.Now())
+	return err
+}
+
+// SessionActive: Apakah keluarga sesi (sid pada access token) masih berlaku. Dipanggil AuthMiddleware.
+func SessionActive(sessionID uuid.UUID) (bool, error) {
+	return sessionRepo.IsFamilyActive(sessionID, time.Now())
+}
+
+// revokeAllSessions mencabut semua sesi user, mis. setelah password atau 2FA berubah
+func revokeAllSessions(userID uuid.UUID, reason string) {
+	if _, err := sessionRepo.RevokeAllByUser(userID, reason, time.Now()); err != nil {
+		log.Println("Gagal mencabut sesi user:", err)
+	}
+}
+
+type SessionService struct {
+	repo repository.SessionRepository
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{repo: repository.NewSessionRepository()}
+}
+
+// GetSessions: Sesi aktif milik user; sesi yang sedang dipakai ditandai Current
+func (s *SessionService) GetSessions(userID, currentSessionID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.repo.FindActiveByUser(userID, time.Now())
+	if err != nil {
+		return nil, errors.New("gagal mengambil daftar sesi")
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].FamilyID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi milik user sendiri (familyID dari daftar sesi)
+func (s *SessionService) RevokeSession(userID, familyID uuid.UUID, actor Actor) error {
+	count, err := s.repo.RevokeFamily(userID, familyID, models.SessionRevokedUser, time.Now())
+	if err != nil {
+		return errors.New("gagal mencabut sesi")
+	}
+	if count == 0 {
+		return errors.New("sesi tidak ditemukan")
+	}
+	recordAudit(actor, models.AuditActionDelete, AuditEntitySession, familyID, nil, nil)
+	return nil
+}
+
+// RevokeUserSessions mencabut semua sesi user lain, mis. perangkat hilang atau akun disalahgunakan (Admin).
+// member menentukan jenis akun target: true hanya member, false hanya staff/admin, agar route
+// /members/:id tidak bisa dipakai untuk mengeluarkan staff atau admin.
+func (s *SessionService) RevokeUserSessions(userID uuid.UUID, member bool, actor Actor) (int64, error) {
+	user, err := authRepo.FindByID(userID)
+	if err != nil || (user.Role == models.RoleMember) != member {
+		return 0, errors.New("user tidak ditemukan")
+	}
+	if err := checkAdminTarget(user, actor); err != nil {
+		return 0, err
+	}
+	count, err := s.repo.RevokeAllByUser(userID, models.SessionRevokedAdmin, time.Now())
+	if err != nil {
+		return 0, errors.New("gagal mencabut sesi")
+	}
+	if count > 0 {
+		recordAudit(actor, models.AuditActionDelete, AuditEntitySession, userID, nil, nil)
+	}
+	return count, nil
+}
+
+// PurgeStale menghapus keluarga sesi yang sudah kedaluwarsa lebih dari SESSION_RETENTION_DAYS (job harian).
+// Keluarga yang masih berlaku disimpan utuh, termasuk token lamanya, untuk deteksi pemakaian ulang.
+func (s *SessionService) PurgeStale(now time.Time, retentionDays int) (int64, error) {
+	return s.repo.DeleteBefore(now.AddDate(0, 0, -retentionDays))
+}
@@ -0,0 +1,583 @@
+package service
+
+import (
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status baris laporan jadwal vs aktual
+const (
+	ShiftStatusOnTime      = "on_time"
+	ShiftStatusLate        = "late"
+	ShiftStatusAbsent      = "absent"
+	ShiftStatusOpen        = "open" // Sudah clock-in, belum clock-out
+	ShiftStatusUpcoming    = "upcoming"
+	ShiftStatusUnscheduled = "unscheduled"
+)
+
+type ShiftService struct {
+	repo     repository.ShiftRepository
+	userRepo repository.AuthRepository
+}
+
+func NewShiftService() *ShiftService {
+	return &ShiftService{
+		repo:     repository.NewShiftRepository(),
+		userRepo: repository.NewAuthRepository(),
+	}
+}
+
+// lateGrace: Toleransi keterlambatan sebelum ditandai terlambat (STAFF_LATE_GRACE_MINUTES, default 5)
+func lateGrace() time.Duration {
+	return time.Duration(config.GetEnvInt("STAFF_LATE_GRACE_MINUTES", 5)) * time.Minute
+}
+
+// clockInEarlyWindow: Seberapa awal staff boleh clock-in untuk shift (STAFF_CLOCKIN_EARLY_MINUTES, default 60)
+func clockInEarlyWindow() time.Duration {
+	return time.Duration(config.GetEnvInt("STAFF_CLOCKIN_EARLY_MINUTES", 60)) * time.Minute
+}
+
+// maxShiftDuration: Clock-in lebih lama dari ini tanpa clock-out dianggap lupa clock-out
+// (STAFF_MAX_SHIFT_HOURS, default 16) dan harus dikoreksi admin
+func maxShiftDuration() time.Duration {
+	return time.Duration(config.GetEnvInt("STAFF_MAX_SHIFT_HOURS", 16)) * time.Hour
+}
+
+// lateMinutes: Menit keterlambatan dari jam mulai shift, 0 jika masih dalam toleransi
+func lateMinutes(shift *models.StaffShift, clockIn time.Time) int {
+	if shift == nil || clockIn.Sub(shift.StartTime) <= lateGrace() {
+		return 0
+	}
+	return int(clockIn.Sub(shift.StartTime).Minutes())
+}
+
+// isoDate: Tanggal (zona waktu gym) dalam format YYYY-MM-DD, sama dengan format query
+func isoDate(t time.Time) string {
+	return t.In(config.Location()).Format("2006-01-02")
+}
+
+func hoursBetween(start, end time.Time) float64 {
+	return math.Round(end.Sub(start).Hours()*100) / 100
+}
+
+// findStaff memastikan user adalah staff/admin aktif
+func (s *ShiftService) findStaff(id uuid.UUID) (*models.User, error) {
+	staff, err := s.userRepo.FindByID(id)
+	if err != nil || staff == nil || !models.IsStaffRole(staff.Role) {
+		return nil, errors.New("staff tidak ditemukan")
+	}
+	if !staff.IsActive {
+		return nil, errors.New("staff tidak aktif")
+	}
+	return staff, nil
+}
+
+// --- Roster Shift ---
+
+// WeekRoster: Shift seluruh staff dalam satu minggu (Senin - Minggu)
+type WeekRoster struct {
+	WeekStart string              `json:"weekStart"`
+	WeekEnd   string              `json:"weekEnd"`
+	Shifts    []models.StaffShift `json:"shifts"`
+}
+
+// weekStartParam: Senin dari minggu yang memuat tanggal (YYYY-MM-DD); default minggu ini
+func weekStartParam(value string) (time.Time, error) {
+	if value == "" {
+		return startOfWeek(time.Now()), nil
+	}
+	day := parseDateParam(value, false)
+	if day == nil {
+		return time.Time{}, errors.New("format tanggal minggu harus YYYY-MM-DD")
+	}
+	return startOfWeek(*day), nil
+}
+
+// GetWeek: Roster mingguan (Admin/Staff). Query: week (tanggal mana pun dalam minggu tsb), staff_id
+func (s *ShiftService) GetWeek(weekStr, staffIDStr string) (*WeekRoster, error) {
+	weekStart, err := weekStartParam(weekStr)
+	if err != nil {
+		return nil, err
+	}
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	var staffID *uuid.UUID
+	if staffIDStr != "" {
+		if id, err := uuid.Parse(staffIDStr); err == nil {
+			staffID = &id
+		}
+	}
+	shifts, err := s.repo.FindShifts(staffID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &WeekRoster{
+		WeekStart: isoDate(weekStart),
+		WeekEnd:   isoDate(weekEnd.AddDate(0, 0, -1)),
+		Shifts:    shifts,
+	}, nil
+}
+
+// validateShift memastikan jam shift valid dan tidak bentrok dengan shift lain milik staff yang sama
+func (s *ShiftService) validateShift(shift *models.StaffShift) error {
+	if !shift.EndTime.After(shift.StartTime) {
+		return errors.New("jam selesai shift harus setelah jam mulai")
+	}
+	if shift.EndTime.Sub(shift.StartTime) > 24*time.Hour {
+		return errors.New("durasi shift maksimal 24 jam")
+	}
+
+	var excludeID *uuid.UUID
+	if shift.ID != uuid.Nil {
+		excludeID = &shift.ID
+	}
+	overlap, err := s.repo.HasOverlappingShift(shift.StaffID, shift.StartTime, shift.EndTime, excludeID)
+	if err != nil {
+		return errors.New("gagal memeriksa jadwal staff")
+	}
+	if overlap {
+		return errors.New("shift bentrok dengan shift lain milik staff ini")
+	}
+	return nil
+}
+
+// CreateShift menjadwalkan shift staff (Admin)
+func (s *ShiftService) CreateShift(input models.StaffShiftInput, actor Actor) (*models.StaffShift, error) {
+	staff, err := s.findStaff(input.StaffID)
+	if err != nil {
+		return nil, err
+	}
+
+	shift := models.StaffShift{
+		StaffID:     staff.ID,
+		StartTime:   input.StartTime,
+		EndTime:     input.EndTime,
+		Position:    input.Position,
+		Notes:       input.Notes,
+		CreatedByID: actor.UserID,
+	}
+	if err := s.validateShift(&shift); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateShift(&shift); err != nil {
+		return nil, errors.New("gagal menyimpan shift")
+	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityShift, shift.ID, nil, shift)
+	shift.Staff = *staff
+	return &shift, nil
+}
+
+// UpdateShift mengubah shift (Admin)
+func (s *ShiftService) UpdateShift(id uuid.UUID, input models.StaffShiftInput, actor Actor) (*models.StaffShift, error) {
+	shift, err := s.repo.FindShiftByID(id)
+	if err != nil || shift == nil {
+		return nil, errors.New("shift tidak ditemukan")
+	}
+	staff, err := s.findStaff(input.StaffID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *shift
+	shift.StaffID = staff.ID
+	shift.StartTime = input.StartTime
+	shift.EndTime = input.EndTime
+	shift.Position = input.Position
+	shift.Notes = input.Notes
+	if err := s.validateShift(shift); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateShift(shift); err != nil {
+		return nil, errors.New("gagal memperbarui shift")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityShift, shift.ID, before, shift)
+	shift.Staff = *staff
+	return shift, nil
+}
+
+// DeleteShift menghapus shift yang belum dimulai (Admin)
+func (s *ShiftService) DeleteShift(id uuid.UUID, actor Actor) error {
+	shift, err := s.repo.FindShiftByID(id)
+	if err != nil || shift == nil {
+		return errors.New("shift tidak ditemukan")
+	}
+	if !shift.StartTime.After(time.Now()) {
+		return errors.New("shift yang sudah berjalan tidak bisa dihapus")
+	}
+	if err := s.repo.DeleteShift(id); err != nil {
+		return errors.New("gagal menghapus shift")
+	}
+	recordAudit(actor, models.AuditActionDelete, AuditEntityShift, shift.ID, shift, nil)
+	return nil
+}
+
+// CopyWeek menyalin roster satu minggu ke minggu lain (Admin). Shift yang bentrok di minggu tujuan dilewati.
+func (s *ShiftService) CopyWeek(input models.CopyShiftWeekInput, actor Actor) (int, int, error) {
+	fromWeek, err := weekStartParam(input.FromWeek)
+	if err != nil {
+		return 0, 0, err
+	}
+	toWeek, err := weekStartParam(input.ToWeek)
+	if err != nil {
+		return 0, 0, err
+	}
+	if fromWeek.Equal(toWeek) {
+		return 0, 0, errors.New("minggu asal dan tujuan tidak boleh sama")
+	}
+
+	shifts, err := s.repo.FindShifts(nil, fromWeek, fromWeek.AddDate(0, 0, 7))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Geser per hari kalender agar jam dinding tetap sama
+	days := int(math.Round(toWeek.Sub(fromWeek).Hours() / 24))
+	created, skipped := 0, 0
+	for _, source := range shifts {
+		if !source.Staff.IsActive {
+			skipped++
+			continue
+		}
+		shift := models.StaffShift{
+			StaffID:     source.StaffID,
+			StartTime:   source.StartTime.In(config.Location()).AddDate(0, 0, days),
+			EndTime:     source.EndTime.In(config.Location()).AddDate(0, 0, days),
+			Position:    source.Position,
+			Notes:       source.Notes,
+			CreatedByID: actor.UserID,
+		}
+		if err := s.validateShift(&shift); err != nil {
+			skipped++
+			continue
+		}
+		if err := s.repo.CreateShift(&shift); err != nil {
+			return created, skipped, errors.New("gagal menyalin shift")
+		}
+		recordAudit(actor, models.AuditActionCreate, AuditEntityShift, shift.ID, nil, shift)
+		created++
+	}
+	return created, skipped, nil
+}
+
+// --- Time Clock ---
+
+// ClockIn mencatat jam masuk staff (actor) dan mencocokkannya dengan shift terjadwal (jika ada)
+func (s *ShiftService) ClockIn(actor Actor) (*models.TimeClockEntry, error) {
+	staff, err := s.findStaff(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pemeriksaan clock-in terbuka + penyimpanan diserialkan per staff di semua replika
+	now := time.Now()
+	var entry models.TimeClockEntry
+	var shift *models.StaffShift
+	var trail auditTrail
+	err = s.repo.Transaction(func(repo repository.ShiftRepository) error {
+		if err := repo.LockTimeClock(staff.ID); err != nil {
+			return errors.New("gagal memeriksa status clock-in")
+		}
+		open, err := repo.FindOpenEntry(staff.ID, now.Add(-maxShiftDuration()))
+		if err != nil {
+			return errors.New("gagal memeriksa status clock-in")
+		}
+		if open != nil {
+			return errors.New("anda sudah clock-in dan belum clock-out")
+		}
+
+		shift, err = repo.FindShiftForClockIn(staff.ID, now, clockInEarlyWindow())
+		if err != nil {
+			return errors.New("gagal mencari shift")
+		}
+
+		entry = models.TimeClockEntry{
+			StaffID:     staff.ID,
+			ClockInTime: now,
+			LateMinutes: lateMinutes(shift, now),
+		}
+		if shift != nil {
+			entry.ShiftID = &shift.ID
+		}
+		if err := repo.CreateEntry(&entry); err != nil {
+			return errors.New("gagal menyimpan clock-in")
+		}
+		trail.add(models.AuditActionCreate, AuditEntityTimeClock, entry.ID, nil, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	entry.Staff = *staff
+	entry.Shift = shift
+	return &entry, nil
+}
+
+// ClockOut mencatat jam pulang untuk clock-in staff (actor) yang masih terbuka
+func (s *ShiftService) ClockOut(actor Actor) (*models.TimeClockEntry, error) {
+	now := time.Now()
+	var entryID uuid.UUID
+	var trail auditTrail
+	err := s.repo.Transaction(func(repo repository.ShiftRepository) error {
+		if err := repo.LockTimeClock(actor.UserID); err != nil {
+			return errors.New("gagal memeriksa status clock-in")
+		}
+		open, err := repo.FindOpenEntry(actor.UserID, now.Add(-maxShiftDuration()))
+		if err != nil {
+			return errors.New("gagal memeriksa status clock-in")
+		}
+		if open == nil {
+			return errors.New("anda belum clock-in")
+		}
+
+		before := *open
+		open.ClockOutTime = &now
+		if err := repo.UpdateEntry(open); err != nil {
+			return errors.New("gagal menyimpan clock-out")
+		}
+		trail.add(models.AuditActionUpdate, AuditEntityTimeClock, open.ID, before, open)
+		entryID = open.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	trail.record(actor)
+	return s.repo.FindEntryByID(entryID)
+}
+
+// AdjustEntry: Admin mengoreksi jam clock-in/clock-out (mis. staff lupa clock-out)
+func (s *ShiftService) AdjustEntry(id uuid.UUID, input models.AdjustTimeClockInput, actor Actor) (*models.TimeClockEntry, error) {
+	entry, err := s.repo.FindEntryByID(id)
+	if err != nil || entry == nil {
+		return nil, errors.New("catatan clock-in tidak ditemukan")
+	}
+
+	before := *entry
+	if input.ClockInTime != nil {
+		entry.ClockInTime = *input.ClockInTime
+	}
+	if input.ClockOutTime != nil {
+		entry.ClockOutTime = input.ClockOutTime
+	}
+	if entry.ClockOutTime != nil && !entry.ClockOutTime.After(entry.ClockInTime) {
+		return nil, errors.New("jam clock-out harus setelah jam clock-in")
+	}
+	if entry.ClockOutTime != nil && entry.ClockOutTime.After(time.Now()) {
+		return nil, errors.New("jam clock-out tidak boleh di masa depan")
+	}
+
+	entry.LateMinutes = lateMinutes(entry.Shift, entry.ClockInTime)
+	entry.AdjustedByID = &actor.UserID
+	entry.AdjustNote = input.Note
+	if err := s.repo.UpdateEntry(entry); err != nil {
+		return nil, errors.New("gagal menyimpan koreksi")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityTimeClock, entry.ID, before, entry)
+	return entry, nil
+}
+
+// GetEntries: Catatan clock-in (Admin/Staff). Query: staff_id, date_from, date_to
+func (s *ShiftService) GetEntries(staffIDStr, dateFromStr, dateToStr string) ([]models.TimeClockEntry, error) {
+	var staffID *uuid.UUID
+	if staffIDStr != "" {
+		if id, err := uuid.Parse(staffIDStr); err == nil {
+			staffID = &id
+		}
+	}
+	return s.repo.FindEntries(staffID, parseDateParam(dateFromStr, false), parseDateParam(dateToStr, true))
+}
+
+// GetMyEntries: Catatan clock-in milik staff yang sedang login
+func (s *ShiftService) GetMyEntries(staffID uuid.UUID, dateFromStr, dateToStr string) ([]models.TimeClockEntry, error) {
+	return s.repo.FindEntries(&staffID, parseDateParam(dateFromStr, false), parseDateParam(dateToStr, true))
+}
+
+// --- Laporan Jadwal vs Aktual ---
+
+// ShiftReportRow: Satu shift terjadwal (atau clock-in di luar jadwal) beserta jam aktualnya
+type ShiftReportRow struct {
+	Date           string     `json:"date"`
+	ShiftID        *uuid.UUID `json:"shiftId"`
+	EntryID        *uuid.UUID `json:"entryId"`
+	ScheduledStart *time.Time `json:"scheduledStart"`
+	ScheduledEnd   *time.Time `json:"scheduledEnd"`
+	ClockIn        *time.Time `json:"clockIn"`
+	ClockOut       *time.Time `json:"clockOut"`
+	ScheduledHours float64    `json:"scheduledHours"`
+	ActualHours    float64    `json:"actualHours"`
+	Late           bool       `json:"late"`
+	LateMinutes    int        `json:"lateMinutes"`
+	Status         string     `json:"status"`
+}
+
+// StaffShiftSummary: Rekap jam terjadwal vs aktual satu staff
+type StaffShiftSummary struct {
+	StaffID          uuid.UUID        `json:"staffId"`
+	StaffName        string           `json:"staffName"`
+	ScheduledHours   float64          `json:"scheduledHours"`
+	ActualHours      float64          `json:"actualHours"`
+	DifferenceHours  float64          `json:"differenceHours"`
+	ShiftCount       int              `json:"shiftCount"`
+	LateCount        int              `json:"lateCount"`
+	LateMinutes      int              `json:"lateMinutes"`
+	AbsentCount      int              `json:"absentCount"`
+	UnscheduledCount int              `json:"unscheduledCount"`
+	Rows             []ShiftReportRow `json:"rows"`
+}
+
+type ShiftReport struct {
+	From  string              `json:"from"`
+	To    string              `json:"to"`
+	Staff []StaffShiftSummary `json:"staff"`
+}
+
+// GetReport: Jam terjadwal vs aktual per staff (Admin). Tanpa tanggal: minggu berjalan.
+func (s *ShiftService) GetReport(dateFromStr, dateToStr, staffIDStr string) (*ShiftReport, error) {
+	from := startOfWeek(time.Now())
+	to := from.AddDate(0, 0, 7)
+	if parsed := parseDateParam(dateFromStr, false); parsed != nil {
+		from = *parsed
+	}
+	if parsed := parseDateParam(dateToStr, false); parsed != nil {
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return nil, errors.New("tanggal akhir harus setelah tanggal awal")
+	}
+
+	var staffID *uuid.UUID
+	if staffIDStr != "" {
+		if id, err := uuid.Parse(staffIDStr); err == nil {
+			staffID = &id
+		}
+	}
+
+	shifts, err := s.repo.FindShifts(staffID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	// Clock-in yang terkait shift dalam periode bisa sedikit sebelum `from` (clock-in lebih awal)
+	entryFrom := from.Add(-clockInEarlyWindow())
+	entryTo := to.Add(-time.Nanosecond)
+	entries, err := s.repo.FindEntries(staffID, &entryFrom, &entryTo)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := map[uuid.UUID]*StaffShiftSummary{}
+	summaryFor := func(user *models.User) *StaffShiftSummary {
+		if summary, ok := summaries[user.ID]; ok {
+			return summary
+		}
+		summary := &StaffShiftSummary{StaffID: user.ID, StaffName: user.Name, Rows: []ShiftReportRow{}}
+		summaries[user.ID] = summary
+		return summary
+	}
+
+	entryByShift := map[uuid.UUID]*models.TimeClockEntry{}
+	for i := range entries {
+		if entries[i].ShiftID != nil {
+			entryByShift[*entries[i].ShiftID] = &entries[i]
+		}
+	}
+
+	now := time.Now()
+	for i := range shifts {
+		shift := &shifts[i]
+		shiftID := shift.ID
+		row := ShiftReportRow{
+			Date:           isoDate(shift.StartTime),
+			ShiftID:        &shiftID,
+			ScheduledStart: &shift.StartTime,
+			ScheduledEnd:   &shift.EndTime,
+			ScheduledHours: hoursBetween(shift.StartTime, shift.EndTime),
+		}
+		applyEntry(&row, entryByShift[shift.ID])
+
+		switch {
+		case row.EntryID != nil && row.ClockOut == nil:
+			row.Status = ShiftStatusOpen
+		case row.EntryID != nil && row.Late:
+			row.Status = ShiftStatusLate
+		case row.EntryID != nil:
+			row.Status = ShiftStatusOnTime
+		case shift.EndTime.After(now):
+			row.Status = ShiftStatusUpcoming
+		default:
+			row.Status = ShiftStatusAbsent
+		}
+
+		summary := summaryFor(&shift.Staff)
+		summary.ShiftCount++
+		summary.ScheduledHours += row.ScheduledHours
+		if row.Status == ShiftStatusAbsent {
+			summary.AbsentCount++
+		}
+		addActual(summary, &row)
+	}
+
+	// Clock-in tanpa shift terjadwal
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ShiftID != nil || entry.ClockInTime.Before(from) {
+			continue
+		}
+		row := ShiftReportRow{Date: isoDate(entry.ClockInTime), Status: ShiftStatusUnscheduled}
+		applyEntry(&row, entry)
+
+		summary := summaryFor(&entry.Staff)
+		summary.UnscheduledCount++
+		addActual(summary, &row)
+	}
+
+	report := &ShiftReport{From: isoDate(from), To: isoDate(to.AddDate(0, 0, -1)), Staff: []StaffShiftSummary{}}
+	for _, summary := range summaries {
+		sort.Slice(summary.Rows, func(i, j int) bool { return rowStart(&summary.Rows[i]).Before(rowStart(&summary.Rows[j])) })
+		summary.ScheduledHours = math.Round(summary.ScheduledHours*100) / 100
+		summary.ActualHours = math.Round(summary.ActualHours*100) / 100
+		summary.DifferenceHours = math.Round((summary.ActualHours-summary.ScheduledHours)*100) / 100
+		report.Staff = append(report.Staff, *summary)
+	}
+	sort.Slice(report.Staff, func(i, j int) bool { return report.Staff[i].StaffName < report.Staff[j].StaffName })
+	return report, nil
+}
+
+// applyEntry mengisi jam aktual baris laporan dari catatan clock-in
+func applyEntry(row *ShiftReportRow, entry *models.TimeClockEntry) {
+	if entry == nil {
+		return
+	}
+	entryID := entry.ID
+	row.EntryID = &entryID
+	row.ClockIn = &entry.ClockInTime
+	row.ClockOut = entry.ClockOutTime
+	row.LateMinutes = entry.LateMinutes
+	row.Late = entry.LateMinutes > 0
+	if entry.ClockOutTime != nil {
+		row.ActualHours = hoursBetween(entry.ClockInTime, *entry.ClockOutTime)
+	}
+}
+
+func addActual(summary *StaffShiftSummary, row *ShiftReportRow) {
+	summary.ActualHours += row.ActualHours
+	if row.Late {
+		summary.LateCount++
+		summary.LateMinutes += row.LateMinutes
+	}
+	summary.Rows = append(summary.Rows, *row)
+}
+
+func rowStart(row *ShiftReportRow) time.Time {
+	if row.ScheduledStart != nil {
+		return *row.ScheduledStart
+	}
+	return *row.ClockIn
+}
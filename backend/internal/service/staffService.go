@@ -5,6 +5,7 @@ import (
 	"gym_management/config"
 	"gym_management/internal/models"
 	"gym_management/internal/repository"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,21 +18,21 @@ func NewStaffService() *StaffService {
 	return &StaffService{repo: repository.NewAuthRepository()}
 }
 
-// GetStaffs mengambil semua user dengan role 'staff'
+// GetStaffs mengambil semua user karyawan (role selain 'member')
 func (s *StaffService) GetStaffs() ([]models.User, error) {
 	if config.DB == nil {
 		return nil, errors.New("database connection not established")
 	}
 	var staffs []models.User
 	// Gorm Find with Where clause for role
-	if err := config.DB.Where("role IN (?)", []string{"staff", "admin"}).Find(&staffs).Error; err != nil {
+	if err := config.DB.Where("role <> ?", models.RoleMember).Find(&staffs).Error; err != nil {
 		return nil, err
 	}
 	return staffs, nil
 }
 
 // CreateStaff
-func (s *StaffService) CreateStaff(input models.RegisterInput, role string) (*models.User, error) {
+func (s *StaffService) CreateStaff(input models.RegisterInput, role string, actor Actor) (*models.User, error) {
 	existingUser, _ := s.repo.FindByEmail(input.Email)
 	if existingUser != nil {
 		return nil, errors.New("email sudah terdaftar")
@@ -42,45 +43,156 @@ func (s *StaffService) CreateStaff(input models.RegisterInput, role string) (*mo
 		return nil, errors.New("gagal hash password")
 	}
 
+	now := time.Now()
 	newStaff := models.User{
 		ID:           uuid.New(),
 		Name:         input.Name,
 		Email:        input.Email,
 		PasswordHash: hashedPassword,
-		Role:         role, // Bisa 'staff' atau 'admin'
+		Role:         role, // 'staff', 'admin' atau role karyawan lain
 		IsActive:     true,
+		// Akun karyawan dibuat admin sehingga email dianggap sudah diverifikasi
+		EmailVerifiedAt: &now,
 	}
 
 	if err := s.repo.Create(&newStaff); err != nil {
 		return nil, err
 	}
+	recordAudit(actor, models.AuditActionCreate, AuditEntityStaff, newStaff.ID, nil, newStaff)
 	return &newStaff, nil
 }
 
 // UpdateStaff
-func (s *StaffService) UpdateStaff(id uuid.UUID, input models.RegisterInput) (*models.User, error) {
+func (s *StaffService) UpdateStaff(id uuid.UUID, input models.RegisterInput, actor Actor) (*models.User, error) {
 	if s.repo == nil {
 		return nil, errors.New("repository not initialized")
 	}
 	staff, err := s.repo.FindByID(id)
-	if err != nil || staff == nil || (staff.Role != "staff" && staff.Role != "admin") {
+	if err != nil || staff == nil || !models.IsStaffRole(staff.Role) {
 		return nil, errors.New("staff/admin tidak ditemukan")
 	}
+	if err := checkAdminTarget(staff, actor); err != nil {
+		return nil, err
+	}
 
+	before := *staff
 	staff.Name = input.Name
 	staff.Email = input.Email // Hati-hati mengubah email, bisa melanggar unique constraint
 
 	if err := s.repo.Update(staff); err != nil {
 		return nil, errors.New("gagal memperbarui staff")
 	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityStaff, staff.ID, before, staff)
 	return staff, nil
 }
 
 // DeleteStaff
-func (s *StaffService) DeleteStaff(id uuid.UUID) error {
+func (s *StaffService) DeleteStaff(id uuid.UUID, actor Actor) error {
 	if config.DB == nil {
 		return errors.New("database connection not established")
 	}
-	// Pastikan tidak menghapus diri sendiri atau admin utama (opsional)
-	return config.DB.Where("id = ? AND role IN (?)", id, []string{"staff", "admin"}).Delete(&models.User{}).Error
+	// Snapshot untuk audit log; error diabaikan karena id yang tidak ada tidak dianggap gagal
+	staff, _ := s.repo.FindByID(id)
+	if staff != nil && staff.Role == models.RoleAdmin {
+		if err := checkAdminTarget(staff, actor); err != nil {
+			return err
+		}
+		if err := ensureAnotherAdmin(); err != nil {
+			return err
+		}
+	}
+	if err := config.DB.Where("id = ? AND role <> ?", id, models.RoleMember).Delete(&models.User{}).Error; err != nil {
+		return err
+	}
+	if staff != nil && models.IsStaffRole(staff.Role) {
+		revokeAllSessions(id, models.SessionRevokedAdmin)
+		recordAudit(actor, models.AuditActionDelete, AuditEntityStaff, id, staff, nil)
+	}
+	return nil
+}
+
+// SetTrainer menandai staff/admin sebagai trainer personal training
+func (s *StaffService) SetTrainer(id uuid.UUID, isTrainer bool, actor Actor) (*models.User, error) {
+	staff, err := s.repo.FindByID(id)
+	if err != nil || staff == nil || !models.IsStaffRole(staff.Role) {
+		return nil, errors.New("staff/admin tidak ditemukan")
+	}
+	if err := checkAdminTarget(staff, actor); err != nil {
+		return nil, err
+	}
+
+	before := *staff
+	staff.IsTrainer = isTrainer
+	if err := s.repo.Update(staff); err != nil {
+		return nil, errors.New("gagal memperbarui staff")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityStaff, staff.ID, before, staff)
+	return staff, nil
+}
+
+// GetTrainers mengambil staff/admin aktif yang menerima sesi personal training
+func (s *StaffService) GetTrainers() ([]models.User, error) {
+	if config.DB == nil {
+		return nil, errors.New("database connection not established")
+	}
+	var trainers []models.User
+	if err := config.DB.Where("role <> ? AND is_trainer = ? AND is_active = ?", models.RoleMember, true, true).
+		Order("name ASC").Find(&trainers).Error; err != nil {
+		return nil, err
+	}
+	return trainers, nil
+}
+
+// SetRole memberikan role karyawan ke staff (roles.manage). Actor hanya bisa memberikan role yang
+// permission-nya ia miliki sendiri, dan admin terakhir tidak bisa diturunkan.
+func (s *StaffService) SetRole(id uuid.UUID, roleName string, actor Actor) (*models.User, error) {
+	staff, err := s.repo.FindByID(id)
+	if err != nil || staff == nil || !models.IsStaffRole(staff.Role) {
+		return nil, errors.New("staff/admin tidak ditemukan")
+	}
+	if err := checkAdminTarget(staff, actor); err != nil {
+		return nil, err
+	}
+	role, err := NewRoleService().AssignableRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(actor, role.Permissions); err != nil {
+		return nil, err
+	}
+
+	if staff.Role == models.RoleAdmin && role.Name != models.RoleAdmin {
+		if err := ensureAnotherAdmin(); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *staff
+	staff.Role = role.Name
+	if err := s.repo.Update(staff); err != nil {
+		return nil, errors.New("gagal memperbarui role staff")
+	}
+	recordAudit(actor, models.AuditActionUpdate, AuditEntityStaff, staff.ID, before, staff)
+	return staff, nil
+}
+
+// checkAdminTarget: Akun admin hanya boleh diubah, dihapus, direset 2FA-nya atau dikeluarkan dari
+// sesinya oleh admin; staff.manage saja tidak cukup.
+func checkAdminTarget(target *models.User, actor Actor) error {
+	if target.Role == models.RoleAdmin && actor.Role != models.RoleAdmin {
+		return errors.New("hanya admin yang bisa mengubah akun admin")
+	}
+	return nil
+}
+
+// ensureAnotherAdmin menolak perubahan yang menyisakan sistem tanpa admin aktif
+func ensureAnotherAdmin() error {
+	var admins int64
+	if err := config.DB.Model(&models.User{}).Where("role = ? AND is_active = ?", models.RoleAdmin, true).Count(&admins).Error; err != nil {
+		return errors.New("gagal memeriksa jumlah admin")
+	}
+	if admins <= 1 {
+		return errors.New("tidak bisa mengubah atau menghapus admin terakhir")
+	}
+	return nil
 }
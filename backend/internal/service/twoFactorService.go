@@ -0,0 +1,450 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"gym_management/config"
+	"gym_management/internal/models"
+	"gym_management/internal/repository"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	twoFactorChallengePurpose = "two_factor_challenge"
+	totpEncryptionPurpose     = "totp_secret_encryption"
+	totpSecretPrefix          = "v1:" // Secret TOTP terenkripsi AES-GCM (base64 nonce+ciphertext)
+	totpPeriod                = 30    // detik
+	recoveryCodeCount         = 10
+)
+
+var totpOptions = totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+type twoFactorChallengeClaims struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Purpose string    `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// TwoFactorEnrollment: Data untuk didaftarkan ke aplikasi authenticator
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+	QRCode     string `json:"qrCode"` // data URI PNG dari OTPAuthURL
+}
+
+// TwoFactorStatus: Status 2FA milik user yang sedang login
+type TwoFactorStatus struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+type TwoFactorService struct {
+	repo  repository.TwoFactorRepository
+	users repository.AuthRepository
+}
+
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{repo: repository.NewTwoFactorRepository(), users: repository.NewAuthRepository()}
+}
+
+// GetStatus: Status 2FA user
+func (s *TwoFactorService) GetStatus(userID uuid.UUID) (*TwoFactorStatus, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	required, err := RoleRequiresTwoFactor(user.Role)
+	if err != nil {
+		return nil, errors.New("gagal memeriksa kebijakan 2FA")
+	}
+	status := &TwoFactorStatus{Enabled: user.TwoFactorEnabled, Required: required}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesLeft, err = s.repo.CountUnusedRecoveryCodes(userID); err != nil {
+			return nil, errors.New("gagal menghitung kode cadangan")
+		}
+	}
+	return status, nil
+}
+
+// Enroll membuat secret TOTP baru (belum aktif sampai dikonfirmasi lewat Confirm).
+// Enroll ulang sebelum konfirmasi mengganti secret sebelumnya.
+func (s *TwoFactorService) Enroll(userID uuid.UUID) (*TwoFactorEnrollment, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Gym Management"
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, errors.New("gagal membuat secret 2FA")
+	}
+
+	png, err := qrcode.Encode(key.URL(), qrcode.Medium, 256)
+	if err != nil {
+		return nil, errors.New("gagal membuat QR code")
+	}
+
+	encrypted, err := encryptTOTPSecret(key.Secret())
+	if err != nil {
+		return nil, errors.New("gagal menyimpan secret 2FA")
+	}
+	user.TOTPSecret = encrypted
+	user.TOTPLastStep = 0
+	if err := s.users.Update(user); err != nil {
+		return nil, errors.New("gagal menyimpan secret 2FA")
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm mengaktifkan 2FA setelah kode pertama dari authenticator cocok. Sesi lama (tanpa 2FA)
+// dicabut; dikembalikan kode cadangan (hanya ditampilkan sekali) dan token sesi baru berstatus 2FA.
+func (s *TwoFactorService) Confirm(userID uuid.UUID, code string, actor Actor, client SessionClient) ([]string, string, string, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, "", "", errors.New("user tidak ditemukan")
+	}
+	if user.TwoFactorEnabled {
+		return nil, "", "", errors.New("2FA sudah aktif")
+	}
+	if user.TOTPSecret == "" {
+		return nil, "", "", errors.New("lakukan enrollment 2FA terlebih dahulu")
+	}
+	if ok, err := s.verifyTOTP(user, code); err != nil || !ok {
+		return nil, "", "", errors.New("kode 2FA tidak valid")
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	user.TwoFactorEnabled = true
+	if err := s.users.Update(user); err != nil {
+		return nil, "", "", errors.New("gagal mengaktifkan 2FA")
+	}
+	recordAudit(actor, models.AuditActionTwoFactorOn, AuditEntityMember, user.ID, nil, nil)
+	revokeAllSessions(user.ID, models.SessionRevokedAuth)
+
+	accessToken, refreshToken, err := issueTokens(user, true, client)
+	if err != nil {
+		return nil, "", "", errors.New("gagal membuat token baru")
+	}
+	return codes, accessToken, refreshToken, nil
+}
+
+// Disable mematikan 2FA milik sendiri (butuh password dan kode TOTP/cadangan).
+// Tidak diizinkan jika role user mewajibkan 2FA.
+func (s *TwoFactorService) Disable(userID uuid.UUID, password, code string, actor Actor) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("2FA belum aktif")
+	}
+	if required, err := RoleRequiresTwoFactor(user.Role); err != nil || required {
+		return errors.New("role Anda mewajibkan 2FA sehingga tidak bisa dinonaktifkan")
+	}
+	if !CheckPasswordHash(password, user.PasswordHash) {
+		return errors.New("password salah")
+	}
+	if ok, err := s.verifySecondFactor(user, code); err != nil || !ok {
+		return errors.New("kode 2FA tidak valid")
+	}
+	return s.clear(user, actor)
+}
+
+// Reset mematikan 2FA user lain, mis. staff yang kehilangan perangkat dan kode cadangan (Admin).
+// Jika role-nya mewajibkan 2FA, user harus enroll ulang pada login berikutnya.
+func (s *TwoFactorService) Reset(userID uuid.UUID, actor Actor) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if err := checkAdminTarget(user, actor); err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled && user.TOTPSecret == "" {
+		return errors.New("2FA belum aktif")
+	}
+	return s.clear(user, actor)
+}
+
+// RegenerateRecoveryCodes mengganti seluruh kode cadangan; butuh kode TOTP yang valid.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, code string, actor Actor) ([]string, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("2FA belum aktif")
+	}
+	if ok, err := s.verifyTOTP(user, code); err != nil || !ok {
+		return nil, errors.New("kode 2FA tidak valid")
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(actor, models.AuditActionRecoveryCodes, AuditEntityMember, user.ID, nil, nil)
+	return codes, nil
+}
+
+// VerifyLogin menyelesaikan login dua langkah: challenge token dari LoginService ditukar dengan
+// access/refresh token setelah kode TOTP (atau kode cadangan) cocok. Kode salah ikut dihitung
+// oleh throttle login akun & IP.
+func (s *TwoFactorService) VerifyLogin(challengeToken, code string, client SessionClient) (*models.User, string, string, error) {
+	invalid := errors.New("challenge 2FA tidak valid atau kadaluarsa")
+
+	claims := &twoFactorChallengeClaims{}
+	secret, err := twoFactorChallengeSecret()
+	if err != nil {
+		return nil, "", "", err
+	}
+	if err := parseToken(challengeToken, claims, secret); err != nil || claims.Purpose != twoFactorChallengePurpose {
+		return nil, "", "", invalid
+	}
+
+	user, err := s.users.FindByID(claims.UserID)
+	if err != nil || !user.IsActive || !user.TwoFactorEnabled {
+		return nil, "", "", invalid
+	}
+
+	now := time.Now()
+	ip := client.IP
+	if err := loginThrottle.Check(user.Email, ip, now); err != nil {
+		return nil, "", "", err
+	}
+	if ok, err := s.verifySecondFactor(user, code); err != nil || !ok {
+		if err := loginThrottle.RecordFailure(user.Email, ip, now); err != nil {
+			return nil, "", "", err
+		}
+		return nil, "", "", errors.New("kode 2FA tidak valid")
+	}
+	loginThrottle.Reset(user.Email)
+
+	accessToken, refreshToken, err := issueTokens(user, true, client)
+	if err != nil {
+		return nil, "", "", errors.New("gagal membuat token")
+	}
+	return user, accessToken, refreshToken, nil
+}
+
+func (s *TwoFactorService) clear(user *models.User, actor Actor) error {
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.users.Update(user); err != nil {
+		return errors.New("gagal menonaktifkan 2FA")
+	}
+	// Sesi yang login dengan 2FA lama harus login ulang
+	revokeAllSessions(user.ID, models.SessionRevokedAuth)
+	if err := s.repo.DeleteRecoveryCodes(user.ID); err != nil {
+		return errors.New("gagal menghapus kode cadangan")
+	}
+	recordAudit(actor, models.AuditActionTwoFactorOff, AuditEntityMember, user.ID, nil, nil)
+	return nil
+}
+
+// verifySecondFactor menerima kode TOTP 6 digit atau kode cadangan (sekali pakai)
+func (s *TwoFactorService) verifySecondFactor(user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == int(otp.DigitsSix) {
+		return s.verifyTOTP(user, code)
+	}
+	return s.repo.UseRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code)), time.Now())
+}
+
+// verifyTOTP mencocokkan kode pada periode sekarang ±1 (toleransi selisih jam). Periode yang cocok
+// dicatat agar kode yang sama tidak bisa dipakai ulang.
+func (s *TwoFactorService) verifyTOTP(user *models.User, code string) (bool, error) {
+	secret, err := decryptTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+	code = strings.TrimSpace(code)
+	now := time.Now()
+	for _, skew := range []int64{0, -1, 1} {
+		at := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, at, totpOptions)
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			step := at.Unix() / totpPeriod
+			consumed, err := s.repo.ConsumeStep(user.ID, step)
+			if consumed {
+				user.TOTPLastStep = step // agar Update(user) berikutnya tidak menimpa dengan nilai lama
+			}
+			return consumed, err
+		}
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes membuat set kode cadangan baru dan mengembalikan versi mentahnya
+func (s *TwoFactorService) replaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.New("gagal membuat kode cadangan")
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(b)) // 8 karakter
+		code := raw[:4] + "-" + raw[4:]
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	if err := s.repo.ReplaceRecoveryCodes(userID, records); err != nil {
+		return nil, errors.New("gagal menyimpan kode cadangan")
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// twoFactorKey menurunkan kunci 32 byte untuk `purpose` dari TWO_FACTOR_SECRET (wajib diisi)
+func twoFactorKey(purpose string) ([]byte, error) {
+	secret := os.Getenv("TWO_FACTOR_SECRET")
+	if secret == "" {
+		return nil, errors.New("TWO_FACTOR_SECRET belum diatur")
+	}
+	return hkdf.Key(sha256.New, []byte(secret), nil, "gym_management:"+purpose, 32)
+}
+
+// twoFactorChallengeSecret: Kunci HMAC challenge login, diturunkan dari TWO_FACTOR_SECRET
+func twoFactorChallengeSecret() (string, error) {
+	key, err := twoFactorKey(twoFactorChallengePurpose)
+	return string(key), err
+}
+
+func totpCipher() (cipher.AEAD, error) {
+	key, err := twoFactorKey(totpEncryptionPurpose)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptTOTPSecret mengenkripsi secret TOTP sebelum disimpan di users.totp_secret
+func encryptTOTPSecret(secret string) (string, error) {
+	aead, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return totpSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTOTPSecret membuka secret TOTP yang disimpan oleh encryptTOTPSecret
+func decryptTOTPSecret(stored string) (string, error) {
+	invalid := errors.New("secret 2FA tidak dapat dibaca")
+	if !strings.HasPrefix(stored, totpSecretPrefix) {
+		return "", invalid
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, totpSecretPrefix))
+	if err != nil {
+		return "", invalid
+	}
+	aead, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", invalid
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", invalid
+	}
+	return string(plain), nil
+}
+
+// EncryptLegacySecrets mengenkripsi secret TOTP yang tersimpan sebagai teks biasa (data sebelum
+// enkripsi diterapkan). Dipanggil saat startup; aman dijalankan berulang kali.
+func (s *TwoFactorService) EncryptLegacySecrets() (int, error) {
+	users, err := s.repo.FindLegacySecrets(totpSecretPrefix)
+	if err != nil {
+		return 0, err
+	}
+	encrypted := 0
+	for _, user := range users {
+		secret, err := encryptTOTPSecret(user.TOTPSecret)
+		if err != nil {
+			return encrypted, err
+		}
+		if err := s.repo.UpdateSecret(user.ID, secret); err != nil {
+			log.Println("Gagal mengenkripsi secret 2FA:", err)
+			continue
+		}
+		encrypted++
+	}
+	return encrypted, nil
+}
+
+// issueTwoFactorChallenge: Token berumur pendek (TWO_FACTOR_CHALLENGE_MINUTES, default 5) untuk langkah kedua login
+func issueTwoFactorChallenge(user *models.User) (string, error) {
+	now := time.Now()
+	ttl := time.Duration(config.GetEnvInt("TWO_FACTOR_CHALLENGE_MINUTES", 5)) * time.Minute
+	claims := &twoFactorChallengeClaims{
+		UserID:  user.ID,
+		Purpose: twoFactorChallengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	secret, err := twoFactorChallengeSecret()
+	if err != nil {
+		return "", err
+	}
+	return signToken(claims, secret)
+}